@@ -0,0 +1,67 @@
+package dash
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Adobe AIR apps packaged with a captive runtime keep their app descriptor
+// in META-INF/AIR/application.xml, next to the launcher. Apps meant to be
+// run with the AIR SDK's debug launcher have a `*-app.xml` descriptor instead.
+const airCaptiveDescriptor = "meta-inf/air/application.xml"
+
+var airDescriptorRegexp = regexp.MustCompile(`(?i)-app\.xml$`)
+
+// The debug launcher isn't the game, even when it's next to a descriptor
+var airDebugLauncherRegexp = regexp.MustCompile(`(?i)^adl(64)?(\.exe)?$`)
+
+// detectAIRApps looks for Adobe AIR app descriptors in the container and
+// marks the native executable next to them as an AIR candidate (macOS app
+// bundles keep theirs in Contents/Resources, and their launcher in
+// Contents/MacOS).
+//
+// When several executables could be the launcher, the biggest one wins.
+func detectAIRApps(container *tlc.Container, candidates []*Candidate) {
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+
+		var appDir string
+		switch {
+		case lowerPath == airCaptiveDescriptor || strings.HasSuffix(lowerPath, "/"+airCaptiveDescriptor):
+			appDir = path.Dir(path.Dir(path.Dir(lowerPath)))
+		case airDescriptorRegexp.MatchString(lowerPath):
+			appDir = path.Dir(lowerPath)
+		default:
+			continue
+		}
+
+		launcherDirs := []string{appDir}
+		if path.Base(appDir) == "resources" {
+			launcherDirs = append(launcherDirs, path.Join(path.Dir(appDir), "macos"))
+		}
+
+		var launcher *Candidate
+		for _, c := range candidates {
+			if !isNativeFlavor(c.Flavor) || airDebugLauncherRegexp.MatchString(path.Base(c.Path)) {
+				continue
+			}
+
+			candidateDir := path.Dir(strings.ToLower(c.Path))
+			for _, launcherDir := range launcherDirs {
+				if candidateDir == launcherDir && (launcher == nil || c.Size > launcher.Size) {
+					launcher = c
+				}
+			}
+		}
+
+		if launcher != nil {
+			launcher.Flavor = FlavorAIR
+			launcher.AIRInfo = &AIRInfo{
+				DescriptorPath: f.Path,
+			}
+		}
+	}
+}
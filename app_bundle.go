@@ -0,0 +1,106 @@
+package dash
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// appBundleExecutables returns the paths (relative to basePath, with
+// forward slashes) of the files in an app bundle that should be
+// executable: the one named by CFBundleExecutable in Info.plist, and
+// everything else in Contents/MacOS.
+func appBundleExecutables(basePath string, bundlePath string) ([]string, error) {
+	var res []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			res = append(res, p)
+		}
+	}
+
+	macosPath := path.Join(bundlePath, "Contents", "MacOS")
+
+	plistPath := filepath.Join(basePath, filepath.FromSlash(path.Join(bundlePath, "Contents", "Info.plist")))
+	exeName, err := bundleExecutableName(plistPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading Info.plist")
+	}
+	// don't let a weird Info.plist make us chmod things outside the bundle
+	if exeName != "" && exeName != ".." && !strings.ContainsAny(exeName, "/\\") {
+		add(path.Join(macosPath, exeName))
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Join(basePath, filepath.FromSlash(macosPath)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "listing Contents/MacOS")
+	}
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() {
+			add(path.Join(macosPath, entry.Name()))
+		}
+	}
+
+	return res, nil
+}
+
+// bundleExecutableName returns the value of CFBundleExecutable from
+// an XML property list, or an empty string if it isn't set. Binary
+// property lists aren't supported and are treated as not setting it.
+func bundleExecutableName(plistPath string) (string, error) {
+	f, err := os.Open(plistPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+	var lastKey string
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			// reached the end, or it's an empty, binary or otherwise
+			// mangled property list: those are common enough, don't
+			// make a fuss about it
+			return "", nil
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "key":
+			var key string
+			err := decoder.DecodeElement(&key, &se)
+			if err != nil {
+				return "", nil
+			}
+			lastKey = strings.TrimSpace(key)
+		case "string":
+			var value string
+			err := decoder.DecodeElement(&value, &se)
+			if err != nil {
+				return "", nil
+			}
+			if lastKey == "CFBundleExecutable" {
+				return strings.TrimSpace(value), nil
+			}
+			lastKey = ""
+		default:
+			if se.Name.Local != "plist" && se.Name.Local != "dict" {
+				lastKey = ""
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package dash
+
+import (
+	"path"
+	"strings"
+)
+
+// appRunName is the entry point of an AppDir, the folder an AppImage
+// mounts (or extracts to) before it runs
+const appRunName = "AppRun"
+
+// appImageExtractName is the folder `--appimage-extract` extracts to
+const appImageExtractName = "squashfs-root"
+
+// appDirPrefixes lists the folders that look like extracted AppImages:
+// those with an AppRun candidate, and those named squashfs-root. Prefixes
+// are normalized, see normalizeBundlePath, and the root folder is an
+// empty prefix.
+func appDirPrefixes(candidates []*Candidate) []string {
+	var prefixes []string
+	for _, c := range candidates {
+		p := strings.Replace(c.Path, "\\", "/", -1)
+		if path.Base(p) == appRunName {
+			dir := normalizeBundlePath(path.Dir(p))
+			if dir == "." {
+				prefixes = append(prefixes, "")
+			} else {
+				prefixes = append(prefixes, dir+"/")
+			}
+		}
+
+		tokens := strings.Split(normalizeBundlePath(p), "/")
+		for i, token := range tokens[:len(tokens)-1] {
+			if token == appImageExtractName {
+				prefixes = append(prefixes, strings.Join(tokens[:i+1], "/")+"/")
+				break
+			}
+		}
+	}
+	return prefixes
+}
+
+// isExtractedAppImage returns true if a candidate that isn't an AppImage
+// lives in one of the folders returned by appDirPrefixes
+func isExtractedAppImage(prefixes []string, c *Candidate) bool {
+	return !isAppImage(c) && isEmbeddedHelper(prefixes, c)
+}
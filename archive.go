@@ -0,0 +1,53 @@
+package dash
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	sevenZipMagic = []byte{0x37, 0x7A, 0xBC, 0xAF, 0x27, 0x1C}
+	rarMagic      = []byte{0x52, 0x61, 0x72, 0x21}
+	gzipMagic     = []byte{0x1F, 0x8B}
+	udifMagic     = []byte("koly")
+)
+
+// udifTrailerSize is the size of the trailer at the end of UDIF disk images
+const udifTrailerSize = 512
+
+// isDiskImage returns true if a file is named like a macOS disk image
+// (.dmg, or the older .img and .smi) and ends with a UDIF trailer. Other
+// files aren't checked, reading the end of an entry of a compressed pool
+// means inflating all of it.
+func isDiskImage(r io.ReadSeeker, lowerPath string, size int64) bool {
+	if size < udifTrailerSize {
+		return false
+	}
+	switch filepath.Ext(lowerPath) {
+	case ".dmg", ".img", ".smi":
+	default:
+		return false
+	}
+	return hasMagicAt(r, size-udifTrailerSize, udifMagic)
+}
+
+// archiveFormat returns the format of an archive given its first
+// bytes, or an empty string if it's not an archive we know about.
+// zip files are handled separately, since they can be jars.
+func archiveFormat(header []byte, lowerPath string) ArchiveFormat {
+	switch {
+	case bytes.HasPrefix(header, sevenZipMagic):
+		return ArchiveFormat7z
+	case bytes.HasPrefix(header, rarMagic):
+		return ArchiveFormatRar
+	case bytes.HasPrefix(header, gzipMagic):
+		// gzip doesn't tell us what's inside, so go by the name
+		if strings.HasSuffix(lowerPath, ".tar.gz") || strings.HasSuffix(lowerPath, ".tgz") {
+			return ArchiveFormatTarGz
+		}
+		return ArchiveFormatGzip
+	}
+	return ""
+}
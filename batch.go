@@ -0,0 +1,221 @@
+package dash
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+)
+
+// batchReadSize is how much of a batch file is searched for the
+// program it launches
+const batchReadSize = 16 * 1024
+
+// sniffBatch returns a windows script candidate for `.bat` and `.cmd`
+// files. If the script launches an executable or a jar, its path,
+// relative to the same folder as scriptPath, is stored in ScriptTarget.
+func sniffBatch(r io.ReadSeeker, size int64, scriptPath string) (*Candidate, error) {
+	res := &Candidate{
+		Flavor: FlavorScriptWindows,
+	}
+
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, batchReadSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	if bytes.IndexByte(buf, 0) != -1 {
+		// not text, still a script as far as windows is concerned
+		return res, nil
+	}
+
+	res.ScriptTarget = batchTarget(string(buf), path.Dir(scriptPath))
+	return res, nil
+}
+
+// batchTarget returns the path of the first executable or jar launched
+// by a batch script, whether directly, with `start`, with `call`, or with
+// `java -jar`. `cd` and `pushd` to relative folders are followed. Targets
+// that are absolute, outside of dir, or contain variables other than
+// `%~dp0` are ignored, and an empty string is returned if none is found.
+func batchTarget(contents string, dir string) string {
+	cwd := dir
+
+	for _, line := range strings.Split(contents, "\n") {
+		args := splitBatchLine(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		command := strings.ToLower(args[0].value)
+		switch command {
+		case "cd", "chdir", "pushd":
+			rest := args[1:]
+			if len(rest) > 0 && strings.EqualFold(rest[0].value, "/d") {
+				rest = rest[1:]
+			}
+			if len(rest) == 0 {
+				continue
+			}
+			if p, ok := batchPath(rest[0].value, dir, cwd); ok {
+				cwd = p
+			}
+			continue
+		case "popd":
+			cwd = dir
+			continue
+		case "start":
+			args = skipStartOptions(args[1:], &cwd, dir)
+		case "call":
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		program := strings.ToLower(args[0].value)
+		base := strings.TrimSuffix(path.Base(strings.Replace(program, "\\", "/", -1)), ".exe")
+		if base == "java" || base == "javaw" {
+			for i, arg := range args[:len(args)-1] {
+				jar := args[i+1].value
+				if strings.EqualFold(arg.value, "-jar") && strings.HasSuffix(strings.ToLower(jar), ".jar") {
+					if p, ok := batchPath(jar, dir, cwd); ok {
+						return p
+					}
+				}
+			}
+			continue
+		}
+
+		if strings.HasSuffix(program, ".exe") || strings.HasSuffix(program, ".jar") {
+			if p, ok := batchPath(args[0].value, dir, cwd); ok {
+				return p
+			}
+		}
+	}
+
+	return ""
+}
+
+// skipStartOptions returns the arguments of a `start` command that
+// follow its options and window title. A `/d` option changes cwd.
+func skipStartOptions(args []batchArg, cwd *string, dir string) []batchArg {
+	for len(args) > 0 {
+		arg := args[0]
+		if arg.quoted {
+			// the first quoted argument of `start` is the window title
+			return args[1:]
+		}
+
+		lower := strings.ToLower(arg.value)
+		if !strings.HasPrefix(lower, "/") {
+			return args
+		}
+		args = args[1:]
+
+		switch {
+		case lower == "/d":
+			if len(args) > 0 {
+				if p, ok := batchPath(args[0].value, dir, *cwd); ok {
+					*cwd = p
+				}
+				args = args[1:]
+			}
+		case strings.HasPrefix(lower, "/d"):
+			if p, ok := batchPath(arg.value[2:], dir, *cwd); ok {
+				*cwd = p
+			}
+		case lower == "/affinity" || lower == "/node":
+			if len(args) > 0 {
+				args = args[1:]
+			}
+		}
+	}
+	return args
+}
+
+// batchPath resolves a path found in a batch script against cwd, or
+// against dir if it starts with `%~dp0` (the folder of the script).
+func batchPath(p string, dir string, cwd string) (string, bool) {
+	p = strings.Replace(p, "\\", "/", -1)
+	base := cwd
+	if len(p) >= 5 && strings.EqualFold(p[:5], "%~dp0") {
+		p = p[5:]
+		base = dir
+	}
+
+	if p == "" {
+		return base, true
+	}
+	if strings.Contains(p, "%") || strings.Contains(p, ":") || strings.HasPrefix(p, "/") {
+		return "", false
+	}
+
+	res := path.Join(base, p)
+	if res == ".." || strings.HasPrefix(res, "../") {
+		return "", false
+	}
+	return res, true
+}
+
+type batchArg struct {
+	value  string
+	quoted bool
+}
+
+// splitBatchLine returns the arguments of the first command on a line of
+// a batch script. Comments, labels and echo commands have no arguments.
+func splitBatchLine(line string) []batchArg {
+	line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+	line = strings.TrimSpace(strings.TrimPrefix(line, "@"))
+	if line == "" || strings.HasPrefix(line, ":") {
+		return nil
+	}
+
+	var args []batchArg
+	var current []byte
+	quoted := false
+	inQuotes := false
+	flush := func() {
+		if len(current) > 0 || quoted {
+			args = append(args, batchArg{value: string(current), quoted: quoted})
+		}
+		current = current[:0]
+		quoted = false
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case inQuotes:
+			current = append(current, c)
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '&' || c == '|' || c == '>' || c == '<':
+			// only the first command matters
+			flush()
+			i = len(line)
+		default:
+			current = append(current, c)
+		}
+	}
+	flush()
+
+	if len(args) > 0 {
+		switch strings.ToLower(args[0].value) {
+		case "rem", "echo", "set", "title", "if", "for":
+			return nil
+		}
+	}
+	return args
+}
@@ -0,0 +1,32 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BatchTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	target := func(script string) string {
+		return batchTarget(script, "game")
+	}
+
+	assert.EqualValues("game/Game.exe", target("@echo off\r\nGame.exe\r\n"))
+	assert.EqualValues("game/bin/game.exe", target("start \"\" \"%~dp0bin\\game.exe\" -fullscreen"))
+	assert.EqualValues("game/bin/game.exe", target("start /b /wait bin\\game.exe"))
+	assert.EqualValues("game/bin/game.exe", target("start /d bin game.exe"))
+	assert.EqualValues("game/bin/game.exe", target("cd bin\ncall game.exe & pause"))
+	assert.EqualValues("game/game.exe", target("pushd bin\npopd\ngame.exe"))
+	assert.EqualValues("game/lib/game.jar", target("@javaw -Xmx1G -jar \"lib\\game.jar\""))
+	assert.EqualValues("", target("jre\\bin\\java.exe -cp lib Main"))
+	assert.EqualValues("game/game.jar", target("jre\\bin\\java.exe -jar game.jar"))
+
+	assert.EqualValues("", target("rem game.exe\n:: game.exe\necho game.exe\nset EXE=game.exe"))
+	assert.EqualValues("", target("call setup.bat"))
+	assert.EqualValues("", target("\"C:\\Program Files\\Game\\game.exe\""))
+	assert.EqualValues("", target("%EXE%.exe"))
+	assert.EqualValues("", target("..\\..\\game.exe"))
+	assert.EqualValues("", target("java -cp game.jar Main"))
+}
@@ -0,0 +1,140 @@
+package dash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// NW.js and Electron ship a bunch of helper executables next to the
+// main one (which is usually renamed after the game). Those are never
+// what the user wants to launch. The main one may be named anything,
+// "Sandbox Tales" included, so only their actual names count.
+var chromiumHelperRegexp = regexp.MustCompile(`^(?i:(chrome_)?crashpad_handler|nacl_helper|chrome-sandbox$|nwjc(\.exe)?$)|Helper( \(.*\))?(\.app)?$`)
+
+// detectChromiumShells marks native executables that run an NW.js or
+// Electron app as such. Signals, relative to the executable's folder:
+//
+//   - NW.js: a package.nw file or folder, or a package.json with a "main" field
+//   - Electron: a resources/app.asar archive, or a resources/app folder
+//
+// Helper executables are left alone, and if there's still more than
+// one executable for a given OS, the biggest one is picked.
+func detectChromiumShells(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	lowerFiles := make(map[string]int)
+	for fileIndex, f := range container.Files {
+		lowerFiles[strings.ToLower(f.Path)] = fileIndex
+	}
+	lowerDirs := make(map[string]bool)
+	for _, d := range container.Dirs {
+		lowerDirs[strings.ToLower(d.Path)] = true
+	}
+	exists := func(p string) bool {
+		_, isFile := lowerFiles[p]
+		return isFile || lowerDirs[p]
+	}
+
+	// group potential runners by folder and OS
+	type runnerKey struct {
+		dir string
+		os  string
+	}
+	runners := make(map[runnerKey]*Candidate)
+	var keys []runnerKey
+	for _, c := range candidates {
+		if !isNativeFlavor(c.Flavor) || chromiumHelperRegexp.MatchString(path.Base(c.Path)) {
+			continue
+		}
+
+		key := runnerKey{dir: path.Dir(strings.ToLower(c.Path)), os: candidateOS(c)}
+		if runner, ok := runners[key]; !ok {
+			runners[key] = c
+			keys = append(keys, key)
+		} else if c.Size > runner.Size {
+			runners[key] = c
+		}
+	}
+
+	for _, key := range keys {
+		runner := runners[key]
+		dir := key.dir
+
+		for _, appPath := range []string{path.Join(dir, "resources", "app.asar"), path.Join(dir, "resources", "app")} {
+			if exists(appPath) {
+				runner.Flavor = FlavorElectron
+				runner.ElectronInfo = &ElectronInfo{AppPath: originalPath(container, appPath)}
+				break
+			}
+		}
+		if runner.Flavor == FlavorElectron {
+			continue
+		}
+
+		packageNw := path.Join(dir, "package.nw")
+		if exists(packageNw) {
+			runner.Flavor = FlavorNWjs
+			runner.NWjsInfo = &NWjsInfo{PackagePath: originalPath(container, packageNw)}
+			continue
+		}
+
+		if fileIndex, ok := lowerFiles[path.Join(dir, "package.json")]; ok {
+			hasMain, err := packageJSONHasMain(pool, int64(fileIndex))
+			if err != nil {
+				return errors.Wrap(err, "reading package.json")
+			}
+
+			if hasMain {
+				runner.Flavor = FlavorNWjs
+				runner.NWjsInfo = &NWjsInfo{PackagePath: container.Files[fileIndex].Path}
+			}
+		}
+	}
+
+	return nil
+}
+
+// packageJSONHasMain returns true if the given file is a package.json
+// manifest with a "main" field. Malformed manifests don't count.
+func packageJSONHasMain(pool lake.Pool, fileIndex int64) (bool, error) {
+	r, err := pool.GetReadSeeker(fileIndex)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+
+	var manifest struct {
+		Main string `json:"main"`
+	}
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return false, nil
+	}
+
+	return manifest.Main != "", nil
+}
+
+// originalPath returns the path of a file or folder as it appears in
+// the container, given its lower-cased path.
+func originalPath(container *tlc.Container, lowerPath string) string {
+	for _, f := range container.Files {
+		if strings.ToLower(f.Path) == lowerPath {
+			return f.Path
+		}
+	}
+	for _, d := range container.Dirs {
+		if strings.ToLower(d.Path) == lowerPath {
+			return d.Path
+		}
+	}
+	return lowerPath
+}
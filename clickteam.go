@@ -0,0 +1,76 @@
+package dash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// ClickTeam Fusion runtimes carry the game's data after the PE image:
+// it starts with a pack of extensions ('wwww'), or directly with the
+// game header ('PAME', or 'PAMU' for unicode builds) when there are none.
+var clickTeamMagics = [][]byte{
+	[]byte("wwww"),
+	[]byte("PAME"),
+	[]byte("PAMU"),
+}
+
+// isClickTeamRuntime returns true if a PE file's overlay starts with
+// ClickTeam Fusion game data
+func isClickTeamRuntime(r io.ReadSeeker, size int64) bool {
+	overlayOffset, ok := readPEOverlayOffset(r)
+	if !ok || overlayOffset+4 > size {
+		return false
+	}
+
+	magic, err := readBytesAt(r, overlayOffset, 4)
+	if err != nil {
+		return false
+	}
+
+	for _, m := range clickTeamMagics {
+		if bytes.Equal(magic, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// readPEOverlayOffset returns the offset at which the sections of a PE
+// file end, which is where any appended data (the overlay) starts.
+func readPEOverlayOffset(r io.ReadSeeker) (int64, bool) {
+	lfanew, err := readBytesAt(r, 0x3C, 4)
+	if err != nil {
+		return 0, false
+	}
+	peOffset := int64(binary.LittleEndian.Uint32(lfanew))
+
+	// "PE\0\0", then the COFF header
+	header, err := readBytesAt(r, peOffset, 24)
+	if err != nil || string(header[:4]) != "PE\x00\x00" {
+		return 0, false
+	}
+	numSections := int64(binary.LittleEndian.Uint16(header[6:8]))
+	optionalHeaderSize := int64(binary.LittleEndian.Uint16(header[20:22]))
+	if numSections == 0 || numSections > 96 {
+		// the PE loader refuses more than 96 sections
+		return 0, false
+	}
+
+	const sectionHeaderSize = 40
+	sections, err := readBytesAt(r, peOffset+24+optionalHeaderSize, int(numSections*sectionHeaderSize))
+	if err != nil {
+		return 0, false
+	}
+
+	var end int64
+	for i := int64(0); i < numSections; i++ {
+		section := sections[i*sectionHeaderSize : (i+1)*sectionHeaderSize]
+		rawSize := int64(binary.LittleEndian.Uint32(section[16:20]))
+		rawPointer := int64(binary.LittleEndian.Uint32(section[20:24]))
+		if rawPointer+rawSize > end {
+			end = rawPointer + rawSize
+		}
+	}
+	return end, true
+}
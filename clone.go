@@ -0,0 +1,128 @@
+package dash
+
+// Clone returns a deep copy of a candidate, which can be modified without
+// affecting the original. It returns nil for a nil candidate.
+func (c *Candidate) Clone() *Candidate {
+	if c == nil {
+		return nil
+	}
+
+	res := *c
+	if c.Spell != nil {
+		res.Spell = append([]string(nil), c.Spell...)
+	}
+	if c.WindowsInfo != nil {
+		info := *c.WindowsInfo
+		res.WindowsInfo = &info
+	}
+	if c.LinuxInfo != nil {
+		info := *c.LinuxInfo
+		res.LinuxInfo = &info
+	}
+	if c.MacosInfo != nil {
+		info := *c.MacosInfo
+		if c.MacosInfo.Archs != nil {
+			info.Archs = append([]Arch(nil), c.MacosInfo.Archs...)
+		}
+		res.MacosInfo = &info
+	}
+	if c.LoveInfo != nil {
+		info := *c.LoveInfo
+		res.LoveInfo = &info
+	}
+	if c.ScriptInfo != nil {
+		info := *c.ScriptInfo
+		res.ScriptInfo = &info
+	}
+	if c.JarInfo != nil {
+		info := *c.JarInfo
+		res.JarInfo = &info
+	}
+	if c.GodotInfo != nil {
+		info := *c.GodotInfo
+		res.GodotInfo = &info
+	}
+	if c.GameMakerInfo != nil {
+		info := *c.GameMakerInfo
+		res.GameMakerInfo = &info
+	}
+	if c.NWjsInfo != nil {
+		info := *c.NWjsInfo
+		res.NWjsInfo = &info
+	}
+	if c.ElectronInfo != nil {
+		info := *c.ElectronInfo
+		res.ElectronInfo = &info
+	}
+	if c.ArchiveInfo != nil {
+		info := *c.ArchiveInfo
+		res.ArchiveInfo = &info
+	}
+	if c.HTMLInfo != nil {
+		info := *c.HTMLInfo
+		res.HTMLInfo = &info
+	}
+	if c.DOSBoxInfo != nil {
+		info := *c.DOSBoxInfo
+		res.DOSBoxInfo = &info
+	}
+	if c.RenpyInfo != nil {
+		info := *c.RenpyInfo
+		res.RenpyInfo = &info
+	}
+	if c.AIRInfo != nil {
+		info := *c.AIRInfo
+		res.AIRInfo = &info
+	}
+	if c.UnityInfo != nil {
+		info := *c.UnityInfo
+		res.UnityInfo = &info
+	}
+	if c.UnrealInfo != nil {
+		info := *c.UnrealInfo
+		res.UnrealInfo = &info
+	}
+	if c.JNLPInfo != nil {
+		info := *c.JNLPInfo
+		if c.JNLPInfo.Jars != nil {
+			info.Jars = append([]string(nil), c.JNLPInfo.Jars...)
+		}
+		res.JNLPInfo = &info
+	}
+	if c.MobileInfo != nil {
+		info := *c.MobileInfo
+		res.MobileInfo = &info
+	}
+	return &res
+}
+
+// cloneCandidates returns a copy of a verdict with deep copies of its
+// candidates, including the ones that were eliminated, so that it doesn't
+// share any candidate with the original.
+func (v Verdict) cloneCandidates() Verdict {
+	clones := make(map[*Candidate]*Candidate)
+	clone := func(c *Candidate) *Candidate {
+		if res, ok := clones[c]; ok {
+			return res
+		}
+		res := c.Clone()
+		clones[c] = res
+		return res
+	}
+
+	candidates := make([]*Candidate, len(v.Candidates))
+	for i, c := range v.Candidates {
+		candidates[i] = clone(c)
+	}
+	v.Candidates = candidates
+
+	if v.scores != nil {
+		scores := make([]ScoredCandidate, len(v.scores))
+		for i, sc := range v.scores {
+			sc.Candidate = clone(sc.Candidate)
+			scores[i] = sc
+		}
+		v.scores = scores
+	}
+	return v
+}
@@ -1,60 +1,132 @@
 package dash
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"encoding/binary"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
 
 	"github.com/itchio/headway/state"
 	"github.com/itchio/lake"
 	"github.com/itchio/lake/pools"
 	"github.com/itchio/lake/tlc"
-	"github.com/itchio/pelican"
 	"github.com/pkg/errors"
 )
 
-func sniffPoolEntry(pool lake.Pool, fileIndex int64, file *tlc.File) (*Candidate, error) {
-	r, err := pool.GetReadSeeker(fileIndex)
+func sniffPoolEntry(pool lake.Pool, fileIndex int64, file *tlc.File, collectSpells bool, deepELF bool, peVersionInfo bool, cache SniffCache) (*Candidate, error) {
+	r, closeReader, err := newSniffReader(pool, fileIndex)
 	if err != nil {
 		return nil, errors.Wrap(err, "while getting read seeker for pool entry")
 	}
+	defer closeReader()
 
 	size := pool.GetSize(fileIndex)
 
-	return Sniff(r, file.Path, size)
+	var key string
+	if cache != nil {
+		key, err = sniffCacheKey(r, file.Path, size, collectSpells, deepELF, peVersionInfo)
+		if err != nil {
+			return nil, errors.Wrap(err, "computing sniff cache key")
+		}
+		if c, ok := cache.Get(key); ok {
+			return c.Clone(), nil
+		}
+		_, err = r.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, errors.Wrap(err, "rewinding pool entry")
+		}
+	}
+
+	c, err := Sniff(r, file.Path, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil {
+		if collectSpells {
+			if c.Spell == nil {
+				// some sniffers don't need a spell to do their job
+				c.Spell = identifySpell(r, size)
+			}
+		} else {
+			c.Spell = nil
+		}
+
+		if deepELF && c.LinuxInfo != nil && !c.LinuxInfo.StaticallyLinked && !c.Compressed {
+			c.LinuxInfo.MinGlibcVersion = readELFGlibcVersion(r, c.LinuxInfo)
+		}
+
+		if peVersionInfo && c.WindowsInfo != nil && !c.WindowsInfo.DOS {
+			readPEVersionInfo(r, size, c.WindowsInfo)
+		}
+	}
+
+	if cache != nil {
+		cache.Put(key, c.Clone())
+	}
+	return c, nil
 }
 
 func Sniff(r io.ReadSeeker, name string, size int64) (*Candidate, error) {
-	c, err := doSniff(r, name, size)
+	return sniff(r, name, size, nil)
+}
+
+// SniffAt is like Sniff, for files that can be read at arbitrary offsets
+// but not as a stream, like memory-mapped files or files served over HTTP
+// with range requests.
+func SniffAt(ra io.ReaderAt, name string, size int64) (*Candidate, error) {
+	return sniff(io.NewSectionReader(ra, 0, size), name, size, nil)
+}
+
+// SniffBytes is like Sniff, for files that are already in memory.
+func SniffBytes(data []byte, name string) (*Candidate, error) {
+	return sniff(bytes.NewReader(data), name, int64(len(data)), data)
+}
+
+// sniff identifies a file. If the caller already has the beginning
+// of the file at hand, it can pass it as header, so we don't have to
+// read it again.
+func sniff(r io.ReadSeeker, name string, size int64, header []byte) (*Candidate, error) {
+	c, err := doSniff(r, name, size, header)
 	if c != nil {
+		if isNativeFlavor(c.Flavor) {
+			switch {
+			case hasEmbeddedGodotPack(r, size):
+				markGodot(c, "")
+			case hasAppendedSWF(r, size):
+				c.Flavor = FlavorFlashProjector
+			}
+		}
 		c.Size = size
 		if c.Path == "" {
 			c.Path = name
 		}
-		c.Depth = pathDepth(c.Path)
+		c.Depth = PathDepth(c.Path)
 	}
 	return c, err
 }
 
-func doSniff(r io.ReadSeeker, path string, size int64) (*Candidate, error) {
+func doSniff(r io.ReadSeeker, path string, size int64, header []byte) (*Candidate, error) {
 	lowerPath := strings.ToLower(path)
 
 	lowerBase := filepath.Base(lowerPath)
 	dir := filepath.Dir(path)
 	switch lowerBase {
 	case "index.html":
-		return &Candidate{
-			Flavor: FlavorHTML,
-			Path:   path,
-		}, nil
+		return sniffHTML(r, path, true)
 	case "conf.lua":
 		return sniffLove(r, size, dir)
 	}
 
+	// other HTML files are only candidates if they're Twine stories, the
+	// HTML fallback in Configure takes care of the rest
+	if strings.HasSuffix(lowerPath, ".html") || strings.HasSuffix(lowerPath, ".htm") {
+		return sniffHTML(r, path, false)
+	}
+
 	if strings.HasSuffix(lowerPath, ".love") {
 		return &Candidate{
 			Flavor: FlavorLove,
@@ -77,61 +149,125 @@ func doSniff(r io.ReadSeeker, path string, size int64) (*Candidate, error) {
 
 	// if it ends in .bat or .cmd, it's a windows script
 	if strings.HasSuffix(lowerPath, ".bat") || strings.HasSuffix(lowerPath, ".cmd") {
-		return &Candidate{
-			Flavor: FlavorScriptWindows,
-		}, nil
+		return sniffBatch(r, size, path)
 	}
 
-	buf := make([]byte, 8)
-	n, _ := io.ReadFull(r, buf)
-	if n < len(buf) {
-		// too short to be an exec or unreadable
-		return nil, nil
+	// .command files are run by Finder with the user's shell, so they
+	// don't need a shebang
+	if strings.HasSuffix(lowerPath, ".command") {
+		return sniffCommand(r, size)
 	}
 
-	// intel Mach-O executables start with 0xCEFAEDFE or 0xCFFAEDFE
-	// (old PowerPC Mach-O executables started with 0xFEEDFACE)
-	if (buf[0] == 0xCE || buf[0] == 0xCF) && buf[1] == 0xFA && buf[2] == 0xED && buf[3] == 0xFE {
-		return &Candidate{
-			Flavor: FlavorNativeMacos,
-		}, nil
+	// Java Web Start descriptors are XML, with no magic to speak of
+	if strings.HasSuffix(lowerPath, ".jnlp") {
+		return sniffJNLP(r, size)
 	}
 
-	// Mach-O universal binaries start with 0xCAFEBABE
-	// it's Apple's 'fat binary' stuff that contains multiple architectures
-	// unfortunately, compiled Java classes also start with that
-	if buf[0] == 0xCA && buf[1] == 0xFE && buf[2] == 0xBA && buf[3] == 0xBE {
-		return sniffFatMach(r, size)
+	buf := header
+	if buf == nil {
+		buf = make([]byte, 8)
+		n, _ := io.ReadFull(r, buf)
+		buf = buf[:n]
 	}
-
-	// ELF executables start with 0x7F454C46
-	// (e.g. 0x7F + 'ELF' in ASCII)
-	if buf[0] == 0x7F && buf[1] == 0x45 && buf[2] == 0x4C && buf[3] == 0x46 {
-		return sniffELF(r, path, size)
+	if len(buf) < 2 {
+		// too short to be anything dash knows about, or unreadable
+		return sniffRegistered(r, path, size)
 	}
 
-	// Shell scripts start with a shebang (#!)
+	// Shell scripts start with a shebang (#!), and they can be
+	// very short, so check for those before anything else.
 	// https://en.wikipedia.org/wiki/Shebang_(Unix)
 	if buf[0] == 0x23 && buf[1] == 0x21 {
 		return sniffScript(r, size)
 	}
 
-	// MSI (Microsoft Installer Packages) have a well-defined magic number.
-	if buf[0] == 0xD0 && buf[1] == 0xCF &&
-		buf[2] == 0x11 && buf[3] == 0xE0 &&
-		buf[4] == 0xA1 && buf[5] == 0xB1 &&
-		buf[6] == 0x1A && buf[7] == 0xE1 {
+	if len(buf) >= 8 {
+		// intel Mach-O executables start with 0xCEFAEDFE or 0xCFFAEDFE
+		// (old PowerPC Mach-O executables started with 0xFEEDFACE)
+		if (buf[0] == 0xCE || buf[0] == 0xCF) && buf[1] == 0xFA && buf[2] == 0xED && buf[3] == 0xFE {
+			return &Candidate{
+				Flavor:       FlavorNativeMacos,
+				Arch:         machoArch(binary.LittleEndian.Uint32(buf[4:8])),
+				MinOSVersion: formatMachOVersion(readMachOMinVersion(r, 0)),
+				MacosInfo:    &MacosInfo{},
+			}, nil
+		}
+
+		// Mach-O universal binaries start with 0xCAFEBABE
+		// it's Apple's 'fat binary' stuff that contains multiple architectures
+		// unfortunately, compiled Java classes also start with that
+		if buf[0] == 0xCA && buf[1] == 0xFE && buf[2] == 0xBA && buf[3] == 0xBE {
+			return sniffFatMach(r, size)
+		}
+
+		// ELF executables start with 0x7F454C46
+		// (e.g. 0x7F + 'ELF' in ASCII)
+		if buf[0] == 0x7F && buf[1] == 0x45 && buf[2] == 0x4C && buf[3] == 0x46 {
+			return sniffELF(r, path, size)
+		}
+
+		// MSI (Microsoft Installer Packages) have a well-defined magic number.
+		if buf[0] == 0xD0 && buf[1] == 0xCF &&
+			buf[2] == 0x11 && buf[3] == 0xE0 &&
+			buf[4] == 0xA1 && buf[5] == 0xB1 &&
+			buf[6] == 0x1A && buf[7] == 0xE1 {
+			return &Candidate{
+				Flavor: FlavorMSI,
+			}, nil
+		}
+	}
+
+	if len(buf) >= 4 && buf[0] == 0x50 && buf[1] == 0x4B &&
+		buf[2] == 0x03 && buf[3] == 0x04 {
+		return sniffZip(r, size, lowerPath)
+	}
+
+	// macOS installer packages are xar archives, which start with 'xar!'
+	if len(buf) >= 4 && buf[0] == 0x78 && buf[1] == 0x61 &&
+		buf[2] == 0x72 && buf[3] == 0x21 &&
+		(strings.HasSuffix(lowerPath, ".pkg") || strings.HasSuffix(lowerPath, ".mpkg")) {
 		return &Candidate{
-			Flavor: FlavorMSI,
+			Flavor: FlavorPkgMacos,
+			MacosInfo: &MacosInfo{
+				Installer: true,
+			},
 		}, nil
 	}
 
-	if buf[0] == 0x50 && buf[1] == 0x4B &&
-		buf[2] == 0x03 && buf[3] == 0x04 {
-		return sniffZip(r, size)
+	// UDIF disk images end with a 512-byte trailer that starts with 'koly'
+	if isDiskImage(r, lowerPath, size) {
+		return &Candidate{
+			Flavor: FlavorDmgMacos,
+			ArchiveInfo: &ArchiveInfo{
+				Format: ArchiveFormatDmg,
+			},
+		}, nil
+	}
+
+	// Other archives can't be launched, but it's worth letting the
+	// caller know they need to be extracted first.
+	format := archiveFormat(buf, lowerPath)
+	if format == ArchiveFormatGzip {
+		// single executables are sometimes shipped gzip-compressed
+		c, err := sniffGzip(r, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "sniffing gzip file")
+		}
+		if c != nil {
+			return c, nil
+		}
+	}
+	if format != "" {
+		return &Candidate{
+			Flavor: FlavorArchive,
+			ArchiveInfo: &ArchiveInfo{
+				Format: format,
+				Split:  isSplitArchivePart(r, size, lowerPath),
+			},
+		}, nil
 	}
 
-	return nil, nil
+	return sniffRegistered(r, path, size)
 }
 
 // ConfigureParams controls the behavior of Configure
@@ -142,10 +278,75 @@ type ConfigureParams struct {
 	// .itch folder)
 	Filter tlc.FilterFunc
 	Stats  *VerdictStats
+	// Set to true to list skipped files in Stats (which must be non-nil),
+	// see VerdictStats.BlacklistedPaths and VerdictStats.UnrecognizedPaths.
+	// It's meant to find out why a file isn't a candidate.
+	CollectSkips bool
+	// Context allows cancelling Configure while it walks or sniffs files.
+	// A nil value means it can't be cancelled.
+	Context context.Context
+	// Number of files to sniff in parallel. Values of 0 or 1 mean
+	// files are sniffed one after the other. The order of candidates
+	// in the verdict doesn't depend on it.
+	Concurrency int
+	// Set to true to get the raw output of wizardry for every candidate,
+	// see Candidate.Spell. It's only useful to diagnose misclassifications,
+	// and comes at a cost, so it's off by default.
+	CollectSpells bool
+	// Candidates whose path matches any of these globs are left out of
+	// the verdict (their size still counts towards TotalSize). Globs are
+	// matched case-insensitively against slash-separated paths, see
+	// path.Match. A glob that matches a folder excludes everything in it.
+	ExcludeGlobs []string
+	// Cache lets Configure skip sniffing files that haven't changed since
+	// a previous call, see SniffCache. A nil value means every file is
+	// sniffed.
+	Cache SniffCache
+	// Set to true to keep the result of the walk around as Verdict.Container,
+	// so callers can look at every file without walking the folder again.
+	// It's off by default, since containers of big folders use a fair amount
+	// of memory.
+	RetainContainer bool
+	// Called as files are processed, with the number of files done so far,
+	// out of all the files in the folder. Calls are throttled, never
+	// concurrent (even with Concurrency), and the last one has done equal
+	// to total. A nil value means no progress is reported.
+	OnProgress func(done, total int)
+	// Set to true to only fall back on top-level HTML files (when nothing
+	// else was found) if there's something else suggesting a game: scripts,
+	// a manifest.json, or a few assets. It keeps documentation like
+	// readme.html from being picked up on its own. Uploads that consist of
+	// a single HTML file are always candidates.
+	StrictHTML bool
+	// How deep the HTML fallback looks for .html files, when nothing else
+	// was found. The shallowest ones win, and index.html wins over other
+	// names at the same depth. Zero means 1, ie. only top-level files, set
+	// it to 2 for uploads that wrap everything in a single folder.
+	HTMLFallbackMaxDepth int
+	// Set to true for folders on case-sensitive filesystems: the Info.plist
+	// of app bundles (and the contents of installer packages) must then be
+	// cased exactly, and bundles where it isn't are reported as malformed.
+	CaseSensitive bool
+	// Set to true to look up the glibc version linux executables need, see
+	// LinuxInfo.MinGlibcVersion. It reads their section headers and symbol
+	// versions, on top of the headers every file gets sniffed for, so it's
+	// off by default.
+	DeepELF bool
+	// Set to true to read the version resource of windows executables, see
+	// WindowsInfo.ProductName. It probes them with pelican, which takes a
+	// while on big executables, so it's off by default.
+	PEVersionInfo bool
 
 	CandidateDetector
 }
 
+func (params ConfigureParams) htmlFallbackMaxDepth() int {
+	if params.HTMLFallbackMaxDepth <= 0 {
+		return 1
+	}
+	return params.HTMLFallbackMaxDepth
+}
+
 type CandidateDetector interface {
 	// Error returned here is treated as critical and will
 	// cancel Configuration.
@@ -161,10 +362,9 @@ type DetectResult struct {
 // Configure walks a directory and finds potential launch candidates,
 // grouped together into a verdict.
 func Configure(root string, params ConfigureParams) (*Verdict, error) {
-	consumer := params.Consumer
-
-	if params.Stats != nil {
-		params.Stats.SniffsByExt = make(map[string]int)
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
 	filter := params.Filter
@@ -172,56 +372,188 @@ func Configure(root string, params ConfigureParams) (*Verdict, error) {
 		filter = tlc.PresetFilter
 	}
 
-	verdict := &Verdict{
-		BasePath: root,
+	// the walk can't be interrupted, but once cancelled, we can
+	// make it skip everything else.
+	walkFilter := func(name string) tlc.FilterResult {
+		if ctx.Err() != nil {
+			return tlc.FilterIgnore
+		}
+		return filter(name)
 	}
 
-	var pool lake.Pool
-
-	container, err := tlc.WalkAny(root, tlc.WalkOpts{Filter: filter})
+	container, err := tlc.WalkAny(root, tlc.WalkOpts{Filter: walkFilter})
 	if err != nil {
 		return nil, err
 	}
 
-	pool, err = pools.New(container, root)
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "walking folder to configure")
+	}
+
+	pool, err := pools.New(container, root)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating pool to configure folder")
 	}
 
 	defer pool.Close()
 
+	newPool := func() (lake.Pool, error) {
+		return pools.New(container, root)
+	}
+	verdict, err := configure(container, pool, newPool, params)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict.BasePath = root
+	return verdict, nil
+}
+
+// ConfigureFromPool finds potential launch candidates among the files of
+// container, reading them from pool instead of walking a folder, so games
+// can be configured straight from a zip file, or any other lake.Pool.
+// The pool isn't closed, it still belongs to the caller.
+//
+// The verdict's BasePath is left empty: it's up to the caller to set it
+// if the files also exist on disk. FixPermissions, and the installer
+// checks of Filter for windows executables, open files relative to
+// BasePath, so they only work with folder pools.
+//
+// Pools can't be shared between goroutines, so files are sniffed one
+// after the other, whatever params.Concurrency is.
+func ConfigureFromPool(container *tlc.Container, pool lake.Pool, params ConfigureParams) (*Verdict, error) {
+	return configure(container, pool, nil, params)
+}
+
+// configure finds candidates among the files of container. newPool may
+// be nil, in which case files are sniffed sequentially from pool.
+func configure(container *tlc.Container, pool lake.Pool, newPool poolFactory, params ConfigureParams) (*Verdict, error) {
+	consumer := params.Consumer
+
+	if params.Stats != nil {
+		params.Stats.SniffsByExt = make(map[string]int)
+		params.Stats.BlacklistedPaths = nil
+		params.Stats.UnrecognizedPaths = nil
+	}
+	collectSkips := params.CollectSkips && params.Stats != nil
+
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	excludeGlobs, err := compileExcludeGlobs(params.ExcludeGlobs)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &Verdict{}
+	warnings := newWarningLog(consumer, nil)
+	if params.RetainContainer {
+		verdict.Container = container
+	}
+
 	var candidates = make([]*Candidate, 0)
 
+	// bundle folders are recognized by their extension whatever its case,
+	// the files in them may have to be cased exactly
+	foldCase := func(p string) string {
+		if params.CaseSensitive {
+			return p
+		}
+		return strings.ToLower(p)
+	}
+
 	for _, d := range container.Dirs {
 		lowerPath := strings.ToLower(d.Path)
 		if strings.HasSuffix(lowerPath, ".app") {
-			plistPath := lowerPath + "/contents/info.plist"
+			plistPath := foldCase(d.Path + "/Contents/Info.plist")
+			bundlePrefix := foldCase(d.Path + "/")
 
+			// bundles are folders, so their size is the size of everything
+			// in them (including any nested bundles)
 			plistFound := false
+			plistMiscased := false
+			var bundleSize int64
 			for _, f := range container.Files {
-				if strings.ToLower(f.Path) == plistPath {
+				filePath := foldCase(f.Path)
+				if filePath == plistPath {
 					plistFound = true
-					break
+				} else if strings.EqualFold(filePath, plistPath) {
+					plistMiscased = true
+				}
+				if strings.HasPrefix(filePath, bundlePrefix) {
+					bundleSize += f.Size
 				}
 			}
 
 			if !plistFound {
-				consumer.Logf("Found app bundle without an Info.plist: %s", d.Path)
+				if plistMiscased {
+					warnings.warnf(d.Path, "Found malformed app bundle, its Info.plist isn't cased as Contents/Info.plist: %s", d.Path)
+				} else {
+					warnings.logf(d.Path, "Found app bundle without an Info.plist: %s", d.Path)
+				}
 				continue
 			}
 
 			res := &Candidate{
 				Flavor: FlavorAppMacos,
-				Size:   0,
+				Size:   bundleSize,
+				Path:   d.Path,
+				Mode:   d.Mode,
+			}
+			res.Depth = PathDepth(res.Path)
+			candidates = append(candidates, res)
+		} else if strings.HasSuffix(lowerPath, ".pkg") || strings.HasSuffix(lowerPath, ".mpkg") {
+			// legacy installer packages are bundles too, with an Info.plist
+			// and/or a compressed payload in their Contents folder
+			markerPaths := []string{
+				foldCase(d.Path + "/Contents/Info.plist"),
+				foldCase(d.Path + "/Contents/Archive.pax.gz"),
+			}
+			bundlePrefix := foldCase(d.Path + "/")
+
+			markerFound := false
+			var bundleSize int64
+			for _, f := range container.Files {
+				filePath := foldCase(f.Path)
+				if filePath == markerPaths[0] || filePath == markerPaths[1] {
+					markerFound = true
+				}
+				if strings.HasPrefix(filePath, bundlePrefix) {
+					bundleSize += f.Size
+				}
+			}
+
+			if !markerFound {
+				warnings.logf(d.Path, "Found installer package folder without contents: %s", d.Path)
+				continue
+			}
+
+			res := &Candidate{
+				Flavor: FlavorPkgMacos,
+				Size:   bundleSize,
 				Path:   d.Path,
 				Mode:   d.Mode,
+				MacosInfo: &MacosInfo{
+					Installer: true,
+				},
 			}
-			res.Depth = pathDepth(res.Path)
+			res.Depth = PathDepth(res.Path)
 			candidates = append(candidates, res)
 		}
 	}
 
+	progress := newProgressReporter(params.OnProgress, len(container.Files))
+
+	detected := make(map[int64]*Candidate)
+	var sniffIndices []int64
+
 	for fileIndex, f := range container.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "detecting candidates")
+		}
+
 		verdict.TotalSize += f.Size
 		if params.CandidateDetector != nil {
 			res, err := params.CandidateDetector.DetectCandidate(pool, int64(fileIndex), f)
@@ -229,473 +561,270 @@ func Configure(root string, params ConfigureParams) (*Verdict, error) {
 				return nil, errors.Wrap(err, "detect candidate")
 			}
 			if res.Candidate != nil {
-				candidates = append(candidates, res.Candidate)
+				detected[int64(fileIndex)] = res.Candidate
 			}
 			if res.SkipDefaultAnalysis {
+				progress.add(1)
 				continue
 			}
 		}
-		if !isBlacklistedExt(f.Path) {
+		if isBlacklistedExt(f.Path) {
+			if collectSkips {
+				params.Stats.BlacklistedPaths = append(params.Stats.BlacklistedPaths, f.Path)
+			}
+			progress.add(1)
+		} else {
 			if params.Stats != nil {
 				params.Stats.NumSniffs++
-				ext := getExt(f.Path)
+				ext := GetExt(f.Path)
 				params.Stats.SniffsByExt[ext] = params.Stats.SniffsByExt[ext] + 1
 			}
 
-			res, err := sniffPoolEntry(pool, int64(fileIndex), f)
-			if err != nil {
-				return nil, errors.Wrap(err, "sniffing pool entry")
-			}
-
-			if res != nil {
-				res.Mode = f.Mode
-				candidates = append(candidates, res)
-			}
+			sniffIndices = append(sniffIndices, int64(fileIndex))
 		}
 	}
 
-	if len(candidates) == 0 && container.IsSingleFile() {
-		f := container.Files[0]
+	sniffed, err := sniffPoolEntries(ctx, container, pool, newPool, sniffIndices, params.Concurrency, params.CollectSpells, params.DeepELF, params.PEVersionInfo, params.Cache, progress)
+	if err != nil {
+		return nil, errors.Wrap(err, "sniffing pool entry")
+	}
 
-		if hasExt(f.Path, ".html") {
-			// ok, that's an HTML5 game
-			candidate := &Candidate{
-				Size:   f.Size,
-				Path:   f.Path,
-				Mode:   f.Mode,
-				Depth:  pathDepth(f.Path),
-				Flavor: FlavorHTML,
-			}
-			candidates = append(candidates, candidate)
-		}
+	// assemble candidates in file order, so the verdict doesn't
+	// depend on how sniffing was scheduled
+	tally := configureTally{
+		files:   len(container.Files),
+		sniffed: len(sniffIndices),
 	}
+	sniffedByIndex := make(map[int64]*Candidate)
+	for i, res := range sniffed {
+		if res != nil {
+			sniffedByIndex[sniffIndices[i]] = res
+			continue
+		}
 
-	if len(candidates) == 0 {
-		// still no candidates? if we have a top-level .html file, let's go for it
-		for _, f := range container.Files {
-			if pathDepth(f.Path) == 1 && hasExt(f.Path, ".html") {
-				// ok, that's an HTML5 game
-				candidate := &Candidate{
-					Size:   f.Size,
-					Path:   f.Path,
-					Mode:   f.Mode,
-					Depth:  pathDepth(f.Path),
-					Flavor: FlavorHTML,
-				}
-				candidates = append(candidates, candidate)
-			}
+		f := container.Files[sniffIndices[i]]
+		if isZipPath(f.Path) {
+			tally.zips++
+		}
+		if collectSkips {
+			params.Stats.UnrecognizedPaths = append(params.Stats.UnrecognizedPaths, f.Path)
 		}
 	}
 
-	verdict.Candidates = candidates
-
-	return verdict, nil
-}
-
-type FixPermissionsParams struct {
-	DryRun   bool
-	Consumer *state.Consumer
-}
-
-// FixPermissions makes sure all ELF executables, COFF executables,
-// and scripts have the executable bit set
-func FixPermissions(v *Verdict, params FixPermissionsParams) ([]string, error) {
-	consumer := params.Consumer
-
-	var fixed []string
+	// archives often ship a `game -> game.x86_64` symlink as the launcher:
+	// those take the place of the file they point to.
+	links := symlinksByTarget(container)
 
-	for _, c := range v.Candidates {
-		switch c.Flavor {
-		case FlavorNativeLinux, FlavorNativeMacos, FlavorScript:
-			fullPath := filepath.Join(v.BasePath, c.Path)
-
-			if c.Mode&0100 == 0 {
-				consumer.Debugf("Adding missing executable bit for (%s)/(%s)", filepath.Base(v.BasePath), c.Path)
-
-				fixed = append(fixed, c.Path)
-				if !params.DryRun {
-					err := os.Chmod(fullPath, 0755)
-					if err != nil {
-						return nil, err
-					}
+	for fileIndex, f := range container.Files {
+		if res, ok := detected[int64(fileIndex)]; ok {
+			candidates = append(candidates, res)
+		}
+		if res, ok := sniffedByIndex[int64(fileIndex)]; ok {
+			res.Mode = f.Mode
+			if ls := links[int64(fileIndex)]; len(ls) > 0 && res.Path == f.Path {
+				for _, l := range ls {
+					linked := res.Clone()
+					linked.Path = l.Path
+					linked.Depth = PathDepth(l.Path)
+					candidates = append(candidates, linked)
 				}
+				continue
 			}
+			candidates = append(candidates, res)
 		}
-
-		c.Mode = 0
 	}
 
-	return fixed, nil
-}
-
-type biggestFirst struct {
-	candidates []*Candidate
-}
-
-var _ sort.Interface = (*biggestFirst)(nil)
-
-func (bf *biggestFirst) Len() int {
-	return len(bf.candidates)
-}
-
-func (bf *biggestFirst) Less(i, j int) bool {
-	return bf.candidates[i].Size > bf.candidates[j].Size
-}
-
-func (bf *biggestFirst) Swap(i, j int) {
-	bf.candidates[i], bf.candidates[j] = bf.candidates[j], bf.candidates[i]
-}
-
-type HighestScoreFirst struct {
-	candidates []ScoredCandidate
-}
-
-var _ sort.Interface = (*HighestScoreFirst)(nil)
-
-func (hsf *HighestScoreFirst) Len() int {
-	return len(hsf.candidates)
-}
-
-func (hsf *HighestScoreFirst) Less(i, j int) bool {
-	return hsf.candidates[i].score > hsf.candidates[j].score
-}
-
-func (hsf *HighestScoreFirst) Swap(i, j int) {
-	hsf.candidates[i], hsf.candidates[j] = hsf.candidates[j], hsf.candidates[i]
-}
-
-type BlacklistEntry struct {
-	pattern *regexp.Regexp
-	penalty Penalty
-}
-
-type PenaltyKind int
-
-const (
-	PenaltyExclude = iota
-	PenaltyScore
-)
-
-type Penalty struct {
-	kind  PenaltyKind
-	delta int64
-}
-
-var blacklist = []BlacklistEntry{
-	// Penalties
-	{regexp.MustCompile(`(?i)unins.*\.exe$`), Penalty{PenaltyScore, 50}},
-	{regexp.MustCompile(`(?i)kick\.bin$`), Penalty{PenaltyScore, 50}},
-	{regexp.MustCompile(`(?i)\.vshost\.exe$`), Penalty{PenaltyScore, 50}},
-	{regexp.MustCompile(`(?i)nacl_helper`), Penalty{PenaltyScore, 20}},
-	{regexp.MustCompile(`(?i)nwjc\.exe$`), Penalty{PenaltyScore, 20}},
-	{regexp.MustCompile(`(?i)flixel\.exe$`), Penalty{PenaltyScore, 20}},
-
-	// Excludes
-	{regexp.MustCompile(`(?i)\.(so|dylib)$`), Penalty{PenaltyExclude, 0}},
-	{regexp.MustCompile(`(?i)dxwebsetup\.exe$`), Penalty{PenaltyExclude, 0}},
-	{regexp.MustCompile(`(?i)vcredist.*\.exe$`), Penalty{PenaltyExclude, 0}},
-	{regexp.MustCompile(`(?i)unitycrashhandler.*\.exe$`), Penalty{PenaltyExclude, 0}},
-}
-
-type ScoredCandidate struct {
-	candidate *Candidate
-	score     int64
-}
+	err = detectGodotPacks(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting godot packs")
+	}
 
-type FilterParams struct {
-	OS   string
-	Arch string
-}
+	detectGameMakerRunners(container, candidates)
+	detectAIRApps(container, candidates)
+	detectUnityPlayers(container, candidates)
+	detectUnrealGames(container, candidates)
 
-// Filter candidates by OS and/or Arch
-// OS and Arch may be empty strings.
-//
-// Returns a copy of this Verdict.
-func (v Verdict) Filter(consumer *state.Consumer, params FilterParams) Verdict {
-	osFilter := params.OS
-	archFilter := params.Arch
-
-	hasOS := func(os string) bool {
-		return osFilter != "" && osFilter == os
-	}
-	excludesOS := func(os string) bool {
-		return osFilter != "" && osFilter != os
-	}
-	hasArch := func(arch string) bool {
-		return archFilter != "" && archFilter == arch
+	err = detectChromiumShells(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting NW.js and Electron apps")
 	}
 
-	consumer.Debugf("Filtering %d candidates to os (%s), arch (%s)", len(v.Candidates), osFilter, archFilter)
+	err = detectDOSBoxGames(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting DOSBox games")
+	}
 
-	var compatibleCandidates []*Candidate
+	candidates = detectConstructExports(container, candidates)
 
-	// exclude things we can't run at all
-	for _, c := range v.Candidates {
-		keep := true
-
-		consumer.Debugf("Reviewing (%s) flavor %v", c.Path, c.Flavor)
-		switch c.Flavor {
-		case FlavorNativeLinux:
-			if excludesOS("linux") {
-				consumer.Debugf("Excluding (%s) - linux native, os filter is (%s)", c.Path, osFilter)
-				keep = false
-			}
+	if len(candidates) == 0 && container.IsSingleFile() {
+		f := container.Files[0]
 
-			if hasArch("386") && (c.Arch != "" && c.Arch != Arch386) {
-				consumer.Debugf("Excluding (%s) - not 32-bit, but arch filter is (%s)", c.Path, archFilter)
-				keep = false
-			}
-		case FlavorNativeWindows:
-			if excludesOS("windows") {
-				consumer.Debugf("Excluding (%s) - windows native, os filter is (%s)", c.Path, osFilter)
-				keep = false
-			}
-		case FlavorNativeMacos, FlavorAppMacos:
-			if excludesOS("darwin") {
-				consumer.Debugf("Excluding (%s) - darwin (macOS) native, os filter is (%s)", c.Path, osFilter)
-				keep = false
+		if hasExt(f.Path, ".html") {
+			// ok, that's an HTML5 game
+			candidate := &Candidate{
+				Size:   f.Size,
+				Path:   f.Path,
+				Mode:   f.Mode,
+				Depth:  PathDepth(f.Path),
+				Flavor: FlavorHTML,
 			}
+			candidates = append(candidates, candidate)
 		}
+	}
 
-		if keep {
-			compatibleCandidates = append(compatibleCandidates, c)
+	if len(candidates) == 0 {
+		// a lone jar is more likely to be the game than a stray HTML file,
+		// even if its manifest doesn't tell how to run it
+		c, err := jarFallbackCandidate(pool, container)
+		if err != nil {
+			return nil, errors.Wrap(err, "looking for a top-level jar")
+		}
+		if c != nil {
+			consumer.Debugf("No candidates, falling back on top-level jar (%s)", c.Path)
+			candidates = append(candidates, c)
 		}
 	}
-	bestCandidates := compatibleCandidates
 
-	if len(bestCandidates) == 1 {
-		v.Candidates = bestCandidates
-		return v
+	if len(candidates) == 0 && params.StrictHTML && !hasHTMLGameSignals(container) {
+		consumer.Debugf("No game files next to top-level HTML files, not falling back on them")
+	} else if len(candidates) == 0 {
+		// still no candidates? if we have a top-level .html file, let's go for it
+		candidates = append(candidates, htmlFallbackCandidates(container, params.htmlFallbackMaxDepth())...)
 	}
 
-	// now keep all candidates of the lowest depth
-	lowestDepth := 4096
-	for _, c := range v.Candidates {
-		if c.Depth < lowestDepth {
-			lowestDepth = c.Depth
-		}
+	candidates, err = detectWebManifests(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting web app manifests")
 	}
+	detectRPGMakerProjects(container, candidates)
+	detectLoveJSExports(container, candidates)
 
-	bestCandidates = selectByFunc(compatibleCandidates, func(c *Candidate) bool {
-		pass := c.Depth == lowestDepth
-		if !pass {
-			consumer.Debugf("Excluding (%s) - depth %d > lowest depth %d", c.Path, c.Depth, lowestDepth)
-		}
-		return pass
-	})
-
-	if len(bestCandidates) == 1 {
-		v.Candidates = bestCandidates
-		return v
+	err = detectWasmModules(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting WebAssembly modules")
 	}
-
-	// love always wins, in the end
-	{
-		loveCandidates := selectByFlavor(bestCandidates, FlavorLove)
-
-		if len(loveCandidates) == 1 {
-			consumer.Debugf("Found single .love candidate")
-			v.Candidates = loveCandidates
-			return v
-		}
+	err = detectMonoRuntimes(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting Mono runtimes")
 	}
-
-	// on macOS, app bundles win
-	if hasOS("darwin") {
-		appCandidates := selectByFlavor(bestCandidates, FlavorAppMacos)
-
-		if len(appCandidates) > 0 {
-			consumer.Debugf("Found some .app bundles")
-			bestCandidates = appCandidates
-		}
+	detectBundledJava(container, candidates)
+	detectRenpyGames(container, candidates)
+	detectSteamAPI(container, candidates)
+	err = detectDesktopEntries(pool, container, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting desktop entries")
 	}
 
-	// on windows, scripts win
-	if hasOS("windows") {
-		scriptCandidates := selectByFlavor(bestCandidates, FlavorScriptWindows)
-
-		if len(scriptCandidates) == 1 {
-			consumer.Debugf("Found single windows script (%s)", scriptCandidates[0].Path)
-			v.Candidates = scriptCandidates
-			return v
-		}
+	if len(excludeGlobs) > 0 {
+		candidates = selectByFunc(candidates, func(c *Candidate) bool {
+			if isExcluded(excludeGlobs, c.Path) {
+				consumer.Debugf("Excluding (%s) - matches exclude globs", c.Path)
+				tally.excluded++
+				return false
+			}
+			return true
+		})
 	}
 
-	// on linux, scripts win
-	if hasOS("linux") {
-		scriptCandidates := selectByFlavor(bestCandidates, FlavorScript)
-
-		if len(scriptCandidates) == 1 {
-			consumer.Debugf("Found single Linux script (%s)", scriptCandidates[0].Path)
-			v.Candidates = scriptCandidates
-			return v
+	verdict.Candidates = candidates
+	if len(candidates) == 0 {
+		tally.consoleImages, err = countConsoleImages(pool, container)
+		if err != nil {
+			return nil, errors.Wrap(err, "looking for console images")
+		}
+		tally.splitArchiveParts, err = countSplitArchiveParts(pool, container)
+		if err != nil {
+			return nil, errors.Wrap(err, "looking for split archives")
 		}
 	}
+	verdict.Diagnosis = diagnose(tally, candidates)
+	verdict.Warnings = warnings.warnings
 
-	if hasOS("linux") && hasArch("amd64") {
-		consumer.Debugf("Oh boy, we're on 64-bit Linux, let's filter some stuff")
+	return verdict, nil
+}
 
-		linuxCandidates := selectByFlavor(bestCandidates, FlavorNativeLinux)
-		linux64Candidates := selectByArch(linuxCandidates, ArchAmd64)
+type FixPermissionsParams struct {
+	DryRun   bool
+	Consumer *state.Consumer
+}
 
-		if len(linux64Candidates) > 0 {
-			consumer.Debugf("Found some native 64-bit Linux candidates, excluding all others")
+// FixPermissions makes sure all ELF executables, COFF executables,
+// and scripts have the executable bit set. For app bundles, it fixes
+// the bundle's executable and everything in Contents/MacOS.
+// It works on the files in v.BasePath, so it doesn't apply to verdicts
+// from ConfigureFromPool, unless BasePath was set.
+func FixPermissions(v *Verdict, params FixPermissionsParams) ([]string, error) {
+	consumer := params.Consumer
 
-			// on linux 64, 64-bit binaries win
-			bestCandidates = linux64Candidates
-		} else {
-			consumer.Debugf("No native 64-bit Linux candidates, looking for jars")
+	var fixed []string
+	fixedPaths := make(map[string]bool)
 
-			// if no 64-bit binaries, jars win
-			jarCandidates := selectByFlavor(bestCandidates, FlavorJar)
-			if len(jarCandidates) > 0 {
-				consumer.Debugf("Found some jar candidates, excluding all others")
+	fix := func(path string, mode os.FileMode) error {
+		if mode&0100 != 0 || fixedPaths[path] {
+			return nil
+		}
+		consumer.Debugf("Adding missing executable bit for (%s)/(%s)", filepath.Base(v.BasePath), path)
 
-				v.Candidates = jarCandidates
-				return v
+		fixedPaths[path] = true
+		fixed = append(fixed, path)
+		if !params.DryRun {
+			err := os.Chmod(filepath.Join(v.BasePath, path), 0755)
+			if err != nil {
+				return err
 			}
 		}
+		return nil
 	}
 
-	// on windows, non-installers win
-	if hasOS("windows") {
-		windowsCandidates := selectByFlavor(bestCandidates, FlavorNativeWindows)
-		nonInstallerCandidates := selectByFunc(windowsCandidates, func(c *Candidate) bool {
-			if c.WindowsInfo != nil && c.WindowsInfo.InstallerType != "" {
-				consumer.Debugf("Excluding (%s) - installer of type (%s)", c.Path, c.WindowsInfo.InstallerType)
-				return false // false means "is an installer"
+	for _, c := range v.Candidates {
+		if needsExecutableBit(c) {
+			err := fix(c.Path, os.FileMode(c.Mode))
+			if err != nil {
+				return nil, err
 			}
+		}
 
-			fullTargetPath := filepath.FromSlash(c.Path)
-			f, err := os.Open(filepath.Join(v.BasePath, fullTargetPath))
+		if c.Flavor == FlavorAppMacos {
+			exePaths, err := appBundleExecutables(v.BasePath, c.Path)
 			if err != nil {
-				consumer.Warnf("Could not open native windows candidate (%s) for inspection", fullTargetPath)
-				consumer.Warnf("Full error: %#v", err)
-			} else {
-				defer f.Close()
-
-				var peLines []string
-				memConsumer := &state.Consumer{
-					OnMessage: func(lvl string, msg string) {
-						peLines = append(peLines, fmt.Sprintf("pelican> [%s] %s", lvl, msg))
-					},
-				}
+				return nil, errors.Wrapf(err, "finding executables of app bundle (%s)", c.Path)
+			}
 
-				peInfo, err := pelican.Probe(f, pelican.ProbeParams{
-					Consumer: memConsumer,
-				})
+			for _, exePath := range exePaths {
+				stats, err := os.Lstat(filepath.Join(v.BasePath, filepath.FromSlash(exePath)))
 				if err != nil {
-					consumer.Warnf("Could not probe (%s) with pelican", fullTargetPath)
-					consumer.Warnf("Full error: %#v", err)
-					consumer.Warnf("Full pelican log:\n%s", strings.Join(peLines, "\n"))
-				} else {
-					if peInfo.RequiresElevation() {
-						consumer.Debugf("Excluding (%s) - requires elevation", c.Path)
-						return false // false means "is an installer"
-					}
-
-					if peInfo.AssemblyInfo == nil && HasSuspiciouslySetupLikeName(filepath.Base(c.Path)) {
-						consumer.Debugf("Excluding (%s) - no assembly info + has suspiciously setup-like name", c.Path)
-						return false // false means "is an installer"
+					if os.IsNotExist(err) {
+						consumer.Warnf("App bundle (%s) lists missing executable (%s)", c.Path, exePath)
+						continue
 					}
+					return nil, err
+				}
+				if !stats.Mode().IsRegular() {
+					continue
 				}
-			}
-
-			return true // can't tell if installer or not
-		})
-
-		bestCandidates = nonInstallerCandidates
-
-		if len(bestCandidates) == 1 {
-			v.Candidates = bestCandidates
-			return v
-		}
-	}
-
-	// on windows, gui executables win
-	if hasOS("windows") {
-		windowsCandidates := selectByFlavor(bestCandidates, FlavorNativeWindows)
-		guiCandidates := selectByFunc(windowsCandidates, func(c *Candidate) bool {
-			pass := c.WindowsInfo != nil && c.WindowsInfo.Gui
-			if !pass {
-				consumer.Debugf("Considering (%s) for exclusion - not a GUI executable", c.Path)
-			}
-			return pass
-		})
-
-		if len(guiCandidates) > 0 {
-			bestCandidates = guiCandidates
-		}
-
-		if len(bestCandidates) == 1 {
-			v.Candidates = bestCandidates
-			return v
-		}
-	}
-
-	// everywhere, HTMLs lose if there's anything else good
-	{
-		htmlCandidates := selectByFlavor(bestCandidates, FlavorHTML)
-		if len(htmlCandidates) > 0 && len(htmlCandidates) < len(bestCandidates) {
-			consumer.Debugf("Has %d HTML candidates, but %d non-HTML candidates - excluding HTML candidates", len(htmlCandidates), len(bestCandidates)-len(htmlCandidates))
-			bestCandidates = selectByFunc(bestCandidates, func(c *Candidate) bool {
-				return c.Flavor != FlavorHTML
-			})
-		}
-	}
-
-	// everywhere, jars lose if there's anything else good
-	{
-		jarCandidates := selectByFlavor(bestCandidates, FlavorJar)
-		if len(jarCandidates) > 0 && len(jarCandidates) < len(bestCandidates) {
-			consumer.Debugf("Has %d JAR candidates, but %d non-JAR candidates - excluding JAR candidates", len(jarCandidates), len(bestCandidates)-len(jarCandidates))
-			bestCandidates = selectByFunc(bestCandidates, func(c *Candidate) bool {
-				return c.Flavor != FlavorJar
-			})
-		}
-	}
-
-	sort.Stable(&biggestFirst{bestCandidates})
 
-	// score, filter & sort
-	computeScore := func(candidate *Candidate) ScoredCandidate {
-		var score int64 = 100
-		for _, entry := range blacklist {
-			if entry.pattern.MatchString(candidate.Path) {
-				switch entry.penalty.kind {
-				case PenaltyScore:
-					consumer.Debugf("Penalizing (%s) - %d score penalty for pattern %q", candidate.Path, entry.penalty.delta, entry.pattern)
-					score -= entry.penalty.delta
-				case PenaltyExclude:
-					consumer.Debugf("0-scoring (%s) - penalty exclude for pattern %q", candidate.Path, entry.pattern)
-					score = 0
+				err = fix(exePath, stats.Mode())
+				if err != nil {
+					return nil, err
 				}
 			}
 		}
 
-		return ScoredCandidate{candidate, score}
+		c.Mode = 0
 	}
 
-	var scoredCandidates []ScoredCandidate
-	for _, candidate := range bestCandidates {
-		scored := computeScore(candidate)
-		if scored.score > 0 {
-			scoredCandidates = append(scoredCandidates, scored)
-		} else {
-			consumer.Debugf("Excluding (%s) - non-positive score %d", candidate.Path, scored.score)
-		}
-	}
-	sort.Stable(&HighestScoreFirst{scoredCandidates})
-	consumer.Debugf("Sorted candidates: ")
-	for _, sc := range scoredCandidates {
-		consumer.Debugf("- [%d] (%s)", sc.score, sc.candidate.Path)
-	}
+	return fixed, nil
+}
 
-	var finalCandidates []*Candidate
-	for _, scored := range scoredCandidates {
-		finalCandidates = append(finalCandidates, scored.candidate)
+func needsExecutableBit(c *Candidate) bool {
+	switch c.Flavor {
+	case FlavorNativeLinux, FlavorNativeMacos, FlavorScript, FlavorScriptMacos:
+		return true
 	}
 
-	v.Candidates = finalCandidates
-	return v
+	if keepsNativeInfo(c.Flavor) {
+		targetOS := candidateOS(c)
+		return targetOS == "linux" || targetOS == "darwin"
+	}
+	return false
 }
@@ -1,12 +1,28 @@
 package dash_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/itchio/dash"
 	"github.com/itchio/headway/state"
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/pools"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func makeConsumer(t *testing.T) *state.Consumer {
@@ -25,6 +41,28 @@ func configureParams(t *testing.T) dash.ConfigureParams {
 	}
 }
 
+// flavorToy is registered along with sniffToy, to test custom sniffers
+const flavorToy dash.Flavor = "toy"
+
+func init() {
+	dash.RegisterFlavor(flavorToy)
+	dash.RegisterSniffer("toy", sniffToy)
+}
+
+// sniffToy recognizes any .toy file that starts with "TOY"
+func sniffToy(r io.ReadSeeker, path string, size int64) (*dash.Candidate, error) {
+	if filepath.Ext(path) != ".toy" {
+		return nil, nil
+	}
+
+	magic := make([]byte, 3)
+	_, err := io.ReadFull(r, magic)
+	if err != nil || string(magic) != "TOY" {
+		return nil, nil
+	}
+	return &dash.Candidate{Flavor: flavorToy}, nil
+}
+
 func fixParams(t *testing.T) dash.FixPermissionsParams {
 	return dash.FixPermissionsParams{
 		Consumer: makeConsumer(t),
@@ -104,6 +142,7 @@ func Test_ConfigureDarwin(t *testing.T) {
 
 	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
 	assert.EqualValues(t, "Some Grand Game.app", vcopy.Candidates[0].Path, "app wins")
+	assert.EqualValues(t, 55, vcopy.Candidates[0].Size, "app size is the size of its contents")
 }
 
 func Test_ConfigureDarwinNested(t *testing.T) {
@@ -120,6 +159,405 @@ func Test_ConfigureDarwinNested(t *testing.T) {
 
 	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
 	assert.EqualValues(t, "osx64/dragonjousting.app", vcopy.Candidates[0].Path, "app wins")
+
+	for _, sc := range vcopy.ScoredCandidates()[1:] {
+		assert.EqualValues(t, dash.FilterStageFlavor, sc.EliminatedBy, "helper (%s) is eliminated for being embedded", sc.Candidate.Path)
+	}
+}
+
+func Test_SniffMachOMinVersion(t *testing.T) {
+	// thin Mach-O executable with a single load command
+	thin := func(magic uint32, cpuType uint32, cmd []uint32) []byte {
+		var buf bytes.Buffer
+		header := []uint32{magic, cpuType, 3, 2 /* MH_EXECUTE */, 1, uint32(len(cmd) * 4), 0}
+		if magic == 0xFEEDFACF {
+			header = append(header, 0)
+		}
+		assert.NoError(t, binary.Write(&buf, binary.LittleEndian, header))
+		assert.NoError(t, binary.Write(&buf, binary.LittleEndian, cmd))
+		return buf.Bytes()
+	}
+
+	// LC_BUILD_VERSION, macOS 10.13.4
+	modern := thin(0xFEEDFACF, 0x01000007, []uint32{0x32, 24, 1, 0x000A0D04, 0x000A0E00, 0})
+	// LC_VERSION_MIN_MACOSX, 10.9
+	legacy := thin(0xFEEDFACE, 7, []uint32{0x24, 16, 0x000A0900, 0x000A0900})
+	// LC_BUILD_VERSION for iOS 12.0, which doesn't count
+	ios := thin(0xFEEDFACF, 0x01000007, []uint32{0x32, 24, 2, 0x000C0000, 0x000C0000, 0})
+
+	c, err := dash.SniffBytes(modern, "game")
+	assert.NoError(t, err)
+	assert.EqualValues(t, dash.FlavorNativeMacos, c.Flavor)
+	assert.EqualValues(t, "10.13.4", c.MinOSVersion, "reads LC_BUILD_VERSION")
+
+	c, err = dash.SniffBytes(legacy, "game")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "10.9", c.MinOSVersion, "reads LC_VERSION_MIN_MACOSX")
+
+	c, err = dash.SniffBytes(ios, "game")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", c.MinOSVersion, "ignores other platforms")
+
+	c, err = dash.SniffBytes(modern[:40], "game")
+	assert.NoError(t, err)
+	assert.EqualValues(t, dash.FlavorNativeMacos, c.Flavor, "still recognizes truncated executables")
+	assert.EqualValues(t, "", c.MinOSVersion, "leaves truncated load commands alone")
+
+	// universal binary with both slices, 4K-aligned
+	const align = 0x1000
+	var fat bytes.Buffer
+	assert.NoError(t, binary.Write(&fat, binary.BigEndian, []uint32{
+		0xCAFEBABE, 2,
+		7, 3, align, uint32(len(legacy)), 12,
+		0x01000007, 3, 2 * align, uint32(len(modern)), 12,
+	}))
+	fat.Write(make([]byte, align-fat.Len()))
+	fat.Write(legacy)
+	fat.Write(make([]byte, 2*align-fat.Len()))
+	fat.Write(modern)
+
+	c, err = dash.SniffBytes(fat.Bytes(), "game")
+	assert.NoError(t, err)
+	assert.EqualValues(t, dash.FlavorNativeMacos, c.Flavor)
+	assert.EqualValues(t, "10.9", c.MinOSVersion, "picks the lowest version of all slices")
+
+	// two slices for the same architecture
+	var dupe bytes.Buffer
+	assert.NoError(t, binary.Write(&dupe, binary.BigEndian, []uint32{
+		0xCAFEBABE, 2,
+		0x01000007, 3, align, uint32(len(modern)), 12,
+		0x01000007, 3, 2 * align, uint32(len(modern)), 12,
+	}))
+	dupe.Write(make([]byte, align-dupe.Len()))
+	dupe.Write(modern)
+	dupe.Write(make([]byte, 2*align-dupe.Len()))
+	dupe.Write(modern)
+
+	c, err = dash.SniffBytes(dupe.Bytes(), "game")
+	assert.NoError(t, err)
+	if assert.NotNil(t, c, "detects universal binary") {
+		assert.EqualValues(t, []dash.Arch{dash.ArchAmd64}, c.MacosInfo.Archs, "lists each architecture once")
+	}
+
+	// not a fat binary, despite the magic
+	bogus := make([]byte, 64*1024)
+	binary.BigEndian.PutUint32(bogus[0:4], 0xCAFEBABE)
+	binary.BigEndian.PutUint32(bogus[4:8], 3000)
+	c, err = dash.SniffBytes(bogus, "game")
+	assert.NoError(t, err)
+	assert.Nil(t, c, "rejects headers with too many slices")
+
+	root := filepath.Join("testdata", "darwin-universal")
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, "", c.MinOSVersion, "no load commands, no version (%s)", c.Path)
+	}
+}
+
+func Test_FilterLauncherNames(t *testing.T) {
+	root := filepath.Join("testdata", "linux-launchers")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps binary and launcher script") {
+		assert.EqualValues(t, "start.sh", vcopy.Candidates[0].Path, "launcher script wins over the binary it wraps")
+		assert.EqualValues(t, 100+dash.DefaultLauncherBonus, vcopy.ScoredCandidates()[0].Score, "launcher script gets a bonus")
+		assert.EqualValues(t, "Game.x86_64", vcopy.Candidates[1].Path, "binary comes second")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", LauncherNames: []string{"Update"}, LauncherBonus: 7})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps binary and launcher script") {
+		assert.EqualValues(t, "update.sh", vcopy.Candidates[0].Path, "uses custom launcher names")
+		assert.EqualValues(t, 107, vcopy.ScoredCandidates()[0].Score, "uses custom launcher bonus")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", LauncherNames: []string{}})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game.x86_64", vcopy.Candidates[0].Path, "binary wins without launcher names")
+	}
+}
+
+func Test_FilterExecutableBit(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "server", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 2048, Mode: 0644},
+			{Path: "game", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 1024, Mode: 0755},
+		},
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "game", vcopy.Candidates[0].Path, "executable wins over bigger file")
+		assert.EqualValues(t, 105, vcopy.ScoredCandidates()[0].Score, "executable gets a bonus")
+		assert.EqualValues(t, 100, vcopy.ScoredCandidates()[1].Score, "other file doesn't")
+	}
+
+	for _, c := range v.Candidates {
+		c.Mode = 0
+	}
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "server", vcopy.Candidates[0].Path, "bigger file wins without modes")
+	}
+}
+
+func Test_FilterStaticLinking(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "game-dynamic", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 2048, LinuxInfo: &dash.LinuxInfo{}},
+			{Path: "game-static", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 1024, LinuxInfo: &dash.LinuxInfo{StaticallyLinked: true}},
+		},
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "game-static", vcopy.Candidates[0].Path, "static executable wins over bigger file")
+		assert.EqualValues(t, 102, vcopy.ScoredCandidates()[0].Score, "static executable gets a small bonus")
+	}
+}
+
+func Test_FilterMinCandidateSize(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "launch", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 2 * 1024},
+			{Path: "bin/game", Depth: 2, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 50 * 1024},
+			{Path: "bin/index.html", Depth: 2, Flavor: dash.FlavorHTML, Size: 1024},
+		},
+	}
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "disabled by default") {
+		assert.EqualValues(t, "launch", vcopy.Candidates[0].Path, "shallower stub wins by default")
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", MinCandidateSize: 64 * 1024})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps stub alongside deeper executable") {
+		scored := vcopy.ScoredCandidates()
+		assert.EqualValues(t, "bin/game", scored[0].Candidate.Path, "bigger executable wins over stub")
+		assert.EqualValues(t, 92, scored[0].Score, "executable a bit under the threshold gets a small penalty")
+		assert.EqualValues(t, "launch", scored[1].Candidate.Path, "stub comes second")
+		assert.EqualValues(t, 62, scored[1].Score, "stub gets a bigger penalty")
+	}
+
+	v = dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "tool", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4 * 1024},
+			{Path: "docs/index.html", Depth: 2, Flavor: dash.FlavorHTML, Size: 1024},
+		},
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", MinCandidateSize: 64 * 1024})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "small executable isn't excluded") {
+		assert.EqualValues(t, "tool", vcopy.Candidates[0].Path, "small executable wins if there's nothing bigger")
+	}
+}
+
+func Test_FilterStaleCopies(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "game.old", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4096},
+			{Path: "game_OLD", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4096},
+			{Path: "game.orig", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4096},
+			{Path: "game.bak", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4096},
+			{Path: "game", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 1024},
+		},
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 5, len(vcopy.Candidates), "keeps all candidates") {
+		assert.EqualValues(t, "game", vcopy.Candidates[0].Path, "live binary wins over bigger stale copies")
+		for _, sc := range vcopy.ScoredCandidates()[1:] {
+			assert.EqualValues(t, 70, sc.Score, "stale copy is penalized (%s)", sc.Candidate.Path)
+		}
+	}
+
+	v = dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "Backup Simulator.exe", Depth: 1, Flavor: dash.FlavorNativeWindows, Arch: dash.Arch386, Size: 1024},
+			{Path: "Game.exe.bak", Depth: 1, Flavor: dash.FlavorNativeWindows, Arch: dash.Arch386, Size: 4096},
+			{Path: "Game_old.exe", Depth: 1, Flavor: dash.FlavorNativeWindows, Arch: dash.Arch386, Size: 4096},
+		},
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386"})
+	if assert.NotEmpty(t, vcopy.Candidates, "keeps candidates") {
+		assert.EqualValues(t, "Backup Simulator.exe", vcopy.Candidates[0].Path, "game with a backup-like name isn't penalized")
+		assert.EqualValues(t, 100, vcopy.ScoredCandidates()[0].Score, "game with a backup-like name isn't penalized")
+	}
+}
+
+func Test_FilterPatchers(t *testing.T) {
+	penalized := map[string]bool{
+		"patch.exe":          true,
+		"Patcher.exe":        true,
+		"Game Patch 1.2.exe": true,
+		"game_patch.exe":     true,
+		"patch-v2.exe":       true,
+		"Patchwork.exe":      false,
+		"PatchQuest.exe":     false,
+	}
+
+	v := dash.Verdict{}
+	for path := range penalized {
+		v.Candidates = append(v.Candidates, &dash.Candidate{Path: path, Depth: 1, Flavor: dash.FlavorNativeWindows, Arch: dash.Arch386, Size: 1024})
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386"})
+	scored := vcopy.ScoredCandidates()
+	require.Len(t, scored, len(penalized), "keeps all candidates")
+	for _, sc := range scored {
+		expected := int64(100)
+		if penalized[sc.Candidate.Path] {
+			expected = 50
+		}
+		assert.EqualValues(t, expected, sc.Score, "scores (%s)", sc.Candidate.Path)
+	}
+}
+
+func Test_FilterClonesCandidates(t *testing.T) {
+	root := filepath.Join("testdata", "linux")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	modes := make(map[string]uint32)
+	for _, c := range v.Candidates {
+		modes[c.Path] = c.Mode
+	}
+
+	first := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.NotEmpty(t, first.Candidates, "keeps candidates") {
+		for _, c := range first.Candidates {
+			for _, orig := range v.Candidates {
+				assert.False(t, c == orig, "doesn't alias input candidates (%s)", c.Path)
+			}
+		}
+		for _, sc := range first.ScoredCandidates() {
+			for _, orig := range v.Candidates {
+				assert.False(t, sc.Candidate == orig, "doesn't alias input candidates in scores (%s)", sc.Candidate.Path)
+			}
+		}
+		assert.True(t, first.ScoredCandidates()[0].Candidate == first.Candidates[0], "scores point at the returned candidates")
+
+		first.Candidates[0].Mode = 0
+		first.Candidates[0].Flavor = dash.FlavorArchive
+	}
+
+	second := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, first.Candidates[0].Path, second.Candidates[0].Path, "second filter picks the same candidate")
+	assert.EqualValues(t, modes[second.Candidates[0].Path], second.Candidates[0].Mode, "second filter sees the original mode")
+	assert.NotEqual(t, dash.FlavorArchive, second.Candidates[0].Flavor, "second filter sees the original flavor")
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, modes[c.Path], c.Mode, "original candidates are untouched (%s)", c.Path)
+	}
+}
+
+func Test_FilteredCopy(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	var before []dash.Candidate
+	for _, c := range v.Candidates {
+		before = append(before, *c.Clone())
+	}
+
+	for _, keepAll := range []bool{false, true} {
+		params := dash.FilterParams{OS: "windows", Arch: "amd64", KeepAll: keepAll}
+		filtered := v.FilteredCopy(makeConsumer(t), params)
+		if assert.NotEmpty(t, filtered.Candidates, "keeps candidates (keepAll=%v)", keepAll) {
+			assert.False(t, filtered == v, "returns a new verdict (keepAll=%v)", keepAll)
+			assert.EqualValues(t, "launcher.bat", filtered.Candidates[0].Path, "picks the best candidate (keepAll=%v)", keepAll)
+			for _, c := range filtered.Candidates {
+				c.Path = "mangled"
+				c.Flavor = dash.FlavorArchive
+			}
+		}
+
+		assert.Nil(t, v.ScoredCandidates(), "doesn't add rationale to the original (keepAll=%v)", keepAll)
+		if assert.EqualValues(t, len(before), len(v.Candidates), "keeps original candidates (keepAll=%v)", keepAll) {
+			for i, c := range v.Candidates {
+				assert.EqualValues(t, before[i], *c, "original candidate is unchanged (keepAll=%v)", keepAll)
+			}
+		}
+	}
+
+	params := dash.FilterParams{OS: "windows", Arch: "amd64"}
+	assert.EqualValues(t, *v.FilteredCopy(makeConsumer(t), params), v.Filter(makeConsumer(t), params), "Filter is a wrapper around FilteredCopy")
+}
+
+func Test_FilterArchSuffix(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "crashreporter", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 4096},
+			{Path: "Game.x86_64", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 1024},
+		},
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "Game.x86_64", vcopy.Candidates[0].Path, "arch-suffixed name wins over bigger file")
+		assert.EqualValues(t, 103, vcopy.ScoredCandidates()[0].Score, "arch-suffixed name gets a small bonus")
+	}
+
+	// the suffix has to match the arch we're filtering for
+	v = dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "crashreporter", Depth: 1, Flavor: dash.FlavorNativeLinux, Size: 4096},
+			{Path: "Game.x86", Depth: 1, Flavor: dash.FlavorNativeLinux, Size: 1024},
+		},
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "crashreporter", vcopy.Candidates[0].Path, "32-bit suffix gets no bonus on amd64")
+		assert.EqualValues(t, 100, vcopy.ScoredCandidates()[1].Score, "32-bit suffix gets no bonus on amd64")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "386"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates") {
+		assert.EqualValues(t, "Game.x86", vcopy.Candidates[0].Path, "32-bit suffix wins on 386")
+	}
+}
+
+func Test_FilterEmbeddedHelpers(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{
+				Path:        "Game/Game.exe",
+				Depth:       2,
+				Flavor:      dash.FlavorElectron,
+				Arch:        dash.ArchAmd64,
+				Size:        1024,
+				WindowsInfo: &dash.WindowsInfo{Gui: true},
+			},
+			{
+				// same folder, spelled differently
+				Path:        `game\Resources\app.asar.unpacked\ffmpeg.exe`,
+				Depth:       1,
+				Flavor:      dash.FlavorNativeWindows,
+				Arch:        dash.ArchAmd64,
+				Size:        4096,
+				WindowsInfo: &dash.WindowsInfo{Gui: true},
+			},
+		},
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game/Game.exe", vcopy.Candidates[0].Path, "app wins over its embedded helper")
+	}
+
+	v.Candidates[0].Flavor = dash.FlavorNativeWindows
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, `game\Resources\app.asar.unpacked\ffmpeg.exe`, vcopy.Candidates[0].Path, "resources of plain executables aren't special")
+	}
 }
 
 func Test_ConfigureDarwinGhost(t *testing.T) {
@@ -138,6 +576,138 @@ func Test_ConfigureDarwinGhost(t *testing.T) {
 	assert.EqualValues(t, "Awesome Stuff.app", vcopy.Candidates[0].Path, "valid app bundle wins")
 }
 
+func Test_VerdictWarnings(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-ghost")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, []dash.VerdictWarning{
+		{
+			Path:     "AwesomeStuff.app",
+			Message:  "Found app bundle without an Info.plist: AwesomeStuff.app",
+			Severity: dash.WarningSeverityInfo,
+		},
+	}, v.Warnings, "records skipped app bundles")
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "darwin"})
+	assert.EqualValues(t, v.Warnings, vcopy.Warnings, "keeps warnings from Configure")
+
+	// the windows executables aren't there, so they can't be probed
+	for _, name := range []string{"game.exe", "tool.exe"} {
+		v.Candidates = append(v.Candidates, &dash.Candidate{
+			Path:        name,
+			Depth:       1,
+			Flavor:      dash.FlavorNativeWindows,
+			Size:        1000,
+			WindowsInfo: &dash.WindowsInfo{Gui: true},
+		})
+	}
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OSes: []string{"windows", "darwin", "windows"}})
+	if assert.EqualValues(t, 3, len(vcopy.Warnings), "adds warnings from Filter, once") {
+		assert.EqualValues(t, v.Warnings[0], vcopy.Warnings[0], "keeps warnings from Configure first")
+		for i, name := range []string{"game.exe", "tool.exe"} {
+			w := vcopy.Warnings[i+1]
+			assert.EqualValues(t, name, w.Path, "records path")
+			assert.EqualValues(t, dash.WarningSeverityWarning, w.Severity, "records severity")
+			assert.Contains(t, w.Message, "Could not open", "records message")
+		}
+	}
+	assert.EqualValues(t, 1, len(v.Warnings), "doesn't change the original verdict")
+
+	pv := vcopy.Public()
+	assert.EqualValues(t, vcopy.Warnings, pv.Warnings, "public verdicts have warnings")
+
+	marshalled, err := json.Marshal(vcopy)
+	assert.NoError(t, err, "marshals verdict")
+	var unmarshalled dash.Verdict
+	assert.NoError(t, json.Unmarshal(marshalled, &unmarshalled), "unmarshals verdict")
+	assert.EqualValues(t, vcopy.Warnings, unmarshalled.Warnings, "warnings survive JSON")
+}
+
+func Test_ConfigureCaseSensitive(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-case")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	plist, err := ioutil.ReadFile(filepath.Join("testdata", "darwin", "Some Grand Game.app", "Contents", "Info.plist"))
+	assert.NoError(t, err, "reads test file")
+
+	for _, name := range []string{"Good.app/Contents/Info.plist", "Bad.app/contents/info.plist"} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder")
+		assert.NoError(t, ioutil.WriteFile(p, plist, 0644), "writes %s", name)
+	}
+
+	bundles := func(caseSensitive bool) ([]string, []string) {
+		var warnings []string
+		params := configureParams(t)
+		params.CaseSensitive = caseSensitive
+		params.Consumer.OnMessage = func(lvl string, msg string) {
+			if lvl == "warning" {
+				warnings = append(warnings, msg)
+			}
+		}
+
+		v, err := dash.Configure(root, params)
+		assert.NoError(t, err, "walks without problems")
+
+		var paths []string
+		for _, c := range v.Candidates {
+			if c.Flavor == dash.FlavorAppMacos {
+				paths = append(paths, c.Path)
+			}
+		}
+		return paths, warnings
+	}
+
+	paths, warnings := bundles(true)
+	assert.EqualValues(t, []string{"Good.app"}, paths, "only finds the correctly-cased bundle")
+	if assert.EqualValues(t, 1, len(warnings), "warns about the other one") {
+		assert.Contains(t, warnings[0], "Bad.app", "reports it as malformed")
+	}
+
+	paths, warnings = bundles(false)
+	assert.ElementsMatch(t, []string{"Bad.app", "Good.app"}, paths, "finds both bundles")
+	assert.Empty(t, warnings, "doesn't warn")
+
+	// installer packages too, but not the files detectors look for
+	// next to candidates
+	root, err = ioutil.TempDir("", "dash-case")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	game, err := ioutil.ReadFile(filepath.Join("testdata", "nwjs", "Game"))
+	assert.NoError(t, err, "reads test file")
+	files := map[string][]byte{
+		"Good.pkg/Contents/Archive.pax.gz": []byte("payload"),
+		"Bad.pkg/contents/archive.pax.gz":  []byte("payload"),
+		"nw/Game":                          game,
+		"nw/Package.JSON":                  []byte(`{"name": "game", "main": "index.html"}`),
+	}
+	for name, contents := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder")
+		assert.NoError(t, ioutil.WriteFile(p, contents, 0644), "writes %s", name)
+	}
+
+	for _, caseSensitive := range []bool{true, false} {
+		params := configureParams(t)
+		params.CaseSensitive = caseSensitive
+
+		v, err := dash.Configure(root, params)
+		assert.NoError(t, err, "walks without problems")
+
+		flavors := make(map[string]dash.Flavor)
+		for _, c := range v.Candidates {
+			flavors[c.Path] = c.Flavor
+		}
+		assert.EqualValues(t, dash.FlavorPkgMacos, flavors["Good.pkg"], "finds the correctly-cased package (case-sensitive: %v)", caseSensitive)
+		_, hasBad := flavors["Bad.pkg"]
+		assert.EqualValues(t, !caseSensitive, hasBad, "finds the other one only when case-insensitive (case-sensitive: %v)", caseSensitive)
+		assert.EqualValues(t, dash.FlavorNWjs, flavors["nw/Game"], "finds the NW.js manifest either way (case-sensitive: %v)", caseSensitive)
+	}
+}
+
 func Test_ConfigureDarwinSymlink(t *testing.T) {
 	root := filepath.Join("testdata", "darwin-symlink")
 
@@ -171,6 +741,115 @@ func Test_ConfigureLinux(t *testing.T) {
 	assert.EqualValues(t, "OpenHexagon", vcopy.Candidates[0].Path, "launcher script wins")
 }
 
+func Test_ConfigureLinuxSymlink(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-symlink")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "bin"), 0755), "creates bin folder")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "bin", "game-1.2.x86_64"), elf, 0644), "writes binary")
+
+	links := map[string]string{
+		"game":     "bin/game-1.2.x86_64",
+		"loop-a":   "loop-b",
+		"loop-b":   "loop-a",
+		"outside":  "../../etc/passwd",
+		"absolute": "/bin/sh",
+		"dangling": "bin/missing",
+	}
+	for name, dest := range links {
+		err := os.Symlink(dest, filepath.Join(root, name))
+		if err != nil {
+			t.Skipf("can't create symlinks: %+v", err)
+		}
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "finds the symlinked launcher only") {
+		c := v.Candidates[0]
+		assert.EqualValues(t, "game", c.Path, "attributes candidate to the symlink")
+		assert.EqualValues(t, 1, c.Depth, "uses the symlink's depth")
+		assert.EqualValues(t, dash.FlavorNativeLinux, c.Flavor, "sniffs the target")
+		assert.EqualValues(t, dash.ArchAmd64, c.Arch, "sniffs the target")
+		assert.EqualValues(t, len(elf), c.Size, "uses the target's size")
+	}
+
+	fixed, err := dash.FixPermissions(v, fixParams(t))
+	assert.NoError(t, err, "fixes permissions without problems")
+	assert.EqualValues(t, []string{"game"}, fixed, "goes by the target's permissions")
+
+	// symlinks to the same file are separate candidates
+	assert.NoError(t, os.Symlink("bin/game-1.2.x86_64", filepath.Join(root, "play")), "creates symlink")
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	require.Len(t, v.Candidates, 2, "finds both symlinked launchers")
+	a, b := v.Candidates[0], v.Candidates[1]
+	require.NotNil(t, a.LinuxInfo, "sniffs the target")
+	require.NotNil(t, b.LinuxInfo, "sniffs the target")
+	a.LinuxInfo.MinGlibcVersion = "2.99"
+	assert.Empty(t, b.LinuxInfo.MinGlibcVersion, "doesn't share info between symlinks")
+}
+
+func Test_ConfigureLinuxSymlinkedFolder(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-symlink")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "x86_64"), 0755), "creates arch folder")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "x86_64", "game"), elf, 0755), "writes binary")
+
+	links := map[string]string{
+		"bin":   "x86_64",
+		"game":  "bin/game",
+		"loop":  "cycle/game",
+		"cycle": "loop",
+	}
+	for name, dest := range links {
+		err := os.Symlink(dest, filepath.Join(root, name))
+		if err != nil {
+			t.Skipf("can't create symlinks: %+v", err)
+		}
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "follows symlinks through symlinked folders") {
+		c := v.Candidates[0]
+		assert.EqualValues(t, "game", c.Path, "attributes candidate to the symlink")
+		assert.EqualValues(t, dash.FlavorNativeLinux, c.Flavor, "sniffs the target")
+		assert.EqualValues(t, len(elf), c.Size, "uses the target's size")
+	}
+}
+
+func Test_ConfigureLinuxPython(t *testing.T) {
+	root := filepath.Join("testdata", "linux-python")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "pack-assets":
+			assert.EqualValues(t, "python3", c.ScriptInfo.Interpreter, "sees through env")
+			assert.True(t, c.ScriptInfo.Env, "sees through env")
+			assert.EqualValues(t, dash.ScriptKindPython, c.ScriptInfo.Kind, "detects python scripts")
+		case "tools/convert":
+			assert.EqualValues(t, "/usr/bin/python2.7", c.ScriptInfo.Interpreter, "reads interpreter")
+			assert.EqualValues(t, dash.ScriptKindPython, c.ScriptInfo.Kind, "detects python scripts")
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.x86_64", vcopy.Candidates[0].Path, "python script doesn't beat native executable")
+}
+
 func Test_ConfigureLinuxLibs(t *testing.T) {
 	root := filepath.Join("testdata", "linux-libs")
 
@@ -210,6 +889,174 @@ func Test_ConfigureLinuxDualArch(t *testing.T) {
 	assert.EqualValues(t, "Game.x86_64", v64.Candidates[0].Path, "launcher script wins")
 }
 
+// withGlibcVersions appends a string table and a .gnu.version_r section
+// to a little-endian ELF64 executable, along with section headers for them
+func withGlibcVersions(t *testing.T, elf []byte, libs map[string][]string) []byte {
+	var strtab bytes.Buffer
+	strtab.WriteByte(0)
+	addString := func(s string) uint32 {
+		offset := uint32(strtab.Len())
+		strtab.WriteString(s)
+		strtab.WriteByte(0)
+		return offset
+	}
+
+	var names []string
+	for name := range libs {
+		names = append(names, name)
+	}
+
+	var verneed bytes.Buffer
+	le := binary.LittleEndian
+	for i, name := range names {
+		versions := libs[name]
+		next := uint32(16 + 16*len(versions))
+		if i == len(names)-1 {
+			next = 0
+		}
+		assert.NoError(t, binary.Write(&verneed, le, []uint16{1, uint16(len(versions))}))
+		assert.NoError(t, binary.Write(&verneed, le, []uint32{addString(name), 16, next}))
+		for j, version := range versions {
+			auxNext := uint32(16)
+			if j == len(versions)-1 {
+				auxNext = 0
+			}
+			assert.NoError(t, binary.Write(&verneed, le, []uint32{0, 0, addString(version), auxNext}))
+		}
+	}
+
+	res := append([]byte{}, elf...)
+	strOffset := uint64(len(res))
+	res = append(res, strtab.Bytes()...)
+	verneedOffset := uint64(len(res))
+	res = append(res, verneed.Bytes()...)
+	shOffset := uint64(len(res))
+
+	// null section, then .dynstr, then .gnu.version_r, linked to .dynstr
+	sections := make([]byte, 3*64)
+	le.PutUint32(sections[64+4:], 3)
+	le.PutUint64(sections[64+24:], strOffset)
+	le.PutUint64(sections[64+32:], uint64(strtab.Len()))
+	le.PutUint32(sections[128+4:], 0x6FFFFFFE)
+	le.PutUint64(sections[128+24:], verneedOffset)
+	le.PutUint64(sections[128+32:], uint64(verneed.Len()))
+	le.PutUint32(sections[128+40:], 1)
+	res = append(res, sections...)
+
+	le.PutUint64(res[0x28:], shOffset)
+	le.PutUint16(res[0x3A:], 64)
+	le.PutUint16(res[0x3C:], 3)
+	return res
+}
+
+func Test_ConfigureDeepELF(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-deep-elf")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	game := withGlibcVersions(t, elf, map[string][]string{
+		"libc.so.6":      {"GLIBC_2.2.5", "GLIBC_2.17", "GLIBC_PRIVATE"},
+		"libstdc++.so.6": {"GLIBCXX_3.4.21"},
+	})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game.x86_64"), game, 0755), "writes game")
+	// no section headers at all, like sstrip'd executables
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "stripped.x86_64"), elf, 0755), "writes stripped game")
+
+	glibcVersions := func(v *dash.Verdict) map[string]string {
+		res := make(map[string]string)
+		for _, c := range v.Candidates {
+			if assert.NotNil(t, c.LinuxInfo, "has linux info (%s)", c.Path) {
+				res[c.Path] = c.LinuxInfo.MinGlibcVersion
+			}
+		}
+		return res
+	}
+
+	params := configureParams(t)
+	params.Cache = dash.NewSniffCache()
+	v, err := dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, map[string]string{
+		"game.x86_64":     "",
+		"stripped.x86_64": "",
+	}, glibcVersions(v), "doesn't look up glibc versions by default")
+
+	params.DeepELF = true
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, map[string]string{
+		"game.x86_64":     "2.17",
+		"stripped.x86_64": "",
+	}, glibcVersions(v), "finds the highest glibc version, and isn't fooled by the cache")
+
+	var warnings []string
+	consumer := makeConsumer(t)
+	onMessage := consumer.OnMessage
+	consumer.OnMessage = func(lvl string, msg string) {
+		if lvl == "warning" {
+			warnings = append(warnings, msg)
+		}
+		onMessage(lvl, msg)
+	}
+
+	vcopy := v.FilteredCopy(consumer, dash.FilterParams{OS: "linux", Arch: "amd64", GlibcVersion: "2.27"})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both candidates")
+	assert.Empty(t, warnings, "doesn't warn about candidates that run on the system")
+
+	vcopy = v.FilteredCopy(consumer, dash.FilterParams{OS: "linux", Arch: "amd64", GlibcVersion: "2.12"})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "doesn't exclude candidates that need a newer glibc")
+	if assert.EqualValues(t, 1, len(warnings), "warns about candidates that need a newer glibc") {
+		assert.Contains(t, warnings[0], "game.x86_64")
+		assert.Contains(t, warnings[0], "2.17")
+	}
+}
+
+func Test_ConfigureDesktopEntry(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-desktop-entry")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	for _, name := range []string{"crashreporter", "bin/My Game.x86_64", "tools/editor.x86_64"} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755), "creates folder")
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), elf, 0755), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left") {
+		assert.EqualValues(t, "crashreporter", vcopy.Candidates[0].Path, "shallowest wins without a desktop entry")
+	}
+
+	entry := "[Desktop Entry]\nType=Application\nName=My Game\nExec=env LD_LIBRARY_PATH=lib \"./My Game.x86_64\" %U\nPath=bin\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "My Game.desktop"), []byte(entry), 0644), "writes desktop entry")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "desktop entries aren't candidates")
+	for _, c := range v.Candidates {
+		if c.Path == "bin/My Game.x86_64" {
+			assert.EqualValues(t, "My Game.desktop", c.DesktopEntry, "is launched by the desktop entry")
+		} else {
+			assert.EqualValues(t, "", c.DesktopEntry, "isn't launched by the desktop entry (%s)", c.Path)
+		}
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left") {
+		assert.EqualValues(t, "bin/My Game.x86_64", vcopy.Candidates[0].Path, "what the desktop entry launches wins")
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 0, len(vcopy.Candidates), "desktop entries don't matter on windows")
+}
+
 func Test_ConfigureHtmlMany(t *testing.T) {
 	root := filepath.Join("testdata", "html", "many")
 
@@ -255,6 +1102,31 @@ func Test_ConfigureBiggerIsBetter(t *testing.T) {
 	assert.EqualValues(t, "tiled.exe", vcopy.Candidates[0].Path, "biggest wins")
 }
 
+func Test_ConfigureExcludeGlobs(t *testing.T) {
+	root := filepath.Join("testdata", "windows-redist")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+	totalSize := v.TotalSize
+
+	for _, globs := range [][]string{{"redist/*"}, {"REDIST"}, {"*/vcredist.exe"}, {"tools", "redist/"}} {
+		params := configureParams(t)
+		params.ExcludeGlobs = globs
+		v, err := dash.Configure(root, params)
+		assert.NoError(t, err, "walks without problems (%v)", globs)
+		if assert.EqualValues(t, 1, len(v.Candidates), "excludes matching candidates (%v)", globs) {
+			assert.EqualValues(t, "Game.exe", v.Candidates[0].Path, "keeps other candidates (%v)", globs)
+		}
+		assert.EqualValues(t, totalSize, v.TotalSize, "still counts excluded files (%v)", globs)
+	}
+
+	params := configureParams(t)
+	params.ExcludeGlobs = []string{"[redist"}
+	_, err = dash.Configure(root, params)
+	assert.Error(t, err, "rejects invalid globs")
+}
+
 func Test_ConfigureBlacklist(t *testing.T) {
 	root := filepath.Join("testdata", "linux-nodewebkit")
 
@@ -267,3 +1139,2916 @@ func Test_ConfigureBlacklist(t *testing.T) {
 	assert.EqualValues(t, 3, len(vcopy.Candidates), "three candidates left after filtering")
 	assert.EqualValues(t, "nw", vcopy.Candidates[0].Path, "non-nacl helper wins")
 }
+
+func Test_ConfigureGodot(t *testing.T) {
+	root := filepath.Join("testdata", "godot")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 1, len(v.Candidates), "finds a single candidate on first walk")
+	assert.EqualValues(t, dash.FlavorGodot, v.Candidates[0].Flavor, "exe is a godot game")
+	assert.EqualValues(t, "game.pck", v.Candidates[0].GodotInfo.PackPath, "pack is associated with exe")
+	assert.EqualValues(t, 1, v.Candidates[0].Depth, "candidate is at the right depth")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "godot game wins")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 0, len(vcopy.Candidates), "windows godot game is excluded on linux")
+}
+
+func Test_ConfigureGodotEmbedded(t *testing.T) {
+	root := filepath.Join("testdata", "godot-embedded")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "godot game wins over bigger exe")
+	assert.True(t, vcopy.Candidates[0].GodotInfo.Embedded, "pack is embedded")
+}
+
+func Test_ConfigureLinuxAppImage(t *testing.T) {
+	root := filepath.Join("testdata", "linux-appimage")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "bin/Game.AppImage", vcopy.Candidates[0].Path, "deeper AppImage wins over script")
+	assert.True(t, vcopy.Candidates[0].LinuxInfo.AppImage, "is flagged as AppImage")
+}
+
+func Test_ConfigureScriptInstaller(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-makeself")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	header := func(label string) []byte {
+		script := "#!/bin/sh\n" +
+			"# This script was generated using Makeself 2.1.5\n" +
+			"\n" +
+			"CRCsum=\"1234567890\"\n" +
+			"label=\"" + label + "\"\n" +
+			"script=\"./startmojo.sh\"\n"
+		// the payload is appended after the header
+		return append([]byte(script), make([]byte, 1024)...)
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game_1.0.sh"), header("Mojo Setup"), 0755), "writes installer")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "finds installer") {
+		c := v.Candidates[0]
+		assert.EqualValues(t, dash.FlavorScript, c.Flavor, "is a script")
+		assert.EqualValues(t, dash.ScriptInstallerMojoSetup, c.ScriptInfo.Installer, "is flagged as a MojoSetup installer")
+		assert.True(t, c.IsInstaller(v.BasePath, makeConsumer(t)), "is an installer")
+	}
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "keeps installer when it's all there is") {
+		assert.EqualValues(t, "game_1.0.sh", vcopy.Candidates[0].Path, "surfaces the installer")
+	}
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "game"), 0755), "creates folder")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game", "Game.x86_64"), elf, 0755), "writes game")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "tools.run"), header("Game tools"), 0755), "writes makeself archive")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates")
+	for _, c := range v.Candidates {
+		if c.Path == "tools.run" {
+			assert.EqualValues(t, dash.ScriptInstallerMakeself, c.ScriptInfo.Installer, "is flagged as a makeself archive")
+		}
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left") {
+		assert.EqualValues(t, "game/Game.x86_64", vcopy.Candidates[0].Path, "deeper game wins over installers")
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", AllowInstallers: true, KeepAll: true})
+	assert.EqualValues(t, 3, len(vcopy.Candidates), "keeps installers when they're allowed")
+}
+
+func Test_FilterExtractedAppImage(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-appimage")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	appImage, err := ioutil.ReadFile(filepath.Join("testdata", "linux-appimage", "bin", "Game.AppImage"))
+	assert.NoError(t, err, "reads test file")
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	files := map[string][]byte{
+		"Game.AppImage":                      appImage,
+		"Game.AppImage.zsync":                []byte("zsync: 0.6.2\nFilename: Game.AppImage\n"),
+		"Game.AppDir/AppRun":                 []byte("#!/bin/sh\nexec \"$APPDIR/usr/bin/game\"\n"),
+		"Game.AppDir/usr/bin/game":           elf,
+		"squashfs-root/usr/bin/game-updater": elf,
+	}
+	for name, contents := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755), "creates folder")
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0755), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		assert.NotEqual(t, "Game.AppImage.zsync", c.Path, "zsync metadata isn't a candidate")
+	}
+	assert.EqualValues(t, 4, len(v.Candidates), "finds the AppImage and its extracted contents")
+
+	for _, targetOS := range []string{"", "linux"} {
+		vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: targetOS, Arch: "amd64"})
+		if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left (os %q)", targetOS) {
+			assert.EqualValues(t, "Game.AppImage", vcopy.Candidates[0].Path, "AppImage wins over its extracted contents (os %q)", targetOS)
+		}
+	}
+
+	assert.NoError(t, os.Remove(filepath.Join(root, "Game.AppImage")), "removes AppImage")
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{KeepAll: true})
+	assert.EqualValues(t, 3, len(vcopy.Candidates), "extracted contents are kept when there's no AppImage")
+}
+
+func Test_ConfigureCorrupt(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-corrupt")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	var jarBuf bytes.Buffer
+	zw := zip.NewWriter(&jarBuf)
+	mw, err := zw.Create("META-INF/MANIFEST.MF")
+	assert.NoError(t, err, "creates manifest")
+	_, err = mw.Write([]byte("Manifest-Version: 1.0\nMain-Class: com.example.Game\n"))
+	assert.NoError(t, err, "writes manifest")
+	assert.NoError(t, zw.Close(), "closes zip")
+	jar := jarBuf.Bytes()
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	files := map[string][]byte{
+		// partial upload, the central directory is missing
+		"game.jar": jar[:len(jar)/2],
+		// cut short in the middle of the ELF header
+		"game.x86_64": elf[:40],
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0755), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "corrupt files don't fail configure")
+	flavors := make(map[string]dash.Flavor)
+	for _, c := range v.Candidates {
+		assert.True(t, c.Corrupt, "is flagged as corrupt (%s)", c.Path)
+		flavors[c.Path] = c.Flavor
+	}
+	assert.EqualValues(t, map[string]dash.Flavor{
+		"game.jar":    dash.FlavorJar,
+		"game.x86_64": dash.FlavorNativeLinux,
+	}, flavors, "still recognizes flavors")
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", KeepAll: true})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps corrupt candidates when there's nothing else")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "bin"), 0755), "creates folder")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "bin", "game.x86_64"), elf, 0755), "writes game")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates")
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left") {
+		assert.EqualValues(t, "bin/game.x86_64", vcopy.Candidates[0].Path, "deeper intact executable wins")
+		assert.False(t, vcopy.Candidates[0].Corrupt, "isn't flagged as corrupt")
+	}
+}
+
+func Test_CandidateLaunchSpec(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+	absRoot, err := filepath.Abs(root)
+	assert.NoError(t, err, "gets absolute path")
+	abs := func(p string) string {
+		return filepath.Join(absRoot, filepath.FromSlash(p))
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		if c.Path != "game.exe" {
+			continue
+		}
+		spec, err := c.LaunchSpec(root, "windows")
+		if assert.NoError(t, err, "launches native executable") {
+			assert.EqualValues(t, abs("game.exe"), spec.Path, "runs executable as-is")
+			assert.Empty(t, spec.Args, "passes no arguments")
+			assert.EqualValues(t, absRoot, spec.Dir, "starts from the executable's folder")
+		}
+
+		_, err = c.LaunchSpec(root, "linux")
+		assert.Error(t, err, "windows executables don't run on linux")
+	}
+
+	cases := []struct {
+		name      string
+		candidate dash.Candidate
+		targetOS  string
+		path      string
+		args      []string
+	}{
+		{"linux", dash.Candidate{Path: "bin/game", Flavor: dash.FlavorNativeLinux, LinuxInfo: &dash.LinuxInfo{}}, "linux", abs("bin/game"), nil},
+		{"mono", dash.Candidate{Path: "Game.exe", Flavor: dash.FlavorNativeWindows, WindowsInfo: &dash.WindowsInfo{DotNet: true, MonoRuntime: "mono"}}, "linux", "mono", []string{abs("Game.exe")}},
+		{"dosbox", dash.Candidate{Path: "dosbox/dosbox.exe", Flavor: dash.FlavorDOSBox, WindowsInfo: &dash.WindowsInfo{}, DOSBoxInfo: &dash.DOSBoxInfo{ConfigPath: "game.conf"}}, "windows", abs("dosbox/dosbox.exe"), []string{"-conf", abs("game.conf")}},
+		{"app", dash.Candidate{Path: "Game.app", Flavor: dash.FlavorAppMacos}, "darwin", "open", []string{"-W", abs("Game.app")}},
+		{"script", dash.Candidate{Path: "start.sh", Flavor: dash.FlavorScript, ScriptInfo: &dash.ScriptInfo{Interpreter: "/bin/bash"}}, "linux", "/bin/bash", []string{abs("start.sh")}},
+		{"env-script", dash.Candidate{Path: "start.py", Flavor: dash.FlavorScript, ScriptInfo: &dash.ScriptInfo{Interpreter: "python3", Env: true}}, "darwin", "python3", []string{abs("start.py")}},
+		{"batch", dash.Candidate{Path: "play.bat", Flavor: dash.FlavorScriptWindows}, "windows", "cmd.exe", []string{"/c", abs("play.bat")}},
+		{"jar", dash.Candidate{Path: "game.jar", Flavor: dash.FlavorJar, JarInfo: &dash.JarInfo{Runnable: true}}, "linux", "java", []string{"-jar", abs("game.jar")}},
+		{"bundled-jar", dash.Candidate{Path: "game.jar", Flavor: dash.FlavorJar, JarInfo: &dash.JarInfo{Runnable: true, BundledJava: "jre/bin/java.exe"}}, "windows", abs("jre/bin/java.exe"), []string{"-jar", abs("game.jar")}},
+		{"love", dash.Candidate{Path: "game.love", Flavor: dash.FlavorLove}, "", "love", []string{abs("game.love")}},
+		{"msi", dash.Candidate{Path: "setup.msi", Flavor: dash.FlavorMSI}, "windows", "msiexec", []string{"/i", abs("setup.msi")}},
+	}
+	for _, tc := range cases {
+		spec, err := tc.candidate.LaunchSpec(root, tc.targetOS)
+		if assert.NoError(t, err, "launches candidate (%s)", tc.name) {
+			assert.EqualValues(t, tc.path, spec.Path, "runs the right program (%s)", tc.name)
+			assert.EqualValues(t, tc.args, spec.Args, "passes the right arguments (%s)", tc.name)
+		}
+	}
+
+	failures := []struct {
+		name      string
+		candidate dash.Candidate
+		targetOS  string
+	}{
+		{"archive", dash.Candidate{Path: "game.7z", Flavor: dash.FlavorArchive}, ""},
+		{"dmg", dash.Candidate{Path: "Game.dmg", Flavor: dash.FlavorDmgMacos}, "darwin"},
+		{"mobile", dash.Candidate{Path: "game.apk", Flavor: dash.FlavorMobile}, ""},
+		{"html", dash.Candidate{Path: "index.html", Flavor: dash.FlavorHTML}, ""},
+		{"compressed", dash.Candidate{Path: "game.gz", Flavor: dash.FlavorNativeLinux, Compressed: true, LinuxInfo: &dash.LinuxInfo{}}, "linux"},
+		{"dos", dash.Candidate{Path: "GAME.EXE", Flavor: dash.FlavorNativeWindows, WindowsInfo: &dash.WindowsInfo{DOS: true}}, "windows"},
+		{"app-on-windows", dash.Candidate{Path: "Game.app", Flavor: dash.FlavorAppMacos}, "windows"},
+		{"batch-on-linux", dash.Candidate{Path: "play.bat", Flavor: dash.FlavorScriptWindows}, "linux"},
+		{"linux-script-on-darwin", dash.Candidate{Path: "start.sh", Flavor: dash.FlavorScript, ScriptInfo: &dash.ScriptInfo{LinuxOnly: true}}, "darwin"},
+	}
+	for _, tc := range failures {
+		_, err := tc.candidate.LaunchSpec(root, tc.targetOS)
+		assert.Error(t, err, "can't launch candidate (%s)", tc.name)
+	}
+}
+
+func Test_VerdictJSON(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all candidates on first walk")
+
+	marshalled, err := json.Marshal(v)
+	assert.NoError(t, err, "marshals without problems")
+
+	var unmarshalled dash.Verdict
+	err = json.Unmarshal(marshalled, &unmarshalled)
+	assert.NoError(t, err, "unmarshals without problems")
+	assert.EqualValues(t, *v, unmarshalled, "round-trip is lossless")
+
+	vcopy := unmarshalled.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "launcher.bat", vcopy.Candidates[0].Path, "batch won")
+	assert.NotContains(t, string(marshalled), "absolutePath", "leaves out absolute paths by default")
+}
+
+func Test_ConfigureRetainContainer(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.Nil(t, v.Container, "doesn't retain container by default")
+
+	params := configureParams(t)
+	params.RetainContainer = true
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	if assert.NotNil(t, v.Container, "retains container") {
+		var totalSize int64
+		var paths []string
+		for _, f := range v.Container.Files {
+			totalSize += f.Size
+			paths = append(paths, f.Path)
+		}
+		assert.EqualValues(t, v.TotalSize, totalSize, "lists every file")
+		assert.Contains(t, paths, "game.exe", "lists candidates")
+	}
+
+	marshalled, err := json.Marshal(v)
+	assert.NoError(t, err, "marshals without problems")
+	assert.NotContains(t, string(marshalled), "container", "doesn't serialize container")
+}
+
+func Test_MergeVerdicts(t *testing.T) {
+	base := filepath.Join("games", "space-game")
+	game := &dash.Verdict{
+		BasePath:  base,
+		TotalSize: 3000,
+		Candidates: []*dash.Candidate{
+			{Path: "game.exe", Depth: 1, Flavor: dash.FlavorNativeWindows, Size: 2000, WindowsInfo: &dash.WindowsInfo{Gui: true}},
+			{Path: "launch.bat", Depth: 1, Flavor: dash.FlavorScriptWindows, Size: 100},
+		},
+	}
+	dlc := &dash.Verdict{
+		BasePath:  base + string(filepath.Separator),
+		TotalSize: 1000,
+		Candidates: []*dash.Candidate{
+			{Path: "launch.bat", Depth: 1, Flavor: dash.FlavorScriptWindows, Size: 200},
+			{Path: "dlc/editor.exe", Depth: 2, Flavor: dash.FlavorNativeWindows, Size: 500},
+		},
+	}
+
+	merged, err := dash.MergeVerdicts(base, game, dlc)
+	assert.NoError(t, err, "merges without problems")
+	assert.EqualValues(t, base, merged.BasePath, "keeps base path")
+	assert.EqualValues(t, 4000, merged.TotalSize, "adds up total sizes")
+	if assert.EqualValues(t, 3, len(merged.Candidates), "dedupes candidates by path") {
+		assert.EqualValues(t, "game.exe", merged.Candidates[0].Path, "keeps candidates in order")
+		assert.EqualValues(t, "launch.bat", merged.Candidates[1].Path, "keeps candidates in order")
+		assert.EqualValues(t, 100, merged.Candidates[1].Size, "first verdict wins for duplicates")
+		assert.EqualValues(t, "dlc/editor.exe", merged.Candidates[2].Path, "keeps candidates in order")
+		assert.False(t, merged.Candidates[0] == game.Candidates[0], "copies candidates")
+	}
+
+	vcopy := merged.Filter(makeConsumer(t), dash.FilterParams{OS: "linux"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "merged verdict can be filtered") {
+		assert.EqualValues(t, "launch.bat", vcopy.Candidates[0].Path, "merged verdict can be filtered")
+	}
+
+	_, err = dash.MergeVerdicts(base, game, &dash.Verdict{BasePath: filepath.Join("games", "other-game")})
+	if assert.Error(t, err, "refuses verdicts for other folders") {
+		assert.Contains(t, err.Error(), "other-game", "says which base path is wrong")
+	}
+
+	empty, err := dash.MergeVerdicts(base, &dash.Verdict{BasePath: base, Diagnosis: &dash.Diagnosis{Kind: dash.DiagnosisEmpty}})
+	assert.NoError(t, err, "merges without problems")
+	assert.EqualValues(t, 0, len(empty.Candidates), "has no candidates")
+	if assert.NotNil(t, empty.Diagnosis, "keeps diagnosis when there are no candidates") {
+		assert.EqualValues(t, dash.DiagnosisEmpty, empty.Diagnosis.Kind, "keeps diagnosis when there are no candidates")
+	}
+
+	root := filepath.Join("testdata", "windows")
+	v1, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	v2, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	configured, err := dash.MergeVerdicts(root, v1, v2)
+	if assert.NoError(t, err, "merges verdicts returned by Configure") {
+		assert.EqualValues(t, root, configured.BasePath, "keeps base path")
+		assert.EqualValues(t, len(v1.Candidates), len(configured.Candidates), "dedupes candidates by path")
+		assert.EqualValues(t, v1.TotalSize+v2.TotalSize, configured.TotalSize, "adds up total sizes")
+	}
+
+	absRoot, err := filepath.Abs(root)
+	assert.NoError(t, err, "gets absolute path")
+	_, err = dash.MergeVerdicts(absRoot, v1, v2)
+	assert.NoError(t, err, "accepts an absolute base path for relative verdicts")
+}
+
+func Test_ConfigureFromPool(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	dir, err := ioutil.TempDir("", "dash-pool")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(dir)
+
+	zipPath := filepath.Join(dir, "game.zip")
+	zf, err := os.Create(zipPath)
+	assert.NoError(t, err, "creates zip")
+	zw := zip.NewWriter(zf)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(contents)
+		return err
+	})
+	assert.NoError(t, err, "writes zip entries")
+	assert.NoError(t, zw.Close(), "closes zip writer")
+	assert.NoError(t, zf.Close(), "closes zip")
+
+	container, err := tlc.WalkAny(zipPath, tlc.WalkOpts{})
+	assert.NoError(t, err, "walks zip")
+	pool, err := pools.New(container, zipPath)
+	assert.NoError(t, err, "opens zip pool")
+	defer pool.Close()
+
+	params := configureParams(t)
+	params.Concurrency = 4
+	vzip, err := dash.ConfigureFromPool(container, pool, params)
+	assert.NoError(t, err, "configures without problems")
+	assert.EqualValues(t, "", vzip.BasePath, "leaves base path empty")
+	assert.EqualValues(t, v.TotalSize, vzip.TotalSize, "counts all files")
+
+	summarize := func(v *dash.Verdict) map[string]dash.Flavor {
+		flavors := make(map[string]dash.Flavor)
+		for _, c := range v.Candidates {
+			flavors[c.Path] = c.Flavor
+		}
+		return flavors
+	}
+	assert.EqualValues(t, summarize(v), summarize(vzip), "finds the same candidates as with a folder")
+}
+
+func Test_ConfigureProgress(t *testing.T) {
+	root := filepath.Join("testdata", "windows-il2cpp")
+
+	for _, concurrency := range []int{1, 4} {
+		type call struct{ done, total int }
+		var calls []call
+
+		params := configureParams(t)
+		params.Concurrency = concurrency
+		params.RetainContainer = true
+		params.OnProgress = func(done, total int) {
+			calls = append(calls, call{done, total})
+		}
+
+		v, err := dash.Configure(root, params)
+		assert.NoError(t, err, "walks without problems")
+
+		total := len(v.Container.Files)
+		if assert.NotEmpty(t, calls, "reports progress (concurrency %d)", concurrency) {
+			assert.EqualValues(t, call{total, total}, calls[len(calls)-1], "reports all files done last (concurrency %d)", concurrency)
+		}
+		for i := 1; i < len(calls); i++ {
+			assert.True(t, calls[i].done >= calls[i-1].done, "progress never goes down (concurrency %d)", concurrency)
+		}
+	}
+}
+
+func Test_ConfigureStrictHTML(t *testing.T) {
+	cases := []struct {
+		name   string
+		files  map[string]string
+		strict bool
+		found  bool
+	}{
+		{"readme", map[string]string{"readme.html": "<h1>Read me</h1>", "LICENSE.txt": "MIT"}, false, true},
+		{"strict-readme", map[string]string{"readme.html": "<h1>Read me</h1>", "LICENSE.txt": "MIT"}, true, false},
+		{"strict-script", map[string]string{"play.html": "<canvas></canvas>", "game.js": "run()"}, true, true},
+		{"strict-manifest", map[string]string{"play.html": "<canvas></canvas>", "data/manifest.json": "{}"}, true, true},
+		{"strict-assets", map[string]string{"play.html": "<canvas></canvas>", "hero.png": "png", "theme.ogg": "ogg"}, true, true},
+		{"strict-single-file", map[string]string{"play.html": "<canvas></canvas>"}, true, true},
+	}
+
+	for _, tc := range cases {
+		root, err := ioutil.TempDir("", "dash-strict-html")
+		assert.NoError(t, err, "creates temp dir")
+		for name, contents := range tc.files {
+			p := filepath.Join(root, filepath.FromSlash(name))
+			assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder")
+			assert.NoError(t, ioutil.WriteFile(p, []byte(contents), 0644), "writes test file")
+		}
+
+		params := configureParams(t)
+		params.StrictHTML = tc.strict
+		v, err := dash.Configure(root, params)
+		assert.NoError(t, err, "walks without problems (%s)", tc.name)
+		if tc.found {
+			if assert.EqualValues(t, 1, len(v.Candidates), "falls back on HTML (%s)", tc.name) {
+				assert.EqualValues(t, dash.FlavorHTML, v.Candidates[0].Flavor, "falls back on HTML (%s)", tc.name)
+			}
+		} else {
+			assert.EqualValues(t, 0, len(v.Candidates), "doesn't fall back on HTML (%s)", tc.name)
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func Test_ConfigureHTMLFallbackDepth(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-html-depth")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"MyGame/game.html", "MyGame/docs/manual.html", "MyGame/LICENSE.txt"} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder")
+		assert.NoError(t, ioutil.WriteFile(p, []byte("<canvas></canvas>"), 0644), "writes test file")
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 0, len(v.Candidates), "only looks at top-level HTML files by default")
+
+	params := configureParams(t)
+	params.HTMLFallbackMaxDepth = 3
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "falls back on nested HTML files") {
+		assert.EqualValues(t, "MyGame/game.html", v.Candidates[0].Path, "picks the shallowest HTML file")
+		assert.EqualValues(t, dash.FlavorHTML, v.Candidates[0].Flavor, "picks the shallowest HTML file")
+		assert.EqualValues(t, 2, v.Candidates[0].Depth, "sets depth")
+	}
+}
+
+func Test_ConfigureSplitArchive(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, err := zw.Create("Game/game.exe")
+	assert.NoError(t, err, "creates zip entry")
+	_, err = w.Write([]byte("MZ"))
+	assert.NoError(t, err, "writes zip entry")
+	assert.NoError(t, zw.Close(), "closes zip")
+	zipData := zipBuf.Bytes()
+
+	sevenZip := append([]byte("7z\xBC\xAF\x27\x1C"), make([]byte, 64)...)
+	spannedZip := append([]byte("PK\x07\x08"), zipData...)
+	// main header of a RAR 4 volume: CRC, type, flags (volume), size
+	rar4Volume := append([]byte("Rar!\x1a\x07\x00\x00\x00\x73\x01\x00\x0d\x00"), make([]byte, 64)...)
+	// main header of a RAR 5 volume: CRC, size, type, flags, archive flags (volume)
+	rar5Volume := append([]byte("Rar!\x1a\x07\x01\x00\x00\x00\x00\x00\x03\x01\x00\x01"), make([]byte, 64)...)
+	junk := []byte("just some level data, nothing to see here")
+
+	cases := []struct {
+		name       string
+		files      map[string][]byte
+		candidates int
+	}{
+		{"zip.001", map[string][]byte{"game.zip.001": zipData[:len(zipData)/2], "game.zip.002": zipData[len(zipData)/2:]}, 0},
+		{"z01", map[string][]byte{"game.z01": junk, "game.zip": zipData}, 0},
+		{"z01-alone", map[string][]byte{"game.z01": spannedZip}, 0},
+		{"7z.001", map[string][]byte{"game.7z.001": sevenZip, "game.7z.002": junk}, 1},
+		{"part1.rar", map[string][]byte{"game.part1.rar": rar5Volume, "game.part2.rar": rar5Volume}, 2},
+		{"rar-volume", map[string][]byte{"game.rar": rar4Volume}, 1},
+		{"numbered", map[string][]byte{"game.001": sevenZip, "game.002": junk}, 1},
+	}
+
+	for _, tc := range cases {
+		root, err := ioutil.TempDir("", "dash-split-archive")
+		assert.NoError(t, err, "creates temp dir")
+		defer os.RemoveAll(root)
+
+		for name, contents := range tc.files {
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+		}
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems (%s)", tc.name)
+		assert.EqualValues(t, tc.candidates, len(v.Candidates), "finds parts of 7-Zip and RAR archives (%s)", tc.name)
+		for _, c := range v.Candidates {
+			if assert.NotNil(t, c.ArchiveInfo, "has archive info (%s)", c.Path) {
+				assert.True(t, c.ArchiveInfo.Split, "is flagged as split (%s)", c.Path)
+			}
+		}
+		if assert.NotNil(t, v.Diagnosis, "explains why (%s)", tc.name) {
+			assert.EqualValues(t, dash.DiagnosisSplitArchive, v.Diagnosis.Kind, "blames split archives (%s)", tc.name)
+		}
+	}
+
+	root, err := ioutil.TempDir("", "dash-split-archive")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	// names that end in digits, but aren't archives
+	for _, name := range []string{"level.001", "level.002", "game.z64", "track.r01", "music.rar"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), junk, 0644), "writes %s", name)
+	}
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.NotNil(t, v.Diagnosis, "explains why") {
+		assert.NotEqual(t, dash.DiagnosisSplitArchive, v.Diagnosis.Kind, "doesn't blame split archives")
+	}
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game.7z"), sevenZip, 0644), "writes game.7z")
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "finds archive") {
+		assert.False(t, v.Candidates[0].ArchiveInfo.Split, "whole archives aren't split")
+	}
+	assert.Nil(t, v.Diagnosis, "no diagnosis for whole archives")
+}
+
+func Test_ConfigureDiagnosis(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, err := zw.Create("Game/game.exe")
+	assert.NoError(t, err, "creates zip entry")
+	_, err = w.Write([]byte("MZ"))
+	assert.NoError(t, err, "writes zip entry")
+	assert.NoError(t, zw.Close(), "closes zip")
+
+	cdSector := append([]byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}, make([]byte, 2340)...)
+	cueSheet := []byte("FILE \"game.bin\" BINARY\n  TRACK 01 MODE2/2352\n    INDEX 01 00:00:00\n")
+	gbaROM := make([]byte, 0xC0)
+	gbaROM[0xB2] = 0x96
+
+	cases := []struct {
+		name  string
+		files map[string][]byte
+		kind  dash.DiagnosisKind
+	}{
+		{"empty", nil, dash.DiagnosisEmpty},
+		{"assets", map[string][]byte{"player.png": []byte("not really a png")}, dash.DiagnosisBlacklisted},
+		{"zips", map[string][]byte{"game.zip": zipBuf.Bytes(), "cover.png": []byte("not really a png")}, dash.DiagnosisOnlyArchives},
+		{"unrecognized", map[string][]byte{"notes": []byte("just some notes")}, dash.DiagnosisUnrecognized},
+		{"disc", map[string][]byte{"game.bin": cdSector, "game.cue": cueSheet}, dash.DiagnosisConsoleImage},
+		{"rom", map[string][]byte{"game.gba": gbaROM}, dash.DiagnosisConsoleImage},
+		{"fake-rom", map[string][]byte{"music.iso": []byte("not really a disc")}, dash.DiagnosisBlacklisted},
+	}
+
+	for _, tc := range cases {
+		root, err := ioutil.TempDir("", "dash-diagnosis")
+		assert.NoError(t, err, "creates temp dir")
+		defer os.RemoveAll(root)
+
+		for name, contents := range tc.files {
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+		}
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems (%s)", tc.name)
+		assert.EqualValues(t, 0, len(v.Candidates), "finds no candidates (%s)", tc.name)
+		if assert.NotNil(t, v.Diagnosis, "explains why (%s)", tc.name) {
+			assert.EqualValues(t, tc.kind, v.Diagnosis.Kind, "finds the right reason (%s)", tc.name)
+			assert.NotEmpty(t, v.Diagnosis.Message, "has a message (%s)", tc.name)
+		}
+	}
+
+	root := filepath.Join("testdata", "windows")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.Nil(t, v.Diagnosis, "no diagnosis when there are candidates")
+
+	params := configureParams(t)
+	params.ExcludeGlobs = []string{"*"}
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	if assert.NotNil(t, v.Diagnosis, "explains why") {
+		assert.EqualValues(t, dash.DiagnosisExcluded, v.Diagnosis.Kind, "blames exclude globs")
+	}
+}
+
+func Test_ConfigureMobile(t *testing.T) {
+	makeZip := func(entries map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, contents := range entries {
+			w, err := zw.Create(name)
+			assert.NoError(t, err, "creates zip entry")
+			_, err = w.Write([]byte(contents))
+			assert.NoError(t, err, "writes zip entry")
+		}
+		assert.NoError(t, zw.Close(), "closes zip")
+		return buf.Bytes()
+	}
+
+	root, err := ioutil.TempDir("", "dash-mobile")
+	assert.NoError(t, err, "creates temp dir")
+	defer os.RemoveAll(root)
+
+	files := map[string][]byte{
+		// android packages sometimes have a jar manifest with a main class
+		"game.apk": makeZip(map[string]string{
+			"AndroidManifest.xml":  "binary xml",
+			"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nMain-Class: com.example.Game\n",
+		}),
+		// recognized by their contents, whatever their name
+		"game-ios.zip": makeZip(map[string]string{
+			"Payload/Game.app/Game": "not really mach-o",
+		}),
+		"manual.zip": makeZip(map[string]string{
+			"mimetype":          "application/epub+zip",
+			"OEBPS/content.opf": "<package/>",
+		}),
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	formats := make(map[string]dash.MobileFormat)
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorMobile, c.Flavor, "isn't mistaken for a jar or archive (%s)", c.Path)
+		if assert.NotNil(t, c.MobileInfo, "has mobile info (%s)", c.Path) {
+			formats[c.Path] = c.MobileInfo.Format
+		}
+	}
+	assert.EqualValues(t, map[string]dash.MobileFormat{
+		"game.apk":     dash.MobileFormatAPK,
+		"game-ios.zip": dash.MobileFormatIPA,
+		"manual.zip":   dash.MobileFormatEPUB,
+	}, formats, "finds all mobile packages")
+
+	if assert.NotNil(t, v.Diagnosis, "explains there's nothing to run on desktop") {
+		assert.EqualValues(t, dash.DiagnosisMobileBuild, v.Diagnosis.Kind, "blames a mobile build")
+	}
+
+	for _, targetOS := range []string{"windows", "linux", "darwin"} {
+		vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: targetOS, Arch: "amd64"})
+		assert.EqualValues(t, 0, len(vcopy.Candidates), "mobile packages never run on desktop (%s)", targetOS)
+	}
+
+	// a jar next to them is still a desktop build
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game.jar"), makeZip(map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nMain-Class: com.example.Game\n",
+	}), 0644), "writes jar")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.Nil(t, v.Diagnosis, "no diagnosis when there's a desktop candidate")
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only the jar is left") {
+		assert.EqualValues(t, "game.jar", vcopy.Candidates[0].Path, "jar wins")
+	}
+}
+
+func Test_VerdictAbsolutePaths(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+	absRoot, err := filepath.Abs(root)
+	assert.NoError(t, err, "gets absolute path")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, filepath.Join(absRoot, filepath.FromSlash(c.Path)), v.FullPath(c), "joins base path and candidate path")
+	}
+
+	marshalled, err := json.Marshal(v.WithAbsolutePaths())
+	assert.NoError(t, err, "marshals without problems")
+
+	var intermediate struct {
+		BasePath   string `json:"basePath"`
+		Candidates []struct {
+			Path         string `json:"path"`
+			Flavor       string `json:"flavor"`
+			AbsolutePath string `json:"absolutePath"`
+		} `json:"candidates"`
+	}
+	err = json.Unmarshal(marshalled, &intermediate)
+	assert.NoError(t, err, "unmarshals without problems")
+	assert.EqualValues(t, root, intermediate.BasePath, "keeps base path as given")
+	if assert.EqualValues(t, len(v.Candidates), len(intermediate.Candidates), "includes all candidates") {
+		for i, c := range v.Candidates {
+			assert.EqualValues(t, c.Path, intermediate.Candidates[i].Path, "keeps relative path")
+			assert.EqualValues(t, c.Flavor, intermediate.Candidates[i].Flavor, "keeps candidate info")
+			assert.EqualValues(t, v.FullPath(c), intermediate.Candidates[i].AbsolutePath, "includes absolute path")
+		}
+	}
+
+	var unmarshalled dash.Verdict
+	err = json.Unmarshal(marshalled, &unmarshalled)
+	assert.NoError(t, err, "unmarshals without problems")
+	assert.EqualValues(t, *v, unmarshalled, "still reads as a verdict")
+}
+
+func Test_VerdictPublic(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	params := configureParams(t)
+	params.CollectSpells = true
+	v, err := dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+
+	pv := v.Public()
+	assert.EqualValues(t, v.BasePath, pv.BasePath, "keeps base path")
+	assert.EqualValues(t, v.TotalSize, pv.TotalSize, "keeps total size")
+	if assert.EqualValues(t, len(v.Candidates), len(pv.Candidates), "keeps all candidates") {
+		for i, c := range v.Candidates {
+			pc := pv.Candidates[i]
+			assert.EqualValues(t, c.Path, pc.Path, "keeps path")
+			if c.Flavor == dash.FlavorNativeWindows {
+				assert.NotEmpty(t, c.Spell, "has spell to leave out (%s)", c.Path)
+			}
+			assert.EqualValues(t, c.Flavor, pc.Flavor, "keeps flavor (%s)", c.Path)
+			assert.EqualValues(t, c.Size, pc.Size, "keeps size (%s)", c.Path)
+			assert.EqualValues(t, c.WindowsInfo, pc.WindowsInfo, "keeps windows info (%s)", c.Path)
+			if c.WindowsInfo != nil {
+				assert.False(t, c.WindowsInfo == pc.WindowsInfo, "copies windows info (%s)", c.Path)
+			}
+		}
+	}
+
+	marshalled, err := json.Marshal(pv)
+	assert.NoError(t, err, "marshals without problems")
+
+	var raw struct {
+		Candidates []map[string]interface{} `json:"candidates"`
+	}
+	assert.NoError(t, json.Unmarshal(marshalled, &raw), "unmarshals without problems")
+	for _, c := range raw.Candidates {
+		assert.NotContains(t, c, "mode", "doesn't leak mode (%s)", c["path"])
+		assert.NotContains(t, c, "spell", "doesn't leak spell (%s)", c["path"])
+		assert.Contains(t, c, "flavor", "has flavor (%s)", c["path"])
+	}
+}
+
+func Test_ConfigureConcurrency(t *testing.T) {
+	for _, dir := range []string{"windows", "linux", "darwin", "linux-nodewebkit"} {
+		root := filepath.Join("testdata", dir)
+
+		serialStats := &dash.VerdictStats{}
+		serialParams := configureParams(t)
+		serialParams.Stats = serialStats
+		serial, err := dash.Configure(root, serialParams)
+		assert.NoError(t, err, "walks without problems")
+
+		parallelStats := &dash.VerdictStats{}
+		parallelParams := configureParams(t)
+		parallelParams.Stats = parallelStats
+		parallelParams.Concurrency = 4
+		parallel, err := dash.Configure(root, parallelParams)
+		assert.NoError(t, err, "walks without problems")
+
+		assert.EqualValues(t, serial, parallel, "same verdict for (%s)", dir)
+		assert.EqualValues(t, serialStats, parallelStats, "same stats for (%s)", dir)
+	}
+}
+
+func Test_ConfigureCollectSkips(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-skips")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "data"), 0755), "creates data folder")
+
+	files := map[string][]byte{
+		"game":           elf,
+		"readme.txt":     []byte("have fun"),
+		"data/music.ogg": []byte("OggS"),
+		"data/level.map": []byte("not much of a level"),
+		"data/scores":    []byte("AAA 9999"),
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, filepath.FromSlash(name)), contents, 0644), "writes %s", name)
+	}
+
+	stats := &dash.VerdictStats{}
+	params := configureParams(t)
+	params.Stats = stats
+	v, err := dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 1, len(v.Candidates), "finds the executable")
+	assert.Nil(t, stats.BlacklistedPaths, "doesn't collect blacklisted paths by default")
+	assert.Nil(t, stats.UnrecognizedPaths, "doesn't collect unrecognized paths by default")
+
+	params.CollectSkips = true
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 1, len(v.Candidates), "still finds the executable")
+	assert.ElementsMatch(t, []string{"readme.txt", "data/music.ogg", "data/level.map"}, stats.BlacklistedPaths, "lists blacklisted paths")
+	assert.ElementsMatch(t, []string{"data/scores"}, stats.UnrecognizedPaths, "lists unrecognized paths")
+}
+
+type cancellingDetector struct {
+	cancel  context.CancelFunc
+	numSeen int
+}
+
+func (cd *cancellingDetector) DetectCandidate(pool lake.Pool, fileIndex int64, f *tlc.File) (dash.DetectResult, error) {
+	cd.numSeen++
+	cd.cancel()
+	return dash.DetectResult{}, nil
+}
+
+type countingSniffCache struct {
+	dash.SniffCache
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+func (csc *countingSniffCache) Get(key string) (*dash.Candidate, bool) {
+	c, ok := csc.SniffCache.Get(key)
+	csc.mu.Lock()
+	defer csc.mu.Unlock()
+	if ok {
+		csc.hits++
+	} else {
+		csc.misses++
+	}
+	return c, ok
+}
+
+func Test_ConfigureSniffCache(t *testing.T) {
+	root := filepath.Join("testdata", "java-bundled")
+
+	cache := &countingSniffCache{SniffCache: dash.NewSniffCache()}
+	params := configureParams(t)
+	params.Cache = cache
+
+	uncached, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	first, err := dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 0, cache.hits, "starts with an empty cache")
+	assert.True(t, cache.misses > 0, "sniffs files on first walk")
+	misses := cache.misses
+
+	params.Concurrency = 4
+	second, err := dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, misses, cache.hits, "skips sniffing unchanged files")
+	assert.EqualValues(t, misses, cache.misses, "skips sniffing unchanged files")
+
+	assert.EqualValues(t, uncached.Candidates, first.Candidates, "finds the same candidates")
+	assert.EqualValues(t, uncached.Candidates, second.Candidates, "cached candidates aren't affected by later detection")
+
+	params.CollectSpells = true
+	_, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2*misses, cache.misses, "doesn't share entries between spell settings")
+}
+
+func Test_ConfigureCancel(t *testing.T) {
+	root := filepath.Join("testdata", "windows")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	detector := &cancellingDetector{cancel: cancel}
+
+	params := configureParams(t)
+	params.Context = ctx
+	params.CandidateDetector = detector
+
+	v, err := dash.Configure(root, params)
+	assert.Error(t, err, "configure is cancelled")
+	assert.Nil(t, v, "no verdict when cancelled")
+	assert.EqualValues(t, context.Canceled, errors.Cause(err), "returns the cancellation error")
+	assert.EqualValues(t, 1, detector.numSeen, "stops after the first file")
+}
+
+func Test_ConfigureLinuxArm64(t *testing.T) {
+	root := filepath.Join("testdata", "linux-arm64")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	varm64 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "arm64"})
+
+	assert.EqualValues(t, 1, len(varm64.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.aarch64", varm64.Candidates[0].Path, "arm64 binary wins")
+	assert.EqualValues(t, dash.ArchArm64, varm64.Candidates[0].Arch, "arch is detected")
+
+	v64 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+
+	assert.EqualValues(t, 1, len(v64.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.x86_64", v64.Candidates[0].Path, "amd64 binary wins")
+}
+
+func Test_ConfigureDarwinPkg(t *testing.T) {
+	{
+		root := filepath.Join("testdata", "darwin-pkg", "flat")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 1, len(v.Candidates), "ignores xar archives that aren't packages")
+		c := v.Candidates[0]
+		assert.EqualValues(t, "Game Installer.pkg", c.Path, "finds installer package")
+		assert.EqualValues(t, dash.FlavorPkgMacos, c.Flavor, "finds installer package")
+		assert.True(t, c.MacosInfo.Installer, "marks package as installer")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "surfaces lone installer package")
+
+		vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+		assert.EqualValues(t, 0, len(vcopy.Candidates), "installer package is macOS-only")
+	}
+
+	{
+		root := filepath.Join("testdata", "darwin-pkg", "bundle")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+		c := vcopy.Candidates[0]
+		assert.EqualValues(t, "Legacy.pkg", c.Path, "finds bundle-style installer package")
+		assert.EqualValues(t, dash.FlavorPkgMacos, c.Flavor, "finds bundle-style installer package")
+		assert.True(t, c.MacosInfo.Installer, "marks package as installer")
+	}
+
+	{
+		root := filepath.Join("testdata", "darwin-pkg", "mixed")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+		assert.EqualValues(t, "Game.app", vcopy.Candidates[0].Path, "app bundle wins over installer package")
+	}
+}
+
+func Test_ConfigureDarwinDmg(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-dmg")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "finds all candidates on first walk") {
+		c := v.Candidates[0]
+		assert.EqualValues(t, dash.FlavorDmgMacos, c.Flavor, "detects disk image")
+		assert.EqualValues(t, dash.ArchiveFormatDmg, c.ArchiveInfo.Format, "flags disk image for mounting")
+	}
+
+	dmg, err := ioutil.ReadFile(filepath.Join(root, "Game.dmg"))
+	assert.NoError(t, err, "reads test file")
+	c, err := dash.SniffBytes(dmg, "Game.IMG")
+	assert.NoError(t, err, "sniffs without problems")
+	if assert.NotNil(t, c, "detects older disk image names") {
+		assert.EqualValues(t, dash.FlavorDmgMacos, c.Flavor, "detects older disk image names")
+	}
+	c, err = dash.SniffBytes(dmg, "data.bin")
+	assert.NoError(t, err, "sniffs without problems")
+	assert.Nil(t, c, "only checks the trailer of files named like disk images")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "keeps disk image on macOS")
+
+	for _, osFilter := range []string{"windows", "linux"} {
+		vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: osFilter, Arch: "amd64"})
+		assert.EqualValues(t, 0, len(vcopy.Candidates), "excludes disk image on %s", osFilter)
+	}
+}
+
+func Test_ConfigureClickTeam(t *testing.T) {
+	root := filepath.Join("testdata", "clickteam")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	flavors := make(map[string]dash.Flavor)
+	for _, c := range v.Candidates {
+		flavors[c.Path] = c.Flavor
+	}
+	assert.EqualValues(t, dash.FlavorClickTeam, flavors["Game.exe"], "detects runtime data")
+	assert.EqualValues(t, dash.FlavorNativeWindows, flavors["Game Patch.exe"], "leaves plain executables alone")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "runtime wins over the bigger patcher")
+		assert.NotNil(t, vcopy.Candidates[0].WindowsInfo, "keeps windows info")
+	}
+
+	tools := dash.Verdict{BasePath: v.BasePath}
+	for _, c := range v.Candidates {
+		if c.Flavor != dash.FlavorClickTeam {
+			tools.Candidates = append(tools.Candidates, c)
+		}
+	}
+	vcopy = tools.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game Patch.exe", vcopy.Candidates[0].Path, "excludes the patch maker")
+		assert.EqualValues(t, 50, vcopy.ScoredCandidates()[0].Score, "penalizes the patcher")
+	}
+}
+
+func Test_ConfigureUnity(t *testing.T) {
+	root := filepath.Join("testdata", "unity-linux")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		c := vcopy.Candidates[0]
+		assert.EqualValues(t, "Game.x86_64", c.Path, "player wins over the bigger tool")
+		assert.EqualValues(t, dash.FlavorUnity, c.Flavor, "detects Unity player")
+		assert.EqualValues(t, "Game_Data", c.UnityInfo.DataPath, "finds data folder")
+		assert.EqualValues(t, dash.ArchAmd64, c.Arch, "keeps native info")
+	}
+
+	root = filepath.Join("testdata", "unity-macos")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds app bundle and player")
+	for _, c := range v.Candidates {
+		if c.Path == "Game.app/Contents/MacOS/Game" {
+			assert.EqualValues(t, dash.FlavorUnity, c.Flavor, "detects Unity player in app bundle")
+			assert.EqualValues(t, "Game.app/Contents/Resources/Data", c.UnityInfo.DataPath, "finds data folder in app bundle")
+		}
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game.app", vcopy.Candidates[0].Path, "app bundle still wins")
+	}
+}
+
+func Test_ConfigureAIR(t *testing.T) {
+	root := filepath.Join("testdata", "air")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "Game.exe":
+			assert.EqualValues(t, dash.FlavorAIR, c.Flavor, "detects AIR launcher")
+			assert.EqualValues(t, "META-INF/AIR/application.xml", c.AIRInfo.DescriptorPath, "finds app descriptor")
+		case "adl.exe":
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "leaves debug launcher alone")
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "launcher wins over the bigger debug launcher")
+	}
+}
+
+func Test_ConfigureDarwinCommand(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-command")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk, but not data files")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		c := vcopy.Candidates[0]
+		assert.EqualValues(t, "Play.command", c.Path, "command file wins over app bundle")
+		assert.EqualValues(t, dash.FlavorScriptMacos, c.Flavor, "detects command file without a shebang")
+		assert.EqualValues(t, dash.ScriptKindShell, c.ScriptInfo.Kind, "command files are shell scripts")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 0, len(vcopy.Candidates), "command files only run on macOS")
+
+	fixed, err := dash.FixPermissions(v, fixParams(t))
+	assert.NoError(t, err, "fixes permissions without problems")
+	assert.Contains(t, fixed, "Play.command", "makes command file executable")
+
+	c, err := dash.SniffBytes([]byte("#!/bin/bash\nexec ./Game\n"), "Start Game.command")
+	assert.NoError(t, err, "sniffs without problems")
+	if assert.NotNil(t, c, "detects command file with a shebang") {
+		assert.EqualValues(t, dash.FlavorScriptMacos, c.Flavor, "detects command file with a shebang")
+		assert.EqualValues(t, "/bin/bash", c.ScriptInfo.Interpreter, "reads interpreter")
+	}
+}
+
+func Test_ConfigureDarwinUniversal(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-universal")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	varm64 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "arm64"})
+
+	assert.EqualValues(t, 1, len(varm64.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game-universal", varm64.Candidates[0].Path, "universal binary wins")
+	assert.EqualValues(t, []dash.Arch{dash.ArchAmd64, dash.ArchArm64}, varm64.Candidates[0].MacosInfo.Archs, "lists all slices")
+
+	v64 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+
+	assert.EqualValues(t, 2, len(v64.Candidates), "both candidates run on intel")
+}
+
+func Test_ConfigureExtraPenalties(t *testing.T) {
+	root := filepath.Join("testdata", "bigger-is-better")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{
+		OS:   "windows",
+		Arch: "amd64",
+		ExtraPenalties: []dash.BlacklistEntry{
+			dash.NewBlacklistEntry(regexp.MustCompile(`(?i)tiled\.exe$`), dash.ExcludePenalty()),
+			dash.NewBlacklistEntry(regexp.MustCompile(`(?i)automapping`), dash.ScorePenalty(10)),
+		},
+	})
+
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "two candidates left after filtering")
+	assert.EqualValues(t, "tmxviewer.exe", vcopy.Candidates[0].Path, "unpenalized candidate wins")
+}
+
+func Test_VerdictBestCandidate(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-html")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.True(t, v.HasCandidates(), "has candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+	best, ok := vcopy.BestCandidate()
+	assert.True(t, ok, "finds best candidate")
+	assert.EqualValues(t, "game", best.Path, "returns top-ranked candidate")
+
+	v, err = dash.Configure(filepath.Join("testdata", "darwin-dmg"), configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.False(t, vcopy.HasCandidates(), "no candidates left after filtering")
+	best, ok = vcopy.BestCandidate()
+	assert.False(t, ok, "no best candidate")
+	assert.Nil(t, best, "no best candidate")
+
+	best, ok = dash.Verdict{}.BestCandidate()
+	assert.False(t, ok, "empty verdicts have no best candidate")
+	assert.Nil(t, best, "empty verdicts have no best candidate")
+}
+
+func Test_FilterFlavorWeights(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-html")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game", vcopy.Candidates[0].Path, "native wins by default")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64", FlavorWeights: map[dash.Flavor]int64{}})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "empty weights don't change anything")
+	assert.EqualValues(t, "game", vcopy.Candidates[0].Path, "empty weights don't change anything")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{
+		OS:   "darwin",
+		Arch: "amd64",
+		FlavorWeights: map[dash.Flavor]int64{
+			dash.FlavorHTML: 10,
+		},
+	})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "weighted HTML is scored")
+	assert.EqualValues(t, "index.html", vcopy.Candidates[0].Path, "weighted HTML wins")
+	assert.EqualValues(t, 110, vcopy.ScoredCandidates()[0].Score, "weight is added to base score")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{
+		OS:   "darwin",
+		Arch: "amd64",
+		FlavorWeights: map[dash.Flavor]int64{
+			dash.FlavorHTML:        10,
+			dash.FlavorNativeMacos: 20,
+		},
+	})
+	assert.EqualValues(t, "game", vcopy.Candidates[0].Path, "heavier flavor wins")
+}
+
+func Test_FilterPreferFlavors(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-html")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64", PreferFlavors: []dash.Flavor{dash.FlavorHTML}})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "index.html", vcopy.Candidates[0].Path, "preferred HTML wins over native")
+	}
+
+	v = &dash.Verdict{
+		BasePath: root,
+		Candidates: []*dash.Candidate{
+			{Path: "game.love", Depth: 1, Flavor: dash.FlavorLove, Size: 1000},
+			{Path: "game.x86_64", Depth: 1, Flavor: dash.FlavorNativeLinux, Arch: dash.ArchAmd64, Size: 2000, LinuxInfo: &dash.LinuxInfo{}},
+			{Path: "game.jar", Depth: 1, Flavor: dash.FlavorJar, Size: 3000, JarInfo: &dash.JarInfo{Runnable: true}},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		prefer []dash.Flavor
+		paths  []string
+	}{
+		{"default", nil, []string{"game.love"}},
+		{"native first", []dash.Flavor{dash.FlavorNativeLinux, dash.FlavorLove}, []string{"game.x86_64"}},
+		{"missing flavors are skipped", []dash.Flavor{dash.FlavorHTML, dash.FlavorJar, dash.FlavorLove}, []string{"game.jar"}},
+		// nothing is preferred, but the linux arch rule still applies
+		{"none present", []dash.Flavor{dash.FlavorHTML}, []string{"game.x86_64"}},
+	}
+	for _, tc := range cases {
+		vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", PreferFlavors: tc.prefer})
+		var paths []string
+		for _, c := range vcopy.Candidates {
+			paths = append(paths, c.Path)
+		}
+		assert.EqualValues(t, tc.paths, paths, "picks the right candidates (%s)", tc.name)
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{PreferFlavors: []dash.Flavor{}})
+	assert.EqualValues(t, 3, len(vcopy.Candidates), "without preferences, scoring decides between all flavors")
+}
+
+func Test_FilterRationale(t *testing.T) {
+	root := filepath.Join("testdata", "bigger-is-better")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.Nil(t, v.ScoredCandidates(), "no rationale before filtering")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{
+		OS:   "windows",
+		Arch: "amd64",
+		ExtraPenalties: []dash.BlacklistEntry{
+			dash.NewBlacklistEntry(regexp.MustCompile(`(?i)tiled\.exe$`), dash.ExcludePenalty()),
+			dash.NewBlacklistEntry(regexp.MustCompile(`(?i)automapping`), dash.ScorePenalty(10)),
+		},
+	})
+
+	scored := vcopy.ScoredCandidates()
+	assert.EqualValues(t, 3, len(scored), "explains all candidates")
+
+	assert.EqualValues(t, "tmxviewer.exe", scored[0].Candidate.Path, "winner comes first")
+	assert.EqualValues(t, 100, scored[0].Score, "winner has full score")
+	assert.EqualValues(t, "", scored[0].EliminatedBy, "winner wasn't eliminated")
+
+	assert.EqualValues(t, "automappingconverter.exe", scored[1].Candidate.Path, "runner-up comes second")
+	assert.EqualValues(t, 90, scored[1].Score, "runner-up was penalized")
+	assert.EqualValues(t, 1, len(scored[1].Penalties), "runner-up has one penalty")
+
+	assert.EqualValues(t, "tiled.exe", scored[2].Candidate.Path, "eliminated candidate comes last")
+	assert.EqualValues(t, dash.FilterStageScore, scored[2].EliminatedBy, "eliminated by scoring")
+	assert.EqualValues(t, dash.PenaltyExclude, scored[2].Penalties[0].Kind, "eliminated by exclude penalty")
+}
+
+func Test_FixPermissionsAppBundle(t *testing.T) {
+	root := filepath.Join("testdata", "darwin-bundle-exec")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 1, len(v.Candidates), "finds the app bundle")
+	assert.EqualValues(t, dash.FlavorAppMacos, v.Candidates[0].Flavor, "finds the app bundle")
+
+	fixed, err := dash.FixPermissions(v, fixParams(t))
+	assert.NoError(t, err, "fixes permissions without problems")
+	assert.EqualValues(t, []string{
+		"Game.app/Contents/MacOS/Game Launcher",
+		"Game.app/Contents/MacOS/crashpad_handler",
+	}, fixed, "fixes executables inside the bundle")
+}
+
+func Test_ConfigureRPGMaker(t *testing.T) {
+	{
+		root := filepath.Join("testdata", "html-rpgmaker", "mv")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+		for _, osFilter := range []string{"windows", "darwin"} {
+			vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: osFilter, Arch: "amd64"})
+
+			require.Len(t, vcopy.Candidates, 1, "only one candidate left after filtering for %s", osFilter)
+			assert.EqualValues(t, "www/index.html", vcopy.Candidates[0].Path, "RPG Maker project wins on %s", osFilter)
+			assert.EqualValues(t, dash.HTMLEngineRPGMakerMV, vcopy.Candidates[0].HTMLInfo.Engine, "detects RPG Maker MV")
+		}
+	}
+
+	{
+		root := filepath.Join("testdata", "html-rpgmaker", "mz")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+
+		require.Len(t, vcopy.Candidates, 1, "only one candidate left after filtering")
+		assert.EqualValues(t, "game/index.html", vcopy.Candidates[0].Path, "RPG Maker project wins")
+		assert.EqualValues(t, dash.HTMLEngineRPGMakerMZ, vcopy.Candidates[0].HTMLInfo.Engine, "detects RPG Maker MZ")
+	}
+
+	{
+		// an RPG Maker MV project deployed for windows, with the NW.js
+		// runtime that runs it
+		root, err := ioutil.TempDir("", "dash-rpgmaker")
+		assert.NoError(t, err, "creates temp dir")
+		defer os.RemoveAll(root)
+
+		exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows", "game.exe"))
+		assert.NoError(t, err, "reads test file")
+		files := map[string][]byte{
+			"Game.exe":                exe,
+			"package.json":            []byte(`{"name": "game", "main": "www/index.html"}`),
+			"www/index.html":          []byte("<html><script src=\"js/rpg_core.js\"></script></html>"),
+			"www/js/rpg_core.js":      []byte("// rpg_core.js v1.6.1"),
+			"www/data/System.json":    []byte("{}"),
+			"www/docs/credits.html":   []byte("<html>credits</html>"),
+			"www/docs/changelog.html": []byte("<html>changes</html>"),
+		}
+		for name, contents := range files {
+			p := filepath.Join(root, filepath.FromSlash(name))
+			assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder")
+			assert.NoError(t, ioutil.WriteFile(p, contents, 0644), "writes %s", name)
+		}
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+		require.Len(t, vcopy.Candidates, 1, "only one candidate left after filtering")
+		assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "NW.js runtime wins on windows")
+		assert.EqualValues(t, dash.FlavorNWjs, vcopy.Candidates[0].Flavor, "detects NW.js")
+
+		vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+		require.Len(t, vcopy.Candidates, 1, "only one candidate left after filtering")
+		assert.EqualValues(t, "www/index.html", vcopy.Candidates[0].Path, "RPG Maker project wins elsewhere")
+	}
+}
+
+func Test_ConfigureConstruct(t *testing.T) {
+	{
+		root := filepath.Join("testdata", "html-construct", "c2")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin", Arch: "amd64"})
+
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+		assert.EqualValues(t, "game/index.html", vcopy.Candidates[0].Path, "Construct export wins, even if deeper")
+		assert.EqualValues(t, dash.HTMLEngineConstruct2, vcopy.Candidates[0].HTMLInfo.Engine, "detects Construct 2")
+	}
+
+	{
+		root := filepath.Join("testdata", "html-construct", "c3")
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems")
+		assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+		assert.EqualValues(t, "export/index.html", vcopy.Candidates[0].Path, "Construct export wins")
+		assert.EqualValues(t, dash.HTMLEngineConstruct3, vcopy.Candidates[0].HTMLInfo.Engine, "detects Construct 3")
+	}
+}
+
+func Test_ConfigureGzip(t *testing.T) {
+	root := filepath.Join("testdata", "linux-gzip")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "game.gz":
+			assert.EqualValues(t, dash.FlavorNativeLinux, c.Flavor, "detects compressed executable")
+			assert.EqualValues(t, dash.ArchAmd64, c.Arch, "reads arch of compressed executable")
+			assert.True(t, c.Compressed, "marks executable as compressed")
+			assert.NotNil(t, c.LinuxInfo, "has linux info")
+		case "notes.txt.gz":
+			assert.EqualValues(t, dash.FlavorArchive, c.Flavor, "other gzip files are archives")
+			assert.False(t, c.Compressed, "archives aren't marked as compressed")
+		default:
+			t.Errorf("unexpected candidate %s", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.gz", vcopy.Candidates[0].Path, "compressed executable wins over archive")
+	}
+}
+
+func Test_ConfigureJarFallback(t *testing.T) {
+	var jarBuf bytes.Buffer
+	zw := zip.NewWriter(&jarBuf)
+	w, err := zw.Create("META-INF/MANIFEST.MF")
+	assert.NoError(t, err, "creates manifest")
+	_, err = w.Write([]byte("Manifest-Version: 1.0\r\nLauncher-Agent: com.example.Boot\r\n"))
+	assert.NoError(t, err, "writes manifest")
+	_, err = zw.Create("com/example/Boot.class")
+	assert.NoError(t, err, "creates class")
+	assert.NoError(t, zw.Close(), "closes jar")
+	jar := jarBuf.Bytes()
+
+	cases := []struct {
+		name  string
+		files map[string][]byte
+		found bool
+	}{
+		{"single", map[string][]byte{"game.jar": jar, "assets/sprite.png": []byte("PNG")}, true},
+		{"several", map[string][]byte{"game.jar": jar, "editor.jar": jar}, false},
+		{"nested", map[string][]byte{"lib/game.jar": jar}, false},
+		{"not-a-zip", map[string][]byte{"game.jar": []byte("not a jar")}, false},
+	}
+
+	for _, tc := range cases {
+		root, err := ioutil.TempDir("", "dash-jar-fallback")
+		assert.NoError(t, err, "creates temp folder")
+		defer os.RemoveAll(root)
+
+		for name, contents := range tc.files {
+			p := filepath.Join(root, filepath.FromSlash(name))
+			assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder for %s", name)
+			assert.NoError(t, ioutil.WriteFile(p, contents, 0644), "writes %s", name)
+		}
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems (%s)", tc.name)
+		if !tc.found {
+			assert.Empty(t, v.Candidates, "doesn't fall back on jars (%s)", tc.name)
+			continue
+		}
+
+		if assert.EqualValues(t, 1, len(v.Candidates), "falls back on top-level jar (%s)", tc.name) {
+			c := v.Candidates[0]
+			assert.EqualValues(t, "game.jar", c.Path, "picks the jar (%s)", tc.name)
+			assert.EqualValues(t, dash.FlavorJar, c.Flavor, "marks it as a jar (%s)", tc.name)
+			if assert.NotNil(t, c.JarInfo, "has jar info (%s)", tc.name) {
+				assert.False(t, c.JarInfo.Runnable, "no main class to run (%s)", tc.name)
+			}
+		}
+
+		vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "jar survives filtering (%s)", tc.name)
+	}
+}
+
+func Test_ConfigureJNLP(t *testing.T) {
+	root := filepath.Join("testdata", "java-jnlp")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "game.jnlp":
+			assert.EqualValues(t, dash.FlavorJNLP, c.Flavor, "detects JNLP descriptor")
+			if assert.NotNil(t, c.JNLPInfo, "has JNLP info") {
+				assert.EqualValues(t, "com.example.game.Main", c.JNLPInfo.MainClass, "reads main class despite mangled XML")
+				assert.EqualValues(t, "lib/game.jar", c.JNLPInfo.MainJar, "reads main jar")
+				assert.False(t, c.JNLPInfo.RequiresNetwork, "local jars don't require network")
+			}
+		case "online.jnlp":
+			assert.EqualValues(t, dash.FlavorJNLP, c.Flavor, "detects remote JNLP descriptor")
+			if assert.NotNil(t, c.JNLPInfo, "has JNLP info") {
+				assert.EqualValues(t, "com.example.applet.Game", c.JNLPInfo.MainClass, "reads applet main class")
+				assert.EqualValues(t, []string{"applet.jar", "https://cdn.example.com/lwjgl.jar"}, c.JNLPInfo.Jars, "reads all jars")
+				assert.EqualValues(t, "applet.jar", c.JNLPInfo.MainJar, "first jar is the main one")
+				assert.True(t, c.JNLPInfo.RequiresNetwork, "remote jars require network")
+			}
+		case "lib/game.jar":
+			assert.EqualValues(t, dash.FlavorJar, c.Flavor, "detects jar")
+		default:
+			t.Errorf("unexpected candidate %s", c.Path)
+		}
+	}
+
+	for _, osFilter := range []string{"windows", "linux", "darwin"} {
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: osFilter, Arch: "amd64"})
+		if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps both descriptors (%s)", osFilter) {
+			for _, c := range vcopy.Candidates {
+				assert.EqualValues(t, dash.FlavorJNLP, c.Flavor, "JNLP descriptors win over bare jars (%s)", osFilter)
+			}
+		}
+	}
+}
+
+func Test_ConfigureMonoGame(t *testing.T) {
+	root := filepath.Join("testdata", "monogame")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "doesn't sniff content files")
+
+	for _, c := range v.Candidates {
+		if c.Path == "Game.exe" {
+			if assert.NotNil(t, c.WindowsInfo, "has windows info") {
+				assert.True(t, c.WindowsInfo.DotNet, "detects managed assembly")
+				assert.EqualValues(t, "run-game.sh", c.WindowsInfo.MonoRuntime, "finds mono wrapper script")
+			}
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin"})
+	if assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps assembly and wrapper") {
+		assert.EqualValues(t, "run-game.sh", vcopy.Candidates[0].Path, "wrapper script wins")
+		assert.EqualValues(t, "Game.exe", vcopy.Candidates[1].Path, "assembly runs on mono")
+	}
+
+	// without the wrapper, there's nothing to run the assembly with
+	tmp, err := ioutil.TempDir("", "dash-monogame")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(tmp)
+
+	exe, err := ioutil.ReadFile(filepath.Join(root, "Game.exe"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmp, "Game.exe"), exe, 0644), "writes assembly")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmp, "Game.pdb"), []byte("symbols"), 0644), "writes symbols")
+
+	v, err = dash.Configure(tmp, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "finds assembly") {
+		assert.Empty(t, v.Candidates[0].WindowsInfo.MonoRuntime, "no mono runtime")
+	}
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux"})
+	assert.EqualValues(t, 0, len(vcopy.Candidates), "assembly doesn't run on linux without mono")
+
+	// a bundled runtime, like MonoKickstart's, works too
+	assert.NoError(t, os.Mkdir(filepath.Join(tmp, "lib64"), 0755), "creates lib folder")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmp, "lib64", "libmonosgen-2.0.so.1"), []byte("not really a library"), 0644), "writes runtime")
+
+	v, err = dash.Configure(tmp, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "assembly runs on linux with bundled mono") {
+		assert.EqualValues(t, "lib64/libmonosgen-2.0.so.1", vcopy.Candidates[0].WindowsInfo.MonoRuntime, "finds bundled runtime")
+	}
+}
+
+func Test_FilterMacosScripts(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-macos-scripts")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"start-linux.sh": "#!/bin/bash\nexport LD_LIBRARY_PATH=\"$PWD/lib\"\n./Game.x86_64\n",
+		"start-mac.sh":   "#!/bin/bash\nexec \"$(dirname \"$0\")/Game.app/Contents/MacOS/Game\"\n",
+		"tool.sh":        "#!/bin/bash\necho hi\n",
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), []byte(contents), 0755), "writes script")
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "start-linux.sh":
+			assert.EqualValues(t, dash.FlavorScript, c.Flavor, "linux script is a generic script")
+			assert.True(t, c.ScriptInfo.LinuxOnly, "detects linux-only script")
+		case "start-mac.sh":
+			assert.EqualValues(t, dash.FlavorScriptMacos, c.Flavor, "detects macOS script")
+		case "tool.sh":
+			assert.EqualValues(t, dash.FlavorScript, c.Flavor, "ambiguous script is a generic script")
+			assert.False(t, c.ScriptInfo.LinuxOnly, "ambiguous script isn't linux-only")
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "darwin"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "start-mac.sh", vcopy.Candidates[0].Path, "macOS script wins on macOS")
+	}
+	for _, sc := range vcopy.ScoredCandidates() {
+		if sc.Candidate.Path == "start-linux.sh" {
+			assert.EqualValues(t, "script written for linux, and there are other candidates", sc.Reason, "linux script loses on macOS")
+		}
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux"})
+	for _, c := range vcopy.Candidates {
+		assert.NotEqual(t, "start-mac.sh", c.Path, "macOS script doesn't run on linux")
+	}
+}
+
+func Test_ConfigureWebManifest(t *testing.T) {
+	root := filepath.Join("testdata", "html-manifest")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	var start *dash.Candidate
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorHTML, c.Flavor, "only finds HTML candidates (%s)", c.Path)
+		if c.Path == "play.html" {
+			start = c
+		}
+	}
+	if assert.NotNil(t, start, "finds the page the manifest starts") {
+		if assert.NotNil(t, start.HTMLInfo, "has HTML info") {
+			assert.EqualValues(t, "manifest.webmanifest", start.HTMLInfo.WebManifestPath, "links to the manifest")
+		}
+	}
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "play.html", vcopy.Candidates[0].Path, "page started by the manifest wins")
+	}
+
+	// without a manifest, it's back to guessing
+	tmp, err := ioutil.TempDir("", "dash-no-manifest")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(tmp)
+	for _, name := range []string{"play.html", "credits.html", "game.js"} {
+		contents, err := ioutil.ReadFile(filepath.Join(root, name))
+		assert.NoError(t, err, "reads %s", name)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(tmp, name), contents, 0644), "writes %s", name)
+	}
+
+	v, err = dash.Configure(tmp, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "falls back on all top-level HTML files")
+	for _, c := range v.Candidates {
+		assert.Nil(t, c.HTMLInfo, "no manifest info (%s)", c.Path)
+	}
+}
+
+func Test_ConfigureTwine(t *testing.T) {
+	root := filepath.Join("testdata", "html-twine")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	if assert.EqualValues(t, 1, len(v.Candidates), "only the story is a candidate") {
+		c := v.Candidates[0]
+		assert.EqualValues(t, "lighthouse.html", c.Path, "finds the story")
+		assert.EqualValues(t, dash.FlavorHTML, c.Flavor, "story is HTML")
+		if assert.NotNil(t, c.HTMLInfo, "has HTML info") {
+			assert.EqualValues(t, dash.HTMLEngineTwine, c.HTMLInfo.Engine, "detects Twine story")
+		}
+	}
+
+	// the story wins over an index.html too
+	v.Candidates = append(v.Candidates, &dash.Candidate{Path: "index.html", Depth: 1, Flavor: dash.FlavorHTML})
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "lighthouse.html", vcopy.Candidates[0].Path, "Twine story wins over other HTML")
+	}
+}
+
+func Test_ConfigureLoveJS(t *testing.T) {
+	root := filepath.Join("testdata", "html-lovejs")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		if c.Path == "index.html" {
+			if assert.NotNil(t, c.HTMLInfo, "has HTML info") {
+				assert.EqualValues(t, dash.HTMLEngineLoveJS, c.HTMLInfo.Engine, "detects love.js export")
+			}
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.love", vcopy.Candidates[0].Path, "love bundle wins natively")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: dash.OSWeb})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "index.html", vcopy.Candidates[0].Path, "love.js export wins on the web")
+	}
+}
+
+func Test_ConfigureWindowsDOSStub(t *testing.T) {
+	root := filepath.Join("testdata", "windows-dos-stub")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "sniffs both executables (%s)", c.Path)
+		assert.EqualValues(t, c.Path == "patch.exe", c.WindowsInfo.DOS, "only flags DOS executables (%s)", c.Path)
+	}
+
+	for _, arch := range []string{"386", "amd64"} {
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: arch})
+		assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+		assert.EqualValues(t, "bin/Game.exe", vcopy.Candidates[0].Path, "PE executable wins over DOS one, even if deeper")
+	}
+}
+
+func Test_ConfigureWasm(t *testing.T) {
+	root := filepath.Join("testdata", "html-wasm")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorHTML, c.Flavor, "doesn't make candidates out of wasm modules")
+		if c.Path == "game/index.html" {
+			if assert.NotNil(t, c.HTMLInfo, "finds wasm module") {
+				assert.EqualValues(t, "game/game.wasm", c.HTMLInfo.WasmPath, "finds wasm module")
+			}
+		} else {
+			assert.Nil(t, c.HTMLInfo, "checks wasm magic (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game/index.html", vcopy.Candidates[0].Path, "HTML with a wasm module wins, even if deeper")
+}
+
+func Test_ConfigureBatchTarget(t *testing.T) {
+	root := filepath.Join("testdata", "windows-batch")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "play.bat":
+			assert.EqualValues(t, dash.FlavorScriptWindows, c.Flavor, "sniffs batch script")
+			assert.EqualValues(t, "bin/game.exe", c.ScriptTarget, "finds what the script starts")
+		case "help.bat":
+			assert.EqualValues(t, dash.FlavorScriptWindows, c.Flavor, "sniffs batch script")
+			assert.EqualValues(t, "", c.ScriptTarget, "script doesn't start anything")
+		case "bin/game.exe":
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "sniffs executable")
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "play.bat", vcopy.Candidates[0].Path, "script that starts the game wins")
+	}
+
+	for _, sc := range vcopy.ScoredCandidates() {
+		if sc.Candidate.Path == "bin/game.exe" {
+			assert.EqualValues(t, dash.FilterStageFlavor, sc.EliminatedBy, "target isn't a separate winner")
+			assert.Contains(t, sc.Reason, "play.bat", "mentions the script")
+		}
+	}
+}
+
+func Test_ConfigureRegisteredSniffer(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-sniffers")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	files := map[string][]byte{
+		"game.toy":   []byte("TOY 1.0"),
+		"broken.toy": []byte("not a toy"),
+		"engine.toy": append([]byte{}, elf...),
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds candidates with registered sniffer")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "game.toy":
+			assert.EqualValues(t, flavorToy, c.Flavor, "registered sniffer recognizes file")
+			assert.EqualValues(t, 7, c.Size, "sets size of candidates from registered sniffers")
+		case "engine.toy":
+			assert.EqualValues(t, dash.FlavorNativeLinux, c.Flavor, "built-in sniffers take precedence")
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.toy", vcopy.Candidates[0].Path, "registered flavors aren't tied to an OS")
+	}
+
+	parsed, err := dash.ParseFlavor("toy")
+	assert.NoError(t, err, "parses registered flavor")
+	assert.EqualValues(t, flavorToy, parsed, "parses registered flavor")
+
+	assert.Panics(t, func() { dash.RegisterFlavor(dash.FlavorNativeLinux) }, "can't register built-in flavor")
+	assert.Panics(t, func() { dash.RegisterSniffer("toy", sniffToy) }, "can't register sniffer twice")
+}
+
+func Test_FilterSteamAPI(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-steam")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "reads test file")
+
+	files := map[string][]byte{
+		"game/Game.exe":                  exe,
+		"game/steam_api64.dll":           []byte("not really a dll"),
+		"game/steamerrorreporter64.exe":  exe,
+		"tools/Editor.exe":               exe,
+		"tools/SteamWorld Level Kit.exe": exe,
+	}
+	for name, contents := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755), "creates folder for %s", name)
+		assert.NoError(t, ioutil.WriteFile(p, contents, 0644), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all executables")
+
+	for _, c := range v.Candidates {
+		if assert.NotNil(t, c.WindowsInfo, "has windows info (%s)", c.Path) {
+			assert.EqualValues(t, filepath.Dir(c.Path) == "game", c.WindowsInfo.SteamAPI, "only marks executables next to the Steamworks API (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.NotEmpty(t, vcopy.Candidates, "some candidates left after filtering") {
+		assert.EqualValues(t, "game/Game.exe", vcopy.Candidates[0].Path, "executable next to the Steamworks API wins")
+	}
+
+	for _, sc := range vcopy.ScoredCandidates() {
+		switch sc.Candidate.Path {
+		case "game/steamerrorreporter64.exe":
+			assert.NotEmpty(t, sc.Penalties, "penalizes Steam helper")
+		case "tools/SteamWorld Level Kit.exe":
+			assert.Empty(t, sc.Penalties, "doesn't penalize games named after Steam")
+		}
+	}
+}
+
+func Test_ConfigureFlashProjector(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-flash")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "reads test file")
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+
+	project := func(player []byte, swf []byte) []byte {
+		var buf bytes.Buffer
+		buf.Write(player)
+		buf.Write(swf)
+		assert.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(0xFA123456)))
+		assert.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(len(swf))))
+		return buf.Bytes()
+	}
+	swf := []byte("CWS\x0a\x00\x10\x00\x00compressed movie")
+
+	files := map[string][]byte{
+		"Game.exe":        project(exe, swf),
+		"flashplayer.exe": exe,
+		"game-linux":      project(elf, swf),
+		"broken.exe":      project(exe, []byte("not a movie")),
+		"game.swf":        swf,
+	}
+	for name, contents := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all executables")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "Game.exe":
+			assert.EqualValues(t, dash.FlavorFlashProjector, c.Flavor, "detects windows projector")
+			assert.NotNil(t, c.WindowsInfo, "keeps windows info")
+		case "game-linux":
+			assert.EqualValues(t, dash.FlavorFlashProjector, c.Flavor, "detects linux projector")
+			assert.NotNil(t, c.LinuxInfo, "keeps linux info")
+		case "flashplayer.exe", "broken.exe":
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "leaves other executables alone (%s)", c.Path)
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "windows projector wins")
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game-linux", vcopy.Candidates[0].Path, "linux projector wins")
+	}
+}
+
+func Test_ConfigureUnreal(t *testing.T) {
+	root := filepath.Join("testdata", "unreal")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "MyGame.exe":
+			assert.EqualValues(t, dash.FlavorUnreal, c.Flavor, "marks launcher as Unreal")
+			assert.NotNil(t, c.WindowsInfo, "keeps windows info")
+			if assert.NotNil(t, c.UnrealInfo, "has Unreal info") {
+				assert.EqualValues(t, "MyGame", c.UnrealInfo.ProjectName, "finds project name")
+				assert.EqualValues(t, "MyGame/Binaries/Win64/MyGame-Win64-Shipping.exe", c.UnrealInfo.ShippingPath, "finds shipping binary")
+			}
+		case "MyGame/Binaries/Win64/MyGame-Win64-Shipping.exe", "Engine/Binaries/Win64/CrashReportClient.exe":
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "leaves other executables alone (%s)", c.Path)
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "MyGame.exe", vcopy.Candidates[0].Path, "launcher wins")
+	}
+
+	// without a launcher, the shipping binary wins, even with a shallower tool
+	tmp, err := ioutil.TempDir("", "dash-unreal")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(tmp)
+
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(tmp, rel), 0755)
+		}
+		if rel == "MyGame.exe" {
+			rel = "Tools.exe"
+		}
+		contents, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(tmp, rel), contents, 0644)
+	})
+	assert.NoError(t, err, "copies fixture")
+
+	v, err = dash.Configure(tmp, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		if c.Path == "MyGame/Binaries/Win64/MyGame-Win64-Shipping.exe" {
+			assert.EqualValues(t, dash.FlavorUnreal, c.Flavor, "marks shipping binary as Unreal")
+		} else {
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "leaves other executables alone (%s)", c.Path)
+		}
+	}
+
+	vcopy = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "MyGame/Binaries/Win64/MyGame-Win64-Shipping.exe", vcopy.Candidates[0].Path, "shipping binary wins")
+	}
+}
+
+func Test_FilterUnrealHelpers(t *testing.T) {
+	v := dash.Verdict{
+		Candidates: []*dash.Candidate{
+			{Path: "Engine/Binaries/Win64/CrashReportClient.exe", Depth: 4, Flavor: dash.FlavorNativeWindows, Size: 4096},
+			{Path: "Engine/Binaries/ThirdParty/CEF3/Win64/UnrealCEFSubProcess.exe", Depth: 4, Flavor: dash.FlavorNativeWindows, Size: 4096},
+			{Path: "Engine/Binaries/Win64/Game-Win64-Shipping-Cmd.exe", Depth: 4, Flavor: dash.FlavorNativeWindows, Size: 4096},
+			{Path: "Engine/Binaries/Win64/Game.exe", Depth: 4, Flavor: dash.FlavorNativeWindows, Size: 1024},
+		},
+	}
+
+	for _, sc := range v.FilteredCopy(makeConsumer(t), dash.FilterParams{KeepAll: true}).ScoredCandidates() {
+		if sc.Candidate.Path == "Engine/Binaries/Win64/Game.exe" {
+			assert.Empty(t, sc.Penalties, "doesn't penalize the game")
+		} else {
+			assert.NotEmpty(t, sc.Penalties, "penalizes helper (%s)", sc.Candidate.Path)
+		}
+	}
+}
+
+func Test_ConfigureDOSBox(t *testing.T) {
+	root := filepath.Join("testdata", "dosbox")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		switch c.Path {
+		case "c/GAME/GAME.EXE":
+			assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "sniffs DOS executables")
+			assert.True(t, c.WindowsInfo.DOS, "marks DOS executables as such")
+		case "DOSBOX/DOSBox.exe":
+			assert.EqualValues(t, dash.FlavorDOSBox, c.Flavor, "marks DOSBox as such")
+			assert.NotNil(t, c.WindowsInfo, "keeps native info")
+			assert.False(t, c.WindowsInfo.DOS, "leaves PE executables alone")
+			assert.EqualValues(t, "dosboxGame.conf", c.DOSBoxInfo.ConfigPath, "finds DOSBox config")
+		case "Launch Game.bat":
+			assert.EqualValues(t, dash.FlavorScriptWindows, c.Flavor, "keeps script flavor")
+			assert.EqualValues(t, "DOSBOX/DOSBox.exe", c.ScriptTarget, "finds what the script launches")
+			if assert.NotNil(t, c.DOSBoxInfo, "detects script that starts DOSBox") {
+				assert.EqualValues(t, "dosboxGame.conf", c.DOSBoxInfo.ConfigPath, "finds config passed to DOSBox")
+			}
+		case "c/GAME/INSTALL.BAT":
+			assert.Nil(t, c.DOSBoxInfo, "leaves DOS scripts alone")
+		default:
+			t.Errorf("unexpected candidate (%s)", c.Path)
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Launch Game.bat", vcopy.Candidates[0].Path, "script that starts DOSBox wins")
+
+	params := configureParams(t)
+	params.ExcludeGlobs = []string{"launch game.bat"}
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "DOSBOX/DOSBox.exe", vcopy.Candidates[0].Path, "DOSBox wins, even if deeper")
+}
+
+func Test_ConfigureRenpy(t *testing.T) {
+	root := filepath.Join("testdata", "renpy")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		assert.NotNil(t, c.RenpyInfo, "marks all candidates as part of a Ren'Py game")
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.sh", vcopy.Candidates[0].Path, "launcher script wins over interpreter")
+	assert.EqualValues(t, dash.RenpyRoleLauncher, vcopy.Candidates[0].RenpyInfo.Role, "winner is a launcher")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "launcher executable wins")
+}
+
+func Test_ConfigureGameMaker(t *testing.T) {
+	root := filepath.Join("testdata", "gamemaker")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk, but not data files or options.ini")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "runner wins over helper")
+	assert.EqualValues(t, dash.FlavorGameMaker, vcopy.Candidates[0].Flavor, "runner is a GameMaker candidate")
+	assert.EqualValues(t, "data.win", vcopy.Candidates[0].GameMakerInfo.DataPath, "runner knows its data file")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game", vcopy.Candidates[0].Path, "linux runner wins")
+	assert.EqualValues(t, "assets/game.unx", vcopy.Candidates[0].GameMakerInfo.DataPath, "finds data file in assets folder")
+}
+
+func Test_ConfigureElectron(t *testing.T) {
+	root := filepath.Join("testdata", "electron-asar")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.exe", vcopy.Candidates[0].Path, "main executable wins over helper")
+	assert.EqualValues(t, dash.FlavorElectron, vcopy.Candidates[0].Flavor, "main executable is an Electron candidate")
+	assert.EqualValues(t, "resources/app.asar", vcopy.Candidates[0].ElectronInfo.AppPath, "finds the app archive")
+
+	// games may be named like helpers
+	exe, err := ioutil.ReadFile(filepath.Join(root, "Game.exe"))
+	assert.NoError(t, err, "reads test file")
+	asar, err := ioutil.ReadFile(filepath.Join(root, "resources", "app.asar"))
+	assert.NoError(t, err, "reads test file")
+
+	for _, name := range []string{"Sandbox Tales.exe", "GPU Renderer.exe", "Helpers.exe", "nwjc-quest.exe"} {
+		func() {
+			root, err := ioutil.TempDir("", "dash-electron")
+			assert.NoError(t, err, "creates temp dir")
+			defer os.RemoveAll(root)
+
+			assert.NoError(t, os.Mkdir(filepath.Join(root, "resources"), 0755), "creates resources folder")
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "resources", "app.asar"), asar, 0644), "writes app archive")
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), exe, 0644), "writes %s", name)
+
+			v, err := dash.Configure(root, configureParams(t))
+			assert.NoError(t, err, "walks without problems")
+			require.Len(t, v.Candidates, 1, "finds %s", name)
+			assert.EqualValues(t, dash.FlavorElectron, v.Candidates[0].Flavor, "%s is an Electron candidate", name)
+		}()
+	}
+}
+
+func Test_ConfigureNWjs(t *testing.T) {
+	root := filepath.Join("testdata", "nwjs")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game", vcopy.Candidates[0].Path, "main executable wins over helper")
+	assert.EqualValues(t, dash.FlavorNWjs, vcopy.Candidates[0].Flavor, "main executable is an NW.js candidate")
+	assert.EqualValues(t, "package.json", vcopy.Candidates[0].NWjsInfo.PackagePath, "finds the app manifest")
+}
+
+func Test_ConfigureWindowsDualArch(t *testing.T) {
+	root := filepath.Join("testdata", "windows-dual-arch")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	v32 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386"})
+	assert.EqualValues(t, 1, len(v32.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game.exe", v32.Candidates[0].Path, "32-bit wins on 32-bit")
+	assert.EqualValues(t, dash.Arch386, v32.Candidates[0].Arch, "reads 32-bit machine type")
+
+	v64 := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	assert.EqualValues(t, 1, len(v64.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "Game64.exe", v64.Candidates[0].Path, "64-bit wins on 64-bit")
+	assert.EqualValues(t, dash.ArchAmd64, v64.Candidates[0].Arch, "reads 64-bit machine type")
+}
+
+func Test_FilterWindowsArm64(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-windows-arm64")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"Game.exe", "Game64.exe"} {
+		contents, err := ioutil.ReadFile(filepath.Join("testdata", "windows-dual-arch", name))
+		assert.NoError(t, err, "reads %s", name)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, name), contents, 0644), "writes %s", name)
+	}
+
+	// same as the 64-bit build, with the machine type changed to ARM64
+	arm, err := ioutil.ReadFile(filepath.Join("testdata", "windows-dual-arch", "Game64.exe"))
+	assert.NoError(t, err, "reads test file")
+	peOffset := binary.LittleEndian.Uint32(arm[0x3C:])
+	binary.LittleEndian.PutUint16(arm[peOffset+4:], 0xAA64)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "GameArm64.exe"), arm, 0644), "writes arm64 build")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	varm := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "arm64"})
+	if assert.EqualValues(t, 3, len(varm.Candidates), "keeps x86 and x64 builds as fallbacks") {
+		assert.EqualValues(t, "GameArm64.exe", varm.Candidates[0].Path, "arm64 build wins on arm64")
+		assert.EqualValues(t, dash.ArchArm64, varm.Candidates[0].Arch, "reads arm64 machine type")
+
+		scored := varm.ScoredCandidates()
+		assert.EqualValues(t, 100, scored[0].Score, "arm64 build isn't penalized")
+		for _, sc := range scored[1:] {
+			assert.EqualValues(t, 90, sc.Score, "emulated build is penalized (%s)", sc.Candidate.Path)
+		}
+	}
+
+	v64 := v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64"})
+	if assert.EqualValues(t, 1, len(v64.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "Game64.exe", v64.Candidates[0].Path, "64-bit wins on 64-bit")
+	}
+
+	// without an arm64 build, x64 is the best there is
+	var emulated []*dash.Candidate
+	for _, c := range v.Candidates {
+		if c.Arch != dash.ArchArm64 {
+			emulated = append(emulated, c)
+		}
+	}
+	v.Candidates = emulated
+	varm = v.FilteredCopy(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "arm64"})
+	assert.NotEmpty(t, varm.Candidates, "emulated builds aren't excluded")
+}
+
+func Test_ConfigureWindowsInstaller(t *testing.T) {
+	root := filepath.Join("testdata", "windows-installer")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		if c.Path == "extras.exe" {
+			assert.EqualValues(t, dash.WindowsInstallerTypeInno, c.WindowsInfo.InstallerType, "detects Inno Setup installer")
+		}
+	}
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering")
+	assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "non-installer wins")
+}
+
+func Test_SniffInstallShield(t *testing.T) {
+	// its last section ends where the file does, anything after that is
+	// appended data
+	exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows-version", "game.exe"))
+	assert.NoError(t, err, "reads test file")
+
+	sniff := func(appended string) dash.WindowsInstallerType {
+		data := append(append([]byte(nil), exe...), appended...)
+		c, err := dash.SniffBytes(data, "setup.exe")
+		assert.NoError(t, err, "sniffs without problems")
+		require.NotNil(t, c, "detects executable")
+		return c.WindowsInfo.InstallerType
+	}
+
+	assert.EqualValues(t, dash.WindowsInstallerTypeInstallShield, sniff("InstallShield\x00\x02\x00\x00\x00"), "detects setup stream header")
+	assert.EqualValues(t, dash.WindowsInstallerTypeInstallShield, sniff("\x00\x00ISSetupStream\x00"), "detects setup stream name")
+	assert.EqualValues(t, "", sniff("Thanks to the InstallShield team for the redistributables"), "mentions of InstallShield don't count")
+	assert.EqualValues(t, "", sniff("\x00\x00InstallShield\x00"), "InstallShield headers elsewhere don't count")
+}
+
+func Test_SniffInstallerMarkers(t *testing.T) {
+	// its last section ends where the file does, anything after that is
+	// appended data
+	exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows-version", "game.exe"))
+	assert.NoError(t, err, "reads test file")
+
+	sniffData := func(data []byte) dash.WindowsInstallerType {
+		c, err := dash.SniffBytes(data, "setup.exe")
+		assert.NoError(t, err, "sniffs without problems")
+		require.NotNil(t, c, "detects executable")
+		return c.WindowsInfo.InstallerType
+	}
+	appended := func(offset int, marker string) dash.WindowsInstallerType {
+		data := append([]byte(nil), exe...)
+		data = append(data, make([]byte, offset)...)
+		return sniffData(append(data, marker...))
+	}
+	inside := func(offset int, marker string) dash.WindowsInstallerType {
+		data := append([]byte(nil), exe...)
+		copy(data[offset:], marker)
+		return sniffData(data)
+	}
+
+	// appended data is scanned 64KiB at a time
+	assert.EqualValues(t, dash.WindowsInstallerTypeNullsoft, appended(64*1024-5, "NullsoftInst"), "finds markers across chunks")
+	assert.EqualValues(t, dash.WindowsInstallerTypeInno, appended(3*64*1024-10, "Inno Setup Setup Data"), "finds markers in later chunks")
+	assert.EqualValues(t, "", appended(1024*1024, "NullsoftInst"), "only scans the start of appended data")
+
+	// .text starts at 1024, .rsrc at 11776
+	assert.EqualValues(t, dash.WindowsInstallerTypeMsiWrapper, inside(11776+100, "MSI Wrapper"), "finds markers in resources")
+	assert.EqualValues(t, "", inside(1024+100, "MSI Wrapper"), "doesn't scan code")
+}
+
+func Test_CandidateIsInstaller(t *testing.T) {
+	root := filepath.Join("testdata", "windows-installer")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		expected := c.Path == "extras.exe"
+		assert.EqualValues(t, expected, c.IsInstaller(v.BasePath, makeConsumer(t)), "tells installers apart (%s)", c.Path)
+		assert.EqualValues(t, expected, c.IsInstaller(v.BasePath, makeConsumer(t)), "gives the same answer when asked again (%s)", c.Path)
+	}
+
+	missing := &dash.Candidate{Flavor: dash.FlavorNativeWindows, Path: "missing.exe"}
+	assert.False(t, missing.IsInstaller(root, makeConsumer(t)), "can't tell if missing files are installers")
+
+	script := &dash.Candidate{Flavor: dash.FlavorScriptWindows, Path: "setup.bat"}
+	assert.False(t, script.IsInstaller(root, makeConsumer(t)), "only native windows executables are installers")
+}
+
+func Test_FilterAllowInstallers(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-installers")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	for src, dst := range map[string]string{"game.exe": "game.exe", "extras.exe": "setup.exe"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "windows-installer", src))
+		assert.NoError(t, err, "reads test file")
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, dst), data, 0644), "writes test file")
+	}
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "installer is excluded by default")
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "386", AllowInstallers: true})
+	var paths []string
+	for _, c := range vcopy.Candidates {
+		paths = append(paths, c.Path)
+	}
+	assert.Contains(t, paths, "setup.exe", "installer survives when allowed")
+}
+
+func Test_SniffBytes(t *testing.T) {
+	sniffFile := func(path string) *dash.Candidate {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", filepath.FromSlash(path)))
+		assert.NoError(t, err, "reads test file")
+
+		c, err := dash.SniffBytes(data, path)
+		assert.NoError(t, err, "sniffs without problems")
+		if c != nil {
+			assert.EqualValues(t, len(data), c.Size, "sets size from buffer")
+		}
+		return c
+	}
+
+	c := sniffFile("linux-dual-arch/Game.x86_64")
+	assert.EqualValues(t, dash.FlavorNativeLinux, c.Flavor, "sniffs ELF")
+	assert.EqualValues(t, dash.ArchAmd64, c.Arch, "sniffs ELF arch")
+
+	c = sniffFile("windows/game.exe")
+	assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "sniffs PE")
+
+	c = sniffFile("darwin/Some Grand Game.app/Contents/MacOS/game")
+	assert.EqualValues(t, dash.FlavorNativeMacos, c.Flavor, "sniffs Mach-O")
+
+	c = sniffFile("darwin-universal/game-universal")
+	assert.EqualValues(t, dash.FlavorNativeMacos, c.Flavor, "sniffs fat Mach-O")
+
+	c = sniffFile("darwin/readme.txt")
+	assert.Nil(t, c, "ignores short files")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	mw, err := zw.Create("META-INF/MANIFEST.MF")
+	assert.NoError(t, err, "creates manifest")
+	_, err = mw.Write([]byte("Manifest-Version: 1.0\nMain-Class: com.example.Game\n"))
+	assert.NoError(t, err, "writes manifest")
+	assert.NoError(t, zw.Close(), "closes zip")
+
+	c, err = dash.SniffBytes(buf.Bytes(), "game.jar")
+	assert.NoError(t, err, "sniffs without problems")
+	assert.EqualValues(t, dash.FlavorJar, c.Flavor, "sniffs zip")
+	assert.EqualValues(t, "com.example.Game", c.JarInfo.MainClass, "reads main class")
+	assert.True(t, c.JarInfo.Runnable, "marks jar as runnable")
+}
+
+func Test_SniffAt(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	mw, err := zw.Create("META-INF/MANIFEST.MF")
+	assert.NoError(t, err, "creates manifest")
+	_, err = mw.Write([]byte("Manifest-Version: 1.0\nMain-Class: com.example.Game\n"))
+	assert.NoError(t, err, "writes manifest")
+	assert.NoError(t, zw.Close(), "closes zip")
+
+	files := map[string][]byte{"game.jar": buf.Bytes()}
+	for _, path := range []string{
+		"linux-dual-arch/Game.x86_64",
+		"windows-version/game.exe",
+		"darwin/Some Grand Game.app/Contents/MacOS/game",
+		"darwin-universal/game-universal",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", filepath.FromSlash(path)))
+		assert.NoError(t, err, "reads test file")
+		files[path] = data
+	}
+
+	for path, data := range files {
+		expected, err := dash.SniffBytes(data, path)
+		assert.NoError(t, err, "sniffs without problems (%s)", path)
+
+		// only ReadAt, no Seek
+		ra := struct{ io.ReaderAt }{bytes.NewReader(data)}
+		c, err := dash.SniffAt(ra, path, int64(len(data)))
+		assert.NoError(t, err, "sniffs without problems (%s)", path)
+		if assert.NotNil(t, c, "finds a candidate (%s)", path) {
+			assert.EqualValues(t, expected, c, "finds the same as SniffBytes (%s)", path)
+		}
+	}
+}
+
+func Test_SniffELFIdent(t *testing.T) {
+	for name, class := range map[string]int{"Game.x86": 32, "Game.x86_64": 64} {
+		elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", name))
+		assert.NoError(t, err, "reads test file")
+
+		c, err := dash.SniffBytes(elf, name)
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c.LinuxInfo, "has linux info (%s)", name) {
+			assert.EqualValues(t, class, c.LinuxInfo.ELFClass, "reads ELF class (%s)", name)
+			assert.EqualValues(t, dash.ELFDataLittleEndian, c.LinuxInfo.ELFData, "reads byte order (%s)", name)
+			assert.EqualValues(t, 0, c.LinuxInfo.OSABI, "reads System V ABI (%s)", name)
+		}
+	}
+
+	elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", "Game.x86_64"))
+	assert.NoError(t, err, "reads test file")
+	elf[7] = 9 // FreeBSD
+
+	c, err := dash.SniffBytes(elf, "game-freebsd")
+	assert.NoError(t, err, "sniffs without problems")
+	if assert.NotNil(t, c, "still detects ELF") {
+		assert.EqualValues(t, 9, c.LinuxInfo.OSABI, "reads FreeBSD ABI")
+	}
+}
+
+func Test_ConfigurePEVersionInfo(t *testing.T) {
+	windowsInfo := func(root string, params dash.ConfigureParams) *dash.WindowsInfo {
+		v, err := dash.Configure(filepath.Join("testdata", root), params)
+		assert.NoError(t, err, "walks without problems")
+		for _, c := range v.Candidates {
+			if c.Path == "game.exe" {
+				require.NotNil(t, c.WindowsInfo, "has windows info")
+				return c.WindowsInfo
+			}
+		}
+		require.FailNow(t, "finds game.exe")
+		return nil
+	}
+
+	info := windowsInfo("windows-version", configureParams(t))
+	assert.Empty(t, info.ProductName, "doesn't read version info by default")
+
+	params := configureParams(t)
+	params.PEVersionInfo = true
+	info = windowsInfo("windows-version", params)
+	assert.EqualValues(t, "butler", info.ProductName, "reads product name")
+	assert.EqualValues(t, "Test PE file for pelican", info.FileDescription, "reads file description")
+	assert.EqualValues(t, "itch corp.", info.CompanyName, "reads company name")
+	assert.EqualValues(t, "resourceful.exe", info.OriginalFilename, "reads original filename")
+
+	info = windowsInfo("windows", params)
+	assert.Empty(t, info.ProductName, "no version resource, no product name")
+	assert.Empty(t, info.FileDescription, "no version resource, no description")
+}
+
+func Test_SniffTruncatedPE(t *testing.T) {
+	// only has headers, and a bit of code
+	truncated, err := ioutil.ReadFile(filepath.Join("testdata", "unreal", "MyGame.exe"))
+	assert.NoError(t, err, "reads test file")
+
+	// make the section with the import table claim to be huge
+	peOffset := binary.LittleEndian.Uint32(truncated[0x3C:])
+	optSize := binary.LittleEndian.Uint16(truncated[peOffset+20:])
+	section := truncated[peOffset+24+uint32(optSize):]
+	binary.LittleEndian.PutUint32(section[16:20], 0x40000000)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	c, err := dash.SniffBytes(truncated, "game.exe")
+	runtime.ReadMemStats(&after)
+
+	assert.NoError(t, err, "sniffs without problems")
+	if assert.NotNil(t, c, "detects executable") {
+		assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "still a windows executable")
+	}
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 64*1024*1024, "doesn't allocate what the section header says")
+}
+
+func Test_FilterSetupLikeDescription(t *testing.T) {
+	root, err := ioutil.TempDir("", "dash-pe-version")
+	assert.NoError(t, err, "creates temp folder")
+	defer os.RemoveAll(root)
+
+	exe, err := ioutil.ReadFile(filepath.Join("testdata", "windows-version", "game.exe"))
+	assert.NoError(t, err, "reads test file")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "game.exe"), exe, 0644), "writes game")
+
+	// "Test PE file for pelican" becomes "Test install for pelican"
+	utf16 := func(s string) []byte {
+		var res []byte
+		for _, r := range s {
+			res = append(res, byte(r), 0)
+		}
+		return res
+	}
+	tool := bytes.Replace(exe, utf16("PE file"), utf16("install"), 1)
+	assert.NotEqual(t, exe, tool, "patches description")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "tool.exe"), tool, 0644), "writes tool")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "executable described as an installer is excluded")
+	}
+
+	// "resourceful.exe" becomes "setupfuls.exe"
+	renamed := bytes.Replace(exe, utf16("resourceful.exe"), utf16("setupfuls.exe\x00\x00"), 1)
+	assert.NotEqual(t, exe, renamed, "patches original filename")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "tool.exe"), renamed, 0644), "writes renamed installer")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows"})
+	if assert.EqualValues(t, 1, len(vcopy.Candidates), "only one candidate left after filtering") {
+		assert.EqualValues(t, "game.exe", vcopy.Candidates[0].Path, "renamed installer is excluded")
+	}
+	for _, sc := range vcopy.ScoredCandidates() {
+		if sc.Candidate.Path == "tool.exe" {
+			assert.EqualValues(t, dash.FilterStageInstaller, sc.EliminatedBy, "excluded as an installer")
+			assert.Contains(t, sc.Reason, "setupfuls.exe", "explains why")
+		}
+	}
+}
+
+func Test_SniffELFStaticLinking(t *testing.T) {
+	for name, layout := range map[string]struct{ phoff, entsize int }{
+		"Game.x86":    {52, 32},
+		"Game.x86_64": {64, 56},
+	} {
+		elf, err := ioutil.ReadFile(filepath.Join("testdata", "linux-dual-arch", name))
+		assert.NoError(t, err, "reads test file")
+
+		c, err := dash.SniffBytes(elf, name)
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c.LinuxInfo, "has linux info (%s)", name) {
+			assert.False(t, c.LinuxInfo.StaticallyLinked, "detects dynamic linking (%s)", name)
+		}
+
+		// turn PT_INTERP and PT_DYNAMIC into PT_NOTE
+		for i := 0; i < 9; i++ {
+			off := layout.phoff + i*layout.entsize
+			switch binary.LittleEndian.Uint32(elf[off:]) {
+			case 2, 3:
+				binary.LittleEndian.PutUint32(elf[off:], 4)
+			}
+		}
+
+		c, err = dash.SniffBytes(elf, name)
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c.LinuxInfo, "has linux info (%s)", name) {
+			assert.True(t, c.LinuxInfo.StaticallyLinked, "detects static linking (%s)", name)
+		}
+
+		c, err = dash.SniffBytes(elf[:layout.phoff+layout.entsize], name)
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c, "still detects truncated ELF (%s)", name) {
+			assert.False(t, c.LinuxInfo.StaticallyLinked, "doesn't guess on truncated headers (%s)", name)
+		}
+	}
+}
+
+func Test_SniffShortScripts(t *testing.T) {
+	for _, data := range []string{"#!/sh\n", "#!"} {
+		c, err := dash.SniffBytes([]byte(data), "launch")
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c, "detects short script %q", data) {
+			assert.EqualValues(t, dash.FlavorScript, c.Flavor, "detects short script %q", data)
+		}
+
+		c, err = dash.Sniff(bytes.NewReader([]byte(data)), "launch", int64(len(data)))
+		assert.NoError(t, err, "sniffs without problems")
+		if assert.NotNil(t, c, "detects short script %q", data) {
+			assert.EqualValues(t, dash.FlavorScript, c.Flavor, "detects short script %q", data)
+		}
+	}
+
+	c, err := dash.SniffBytes([]byte("#!/sh\n"), "launch")
+	assert.NoError(t, err, "sniffs without problems")
+	assert.EqualValues(t, "/sh", c.ScriptInfo.Interpreter, "reads interpreter")
+	assert.EqualValues(t, dash.ScriptKindShell, c.ScriptInfo.Kind, "detects shell scripts")
+
+	for _, data := range [][]byte{{0x7F, 0x45, 0x4C, 0x46}, {0xCF, 0xFA, 0xED, 0xFE, 0x07}, {0x23}} {
+		c, err := dash.SniffBytes(data, "truncated")
+		assert.NoError(t, err, "sniffs without problems")
+		assert.Nil(t, c, "ignores truncated magic %x", data)
+	}
+}
+
+func Test_ConfigureNestedArchives(t *testing.T) {
+	root := filepath.Join("testdata", "nested-archives")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all archives on first walk")
+
+	formats := make(map[string]dash.ArchiveFormat)
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorArchive, c.Flavor, "marks archives as such")
+		formats[c.Path] = c.ArchiveInfo.Format
+	}
+	assert.EqualValues(t, map[string]dash.ArchiveFormat{
+		"game.7z":     dash.ArchiveFormat7z,
+		"game.rar":    dash.ArchiveFormatRar,
+		"game.tar.gz": dash.ArchiveFormatTarGz,
+	}, formats, "detects archive formats")
+}
+
+func Test_SniffZipAssets(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"META-INF/MANIFEST.MF", "com/example/Asset.class", "assets/player.png"} {
+		w, err := zw.Create(name)
+		assert.NoError(t, err, "creates entry")
+		_, err = w.Write([]byte("Manifest-Version: 1.0\n"))
+		assert.NoError(t, err, "writes entry")
+	}
+	assert.NoError(t, zw.Close(), "closes zip")
+
+	c, err := dash.SniffBytes(buf.Bytes(), "assets.zip")
+	assert.NoError(t, err, "sniffs without problems")
+	assert.Nil(t, c, "zip without a main class isn't a candidate")
+}
+
+func Test_ConfigureBundledJava(t *testing.T) {
+	root := filepath.Join("testdata", "java-bundled")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 3, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64"})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "self-contained jar isn't excluded")
+	assert.EqualValues(t, "game.jar", vcopy.Candidates[0].Path, "self-contained jar wins")
+	assert.EqualValues(t, "jre/bin/java", vcopy.Candidates[0].JarInfo.BundledJava, "finds bundled runtime")
+}
+
+func Test_ConfigureCollectSpells(t *testing.T) {
+	root := filepath.Join("testdata", "linux")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		assert.Nil(t, c.Spell, "doesn't collect spells by default")
+	}
+
+	params := configureParams(t)
+	params.CollectSpells = true
+	v, err = dash.Configure(root, params)
+	assert.NoError(t, err, "walks without problems")
+	for _, c := range v.Candidates {
+		assert.NotEmpty(t, c.Spell, "collects spells for all candidates (%s)", c.Path)
+	}
+}
+
+func Test_FilterSeveralOSes(t *testing.T) {
+	root := filepath.Join("testdata", "cross-platform")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 4, len(v.Candidates), "finds all candidates on first walk")
+
+	vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OSes: []string{"linux", "darwin"}, Arch: "amd64"})
+	assert.EqualValues(t, 2, len(vcopy.Candidates), "keeps one candidate per OS")
+	assert.EqualValues(t, "Game.x86_64", vcopy.Candidates[0].Path, "linux candidate comes first")
+	assert.EqualValues(t, "Game.app", vcopy.Candidates[1].Path, "macOS candidate comes second")
+
+	scored := vcopy.ScoredCandidates()
+	assert.EqualValues(t, 4, len(scored), "explains all candidates")
+	for _, sc := range scored[2:] {
+		switch sc.Candidate.Path {
+		case "Game.exe":
+			assert.EqualValues(t, dash.FilterStageCompatibility, sc.EliminatedBy, "windows candidate is incompatible")
+		case "Game.app/Contents/MacOS/Game":
+			assert.EqualValues(t, dash.FilterStageDepth, sc.EliminatedBy, "binary in app bundle is too deep")
+		default:
+			t.Errorf("unexpected eliminated candidate (%s)", sc.Candidate.Path)
+		}
+	}
+
+	vcopy = v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", OSes: []string{"darwin"}, Arch: "amd64"})
+	assert.EqualValues(t, 1, len(vcopy.Candidates), "OSes takes precedence over OS")
+	assert.EqualValues(t, "Game.app", vcopy.Candidates[0].Path, "OSes takes precedence over OS")
+}
+
+func Test_VerdictBestPerPlatform(t *testing.T) {
+	root := filepath.Join("testdata", "cross-platform")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	best := v.BestPerPlatform(makeConsumer(t))
+	paths := make(map[string]string)
+	for osFilter, c := range best {
+		paths[osFilter] = c.Path
+	}
+	assert.EqualValues(t, map[string]string{
+		"windows": "Game.exe",
+		"darwin":  "Game.app",
+		"linux":   "Game.x86_64",
+	}, paths, "picks one candidate per platform")
+
+	for osFilter, c := range best {
+		vcopy := v.Filter(makeConsumer(t), dash.FilterParams{OS: osFilter})
+		bc, _ := vcopy.BestCandidate()
+		assert.EqualValues(t, bc, c, "agrees with Filter for %s", osFilter)
+	}
+
+	root = filepath.Join("testdata", "darwin-universal")
+
+	v, err = dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	best = v.BestPerPlatform(makeConsumer(t))
+	assert.EqualValues(t, 1, len(best), "leaves out platforms without candidates")
+	assert.NotNil(t, best["darwin"], "picks a macOS candidate")
+}
+
+func Test_FilterKeepAll(t *testing.T) {
+	cases := []struct {
+		fixture string
+		params  dash.FilterParams
+	}{
+		{"windows-il2cpp", dash.FilterParams{OS: "windows", Arch: "amd64"}},
+		{"windows-il2cpp", dash.FilterParams{OS: "windows", Arch: "386"}},
+		{"bigger-is-better", dash.FilterParams{OS: "windows", Arch: "amd64"}},
+		{"darwin", dash.FilterParams{OS: "darwin", Arch: "amd64"}},
+		{"linux-dual-arch", dash.FilterParams{OS: "linux", Arch: "amd64"}},
+		{"cross-platform", dash.FilterParams{OSes: []string{"linux", "darwin"}, Arch: "amd64"}},
+	}
+
+	for _, tc := range cases {
+		root := filepath.Join("testdata", tc.fixture)
+
+		v, err := dash.Configure(root, configureParams(t))
+		assert.NoError(t, err, "walks without problems (%s)", tc.fixture)
+
+		pruned := v.Filter(makeConsumer(t), tc.params)
+		params := tc.params
+		params.KeepAll = true
+		all := v.Filter(makeConsumer(t), params)
+
+		if assert.NotEmpty(t, all.Candidates, "keeps candidates (%s)", tc.fixture) {
+			assert.EqualValues(t, pruned.Candidates[0].Path, all.Candidates[0].Path, "picks the same best candidate (%s)", tc.fixture)
+		}
+		assert.True(t, len(all.Candidates) >= len(pruned.Candidates), "keeps at least as many candidates (%s)", tc.fixture)
+		for i, c := range pruned.Candidates {
+			assert.EqualValues(t, c.Path, all.Candidates[i].Path, "keeps winners first, in order (%s)", tc.fixture)
+		}
+		assert.EqualValues(t, len(v.Candidates), len(all.ScoredCandidates()), "explains all candidates (%s)", tc.fixture)
+	}
+
+	root := filepath.Join("testdata", "windows-il2cpp")
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+
+	all := v.Filter(makeConsumer(t), dash.FilterParams{OS: "windows", Arch: "amd64", KeepAll: true})
+	assert.EqualValues(t, 3, len(all.Candidates), "keeps all compatible candidates")
+	assert.EqualValues(t, "game.exe", all.Candidates[0].Path, "game still wins")
+
+	all = v.Filter(makeConsumer(t), dash.FilterParams{OS: "linux", Arch: "amd64", KeepAll: true})
+	assert.EqualValues(t, 0, len(all.Candidates), "still drops incompatible candidates")
+}
+
+func Test_ConfigureLoveFused(t *testing.T) {
+	root := filepath.Join("testdata", "love-fused")
+
+	v, err := dash.Configure(root, configureParams(t))
+	assert.NoError(t, err, "walks without problems")
+	assert.EqualValues(t, 2, len(v.Candidates), "finds all candidates on first walk")
+
+	for _, c := range v.Candidates {
+		assert.EqualValues(t, dash.FlavorNativeWindows, c.Flavor, "keeps native flavor (%s)", c.Path)
+		switch c.Path {
+		case "game.exe":
+			if assert.NotNil(t, c.LoveInfo, "detects fused love game") {
+				assert.True(t, c.LoveInfo.Fused, "detects fused love game")
+				assert.EqualValues(t, "11.3", c.LoveInfo.Version, "reads love version")
+			}
+		case "sfx.exe":
+			assert.Nil(t, c.LoveInfo, "ignores other zip overlays")
+		}
+	}
+}
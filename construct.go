@@ -0,0 +1,98 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Runtime scripts of Construct 3 exports, relative to the folder
+// that contains index.html
+var construct3Signatures = []string{
+	"scripts/c3runtime.js",
+	"c3runtime.js",
+}
+
+// Runtime scripts of Construct 2 exports, relative to the folder
+// that contains index.html
+var construct2Signatures = []string{
+	"c2runtime.js",
+}
+
+// detectConstructExports finds Construct 2 and 3 HTML5 exports, and makes
+// sure the index.html next to their runtime is a candidate, marked as such.
+// Uploads often come with other HTML files (docs, readmes), which the
+// filter would otherwise prefer when they're closer to the root.
+func detectConstructExports(container *tlc.Container, candidates []*Candidate) []*Candidate {
+	lowerPaths := make(map[string]*tlc.File)
+	for _, f := range container.Files {
+		lowerPaths[strings.ToLower(f.Path)] = f
+	}
+
+	candidatesByPath := make(map[string]*Candidate)
+	for _, c := range candidates {
+		candidatesByPath[strings.ToLower(c.Path)] = c
+	}
+
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+
+		var engine HTMLEngine
+		var dir string
+		for _, sig := range construct3Signatures {
+			if lowerPath == sig || strings.HasSuffix(lowerPath, "/"+sig) {
+				engine = HTMLEngineConstruct3
+				dir = strings.TrimSuffix(strings.TrimSuffix(lowerPath, sig), "/")
+				break
+			}
+		}
+		if engine == "" {
+			for _, sig := range construct2Signatures {
+				if lowerPath == sig || strings.HasSuffix(lowerPath, "/"+sig) {
+					engine = HTMLEngineConstruct2
+					dir = strings.TrimSuffix(strings.TrimSuffix(lowerPath, sig), "/")
+					break
+				}
+			}
+		}
+		if engine == "" {
+			continue
+		}
+
+		indexPath := path.Join(dir, "index.html")
+		index, ok := lowerPaths[indexPath]
+		if !ok {
+			continue
+		}
+
+		c, ok := candidatesByPath[indexPath]
+		if !ok {
+			c = &Candidate{
+				Flavor: FlavorHTML,
+				Path:   index.Path,
+				Mode:   index.Mode,
+				Size:   index.Size,
+				Depth:  PathDepth(index.Path),
+			}
+			candidatesByPath[indexPath] = c
+			candidates = append(candidates, c)
+		}
+		if c.Flavor == FlavorHTML {
+			c.HTMLInfo = &HTMLInfo{Engine: engine}
+		}
+	}
+
+	return candidates
+}
+
+func isConstructExport(c *Candidate) bool {
+	if c.HTMLInfo == nil {
+		return false
+	}
+	switch c.HTMLInfo.Engine {
+	case HTMLEngineConstruct2, HTMLEngineConstruct3:
+		return true
+	}
+	return false
+}
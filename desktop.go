@@ -0,0 +1,250 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// Linux games sometimes ship a desktop entry (a `.desktop` file, cf.
+// https://specifications.freedesktop.org/desktop-entry-spec/latest/), for
+// desktop environments to list them in their menus. Those aren't launched
+// themselves, but their Exec line names the game's executable.
+const desktopEntryGroup = "[Desktop Entry]"
+
+// desktopEntry holds the keys of a desktop entry dash cares about
+type desktopEntry struct {
+	exec    string
+	tryExec string
+	// working directory to run the program in
+	dir string
+}
+
+// detectDesktopEntries sets DesktopEntry on the candidates launched by
+// desktop entries. Exec lines are split into arguments, field codes
+// (`%f`, `%U`...) are left out, and `env`, `sh` and `java -jar` are
+// looked through. Programs are resolved relative to the entry's Path key,
+// then to the entry's folder, then to the root of the container. Absolute
+// paths are ignored, since they depend on where the game is installed.
+func detectDesktopEntries(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	byPath := make(map[string]*Candidate)
+	for _, c := range candidates {
+		byPath[strings.ToLower(c.Path)] = c
+	}
+
+	for fileIndex, f := range container.Files {
+		if !strings.HasSuffix(strings.ToLower(f.Path), ".desktop") {
+			continue
+		}
+
+		contents, err := readScriptHead(pool, int64(fileIndex))
+		if err != nil {
+			return errors.Wrapf(err, "reading desktop entry (%s)", f.Path)
+		}
+		entry := parseDesktopEntry(string(contents))
+		if entry == nil {
+			continue
+		}
+
+		entryDir := path.Dir(f.Path)
+		var dirs []string
+		if entry.dir != "" && !path.IsAbs(entry.dir) {
+			dirs = append(dirs, path.Join(entryDir, entry.dir))
+		}
+		dirs = append(dirs, entryDir, ".")
+
+		resolve := func(program string) *Candidate {
+			if program == "" || path.IsAbs(program) {
+				return nil
+			}
+			for _, dir := range dirs {
+				p := path.Join(dir, program)
+				if p == ".." || strings.HasPrefix(p, "../") {
+					continue
+				}
+				if c, ok := byPath[strings.ToLower(p)]; ok {
+					return c
+				}
+			}
+			return nil
+		}
+
+		for _, program := range []string{desktopExecProgram(entry.exec), entry.tryExec} {
+			if c := resolve(program); c != nil {
+				if c.DesktopEntry == "" {
+					c.DesktopEntry = f.Path
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseDesktopEntry returns the keys of the `[Desktop Entry]` group of a
+// desktop entry, or nil if it doesn't have one, or if it's not for an
+// application (links and folders can't be launched).
+func parseDesktopEntry(contents string) *desktopEntry {
+	var entry *desktopEntry
+	inEntry := false
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == desktopEntryGroup
+			if inEntry && entry == nil {
+				entry = &desktopEntry{}
+			}
+			continue
+		}
+		if !inEntry {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		value := unescapeDesktopValue(strings.TrimSpace(line[i+1:]))
+		switch strings.TrimSpace(line[:i]) {
+		case "Type":
+			if value != "Application" {
+				return nil
+			}
+		case "Exec":
+			entry.exec = value
+		case "TryExec":
+			entry.tryExec = value
+		case "Path":
+			entry.dir = value
+		}
+	}
+	return entry
+}
+
+// unescapeDesktopValue replaces the escape sequences of string values
+// (`\s`, `\n`, `\t`, `\r` and `\\`)
+func unescapeDesktopValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			sb.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 's':
+			sb.WriteByte(' ')
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			// `\\`, and sequences that are only meaningful to the
+			// Exec quoting rules, like `\"`
+			if value[i] != '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(value[i])
+		}
+	}
+	return sb.String()
+}
+
+// desktopExecProgram returns the program an Exec line runs, looking
+// through `env` (and the variables it sets), shells running a script,
+// and `java -jar`. It returns an empty string if there's none.
+func desktopExecProgram(exec string) string {
+	args := splitDesktopExec(exec)
+
+	if len(args) > 0 && path.Base(args[0]) == "env" {
+		args = args[1:]
+		for len(args) > 0 && (strings.HasPrefix(args[0], "-") || strings.Contains(args[0], "=")) {
+			switch args[0] {
+			case "-u", "--unset", "-C", "--chdir":
+				// those take a value
+				if len(args) > 1 {
+					args = args[1:]
+				}
+			}
+			args = args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+
+	switch path.Base(args[0]) {
+	case "sh", "bash", "dash":
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				return arg
+			}
+		}
+		return ""
+	case "java":
+		for i, arg := range args[:len(args)-1] {
+			if arg == "-jar" {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+	return args[0]
+}
+
+// splitDesktopExec splits an Exec line into arguments, following its
+// quoting rules, and leaves out field codes (`%f`, `%U`...), which stand
+// for files and URLs passed by the desktop environment.
+func splitDesktopExec(exec string) []string {
+	var args []string
+	var current strings.Builder
+	inArg, quoted := false, false
+
+	flush := func() {
+		if inArg {
+			args = append(args, current.String())
+		}
+		current.Reset()
+		inArg = false
+	}
+
+	for i := 0; i < len(exec); i++ {
+		ch := exec[i]
+		switch {
+		case quoted && ch == '\\' && i+1 < len(exec):
+			i++
+			current.WriteByte(exec[i])
+		case ch == '"':
+			quoted = !quoted
+			inArg = true
+		case !quoted && (ch == ' ' || ch == '\t'):
+			flush()
+		case ch == '%' && i+1 < len(exec):
+			i++
+			if exec[i] == '%' {
+				current.WriteByte('%')
+				inArg = true
+			}
+			// other field codes expand to nothing we know of
+		default:
+			current.WriteByte(ch)
+			inArg = true
+		}
+	}
+	flush()
+	return args
+}
@@ -0,0 +1,32 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DesktopExecProgram(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.EqualValues([]string{"./game", "--fullscreen"}, splitDesktopExec("./game %U --fullscreen %f"))
+	assert.EqualValues([]string{"My Game/game", "100%"}, splitDesktopExec(`"My Game/game" 100%%`))
+	assert.EqualValues([]string{`say "hi"`}, splitDesktopExec(`"say \"hi\""`))
+
+	assert.EqualValues("bin/game.x86_64", desktopExecProgram("bin/game.x86_64 %F"))
+	assert.EqualValues("./game", desktopExecProgram("env -u FOO LD_LIBRARY_PATH=lib SDL_VIDEODRIVER=x11 ./game"))
+	assert.EqualValues("start.sh", desktopExecProgram("/bin/sh -e start.sh %u"))
+	assert.EqualValues("lib/game.jar", desktopExecProgram("java -Xmx1G -jar lib/game.jar"))
+	assert.EqualValues("", desktopExecProgram("java -cp lib Main"))
+	assert.EqualValues("", desktopExecProgram("%U"))
+
+	entry := parseDesktopEntry("# made by hand\n[Desktop Entry]\nType=Application\nName=Game\nExec=\"./My\\sGame\" %U\nTryExec=game\nPath=bin\n\n[Desktop Action Editor]\nExec=./editor\n")
+	if assert.NotNil(entry) {
+		assert.EqualValues(`"./My Game" %U`, entry.exec)
+		assert.EqualValues("game", entry.tryExec)
+		assert.EqualValues("bin", entry.dir)
+	}
+
+	assert.Nil(parseDesktopEntry("[Desktop Entry]\nType=Link\nURL=https://example.org\n"))
+	assert.Nil(parseDesktopEntry("Exec=./game\n"))
+}
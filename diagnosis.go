@@ -0,0 +1,95 @@
+package dash
+
+import "strings"
+
+// configureTally counts what Configure came across, so it can explain
+// an empty verdict
+type configureTally struct {
+	// number of files in the folder
+	files int
+	// number of files that were sniffed (the others have ignored extensions)
+	sniffed int
+	// number of zip files that turned out not to be jars
+	zips int
+	// number of candidates left out because of ConfigureParams.ExcludeGlobs
+	excluded int
+	// number of console ROMs and disc images, only counted when there
+	// are no candidates
+	consoleImages int
+	// number of parts of split archives, only counted when there are no
+	// candidates
+	splitArchiveParts int
+}
+
+// diagnose explains why Configure found no candidates. It returns nil
+// if there were some, unless they're all mobile packages, or all parts
+// of split archives.
+func diagnose(tally configureTally, candidates []*Candidate) *Diagnosis {
+	if len(candidates) > 0 {
+		switch {
+		case allCandidates(candidates, func(c *Candidate) bool { return c.Flavor == FlavorMobile }):
+			return &Diagnosis{
+				Kind:    DiagnosisMobileBuild,
+				Message: "only mobile app packages or ebooks were found, this looks like a mobile build",
+			}
+		case allCandidates(candidates, func(c *Candidate) bool { return c.ArchiveInfo != nil && c.ArchiveInfo.Split }):
+			return splitArchiveDiagnosis()
+		}
+		return nil
+	}
+
+	switch {
+	case tally.files == 0:
+		return &Diagnosis{
+			Kind:    DiagnosisEmpty,
+			Message: "the folder doesn't contain any files",
+		}
+	case tally.excluded > 0:
+		return &Diagnosis{
+			Kind:    DiagnosisExcluded,
+			Message: "all candidates were left out by the exclude globs",
+		}
+	case tally.consoleImages > 0:
+		return &Diagnosis{
+			Kind:    DiagnosisConsoleImage,
+			Message: "only console ROMs or disc images were found, they have no desktop runtime",
+		}
+	case tally.splitArchiveParts > 0:
+		return splitArchiveDiagnosis()
+	case tally.zips > 0:
+		return &Diagnosis{
+			Kind:    DiagnosisOnlyArchives,
+			Message: "only zip archives were found, they probably need to be extracted",
+		}
+	case tally.sniffed == 0:
+		return &Diagnosis{
+			Kind:    DiagnosisBlacklisted,
+			Message: "all files are assets or libraries (by their extension), none of them can be launched",
+		}
+	}
+	return &Diagnosis{
+		Kind:    DiagnosisUnrecognized,
+		Message: "no recognized executables, scripts or games were found",
+	}
+}
+
+func splitArchiveDiagnosis() *Diagnosis {
+	return &Diagnosis{
+		Kind:    DiagnosisSplitArchive,
+		Message: "only parts of a split archive were found, they need to be joined and extracted, and some may be missing",
+	}
+}
+
+// allCandidates returns true if f is true for all candidates
+func allCandidates(candidates []*Candidate, f func(c *Candidate) bool) bool {
+	for _, c := range candidates {
+		if !f(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isZipPath(p string) bool {
+	return strings.HasSuffix(strings.ToLower(p), ".zip")
+}
@@ -0,0 +1,125 @@
+package dash
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// Names of the DOSBox executable, for all platforms and a few forks
+var dosboxExecutableRegexp = regexp.MustCompile(`(?i)^dosbox(-x|-staging|-ece)?(\.exe)?$`)
+
+// DOSBox configuration files: dosbox.conf, dosbox-game.conf, etc.
+var dosboxConfigRegexp = regexp.MustCompile(`(?i)^dosbox.*\.conf$`)
+
+// How much of a script we read to find out whether it starts DOSBox
+const dosboxScriptScanSize = 64 * 1024
+
+// detectDOSBoxGames finds setups where a DOS game is run by a bundled
+// DOSBox: the DOSBox executable is marked as FlavorDOSBox, and scripts
+// that start it are marked with DOSBoxInfo.
+func detectDOSBoxGames(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	var configs []string
+	for _, f := range container.Files {
+		if dosboxConfigRegexp.MatchString(path.Base(f.Path)) {
+			configs = append(configs, f.Path)
+		}
+	}
+
+	var runners []*Candidate
+	for _, c := range candidates {
+		if isNativeFlavor(c.Flavor) && dosboxExecutableRegexp.MatchString(path.Base(c.Path)) {
+			runners = append(runners, c)
+		}
+	}
+
+	if len(configs) == 0 && len(runners) == 0 {
+		return nil
+	}
+
+	// configs next to the candidate first, then closest to the root
+	nearestConfig := func(c *Candidate) string {
+		dir := path.Dir(c.Path)
+		for _, config := range configs {
+			if path.Dir(config) == dir {
+				return config
+			}
+		}
+		best := ""
+		for _, config := range configs {
+			if best == "" || PathDepth(config) < PathDepth(best) {
+				best = config
+			}
+		}
+		return best
+	}
+
+	for _, c := range runners {
+		c.Flavor = FlavorDOSBox
+		c.DOSBoxInfo = &DOSBoxInfo{ConfigPath: nearestConfig(c)}
+	}
+
+	lowerFiles := make(map[string]int64)
+	for fileIndex, f := range container.Files {
+		lowerFiles[strings.ToLower(f.Path)] = int64(fileIndex)
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorScript && c.Flavor != FlavorScriptWindows {
+			continue
+		}
+
+		fileIndex, ok := lowerFiles[strings.ToLower(c.Path)]
+		if !ok {
+			continue
+		}
+
+		contents, err := readScriptHead(pool, fileIndex)
+		if err != nil {
+			return errors.Wrap(err, "reading script")
+		}
+		lowerContents := bytes.ToLower(contents)
+		if !bytes.Contains(lowerContents, []byte("dosbox")) {
+			continue
+		}
+
+		configPath := ""
+		for _, config := range configs {
+			if bytes.Contains(lowerContents, []byte(strings.ToLower(path.Base(config)))) {
+				configPath = config
+				break
+			}
+		}
+		if configPath == "" {
+			configPath = nearestConfig(c)
+		}
+		c.DOSBoxInfo = &DOSBoxInfo{ConfigPath: configPath}
+	}
+
+	return nil
+}
+
+func readScriptHead(pool lake.Pool, fileIndex int64) ([]byte, error) {
+	r, err := pool.GetReadSeeker(fileIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(io.LimitReader(r, dosboxScriptScanSize))
+}
+
+func isDOSBoxScript(c *Candidate) bool {
+	return (c.Flavor == FlavorScript || c.Flavor == FlavorScriptWindows) && c.DOSBoxInfo != nil
+}
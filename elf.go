@@ -1,23 +1,35 @@
 package dash
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
+	"math"
 	"regexp"
+	"strconv"
+	"strings"
 
-	"github.com/itchio/spellbook"
-	"github.com/itchio/wizardry/wizardry/wizutil"
+	"github.com/pkg/errors"
 )
 
 var libraryPattern = regexp.MustCompile(`\.so(\.[0-9]+)*$`)
 
+// AppImages are ELF executables with a filesystem image appended.
+// They're marked by 'AI' followed by the AppImage type (1 or 2) at
+// offset 8, in the padding of the ELF identification bytes.
+// cf. https://github.com/AppImage/AppImageSpec
+var appImageMagics = [][]byte{
+	{0x41, 0x49, 0x01},
+	{0x41, 0x49, 0x02},
+}
+
 func sniffELF(r io.ReadSeeker, name string, size int64) (*Candidate, error) {
 	if libraryPattern.MatchString(name) {
 		// libraries (.so files) are not launch candidates
 		return nil, nil
 	}
 
-	sr := wizutil.NewSliceReader(&readerAtFromSeeker{r}, 0, size)
-	spell := spellbook.Identify(sr, 0)
+	spell := identifySpell(r, size)
 
 	if !spellHas(spell, "ELF") {
 		// looked like ELF but isn't? weird
@@ -29,15 +41,437 @@ func sniffELF(r io.ReadSeeker, name string, size int64) (*Candidate, error) {
 	// can be marked as shared objects as well (node-webkit) for example.
 
 	result := &Candidate{
-		Flavor: FlavorNativeLinux,
-		Spell:  spell,
+		Flavor:    FlavorNativeLinux,
+		Spell:     spell,
+		LinuxInfo: &LinuxInfo{},
+	}
+
+	switch readELFMachine(r) {
+	case elfMachineAArch64:
+		result.Arch = ArchArm64
+	case elfMachineARM:
+		result.Arch = ArchArm
+	default:
+		if spellHas(spell, "32-bit") {
+			result.Arch = Arch386
+		} else if spellHas(spell, "64-bit") {
+			result.Arch = ArchAmd64
+		}
+	}
+
+	readELFIdent(r, result.LinuxInfo)
+	corrupt, err := isTruncatedELF(r, size, result.LinuxInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking for truncation")
 	}
+	result.Corrupt = corrupt
+	result.LinuxInfo.StaticallyLinked = isStaticELF(r, result.LinuxInfo)
 
-	if spellHas(spell, "32-bit") {
-		result.Arch = Arch386
-	} else if spellHas(spell, "64-bit") {
-		result.Arch = ArchAmd64
+	for _, magic := range appImageMagics {
+		if hasMagicAt(r, 8, magic) {
+			result.LinuxInfo.AppImage = true
+			break
+		}
 	}
 
 	return result, nil
 }
+
+// readELFIdent fills in the class, byte order and ABI of an ELF
+// executable from its identification bytes, if they can be read.
+func readELFIdent(r io.ReadSeeker, info *LinuxInfo) {
+	// EI_CLASS, EI_DATA, EI_VERSION, then EI_OSABI
+	ident, err := readBytesAt(r, 4, 4)
+	if err != nil {
+		return
+	}
+
+	switch ident[0] {
+	case 1:
+		info.ELFClass = 32
+	case 2:
+		info.ELFClass = 64
+	}
+
+	switch ident[1] {
+	case 1:
+		info.ELFData = ELFDataLittleEndian
+	case 2:
+		info.ELFData = ELFDataBigEndian
+	}
+
+	info.OSABI = ident[3]
+}
+
+const (
+	// program header types
+	elfProgramDynamic = 2
+	elfProgramInterp  = 3
+
+	// sizes of the ELF header itself
+	elfHeaderSize32 = 52
+	elfHeaderSize64 = 64
+
+	// real executables have a dozen program headers or so, and entries
+	// are 32 (ELF32) or 56 (ELF64) bytes long
+	elfMaxProgramHeaders    = 256
+	elfMaxProgramHeaderSize = 128
+)
+
+// isStaticELF returns true if an ELF executable has program headers, and
+// none of them ask for an interpreter (the dynamic loader) or dynamic
+// linking. Only the program headers are read, and anything odd about them
+// makes it return false. It relies on the class and byte order found by
+// readELFIdent.
+func isStaticELF(r io.ReadSeeker, info *LinuxInfo) bool {
+	var order binary.ByteOrder = binary.LittleEndian
+	if info.ELFData == ELFDataBigEndian {
+		order = binary.BigEndian
+	}
+
+	// e_phoff, then e_phentsize and e_phnum
+	var phOffset uint64
+	var sizes []byte
+	switch info.ELFClass {
+	case 32:
+		buf, err := readBytesAt(r, 0x1C, 4)
+		if err != nil {
+			return false
+		}
+		phOffset = uint64(order.Uint32(buf))
+		sizes, err = readBytesAt(r, 0x2A, 4)
+		if err != nil {
+			return false
+		}
+	case 64:
+		buf, err := readBytesAt(r, 0x20, 8)
+		if err != nil {
+			return false
+		}
+		phOffset = order.Uint64(buf)
+		sizes, err = readBytesAt(r, 0x36, 4)
+		if err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	entrySize := int(order.Uint16(sizes[0:2]))
+	numEntries := int(order.Uint16(sizes[2:4]))
+	if numEntries == 0 || numEntries > elfMaxProgramHeaders || entrySize < 4 || entrySize > elfMaxProgramHeaderSize || phOffset > math.MaxInt32 {
+		return false
+	}
+
+	headers, err := readBytesAt(r, int64(phOffset), numEntries*entrySize)
+	if err != nil {
+		return false
+	}
+
+	for i := 0; i < numEntries; i++ {
+		switch order.Uint32(headers[i*entrySize:]) {
+		case elfProgramDynamic, elfProgramInterp:
+			return false
+		}
+	}
+	return true
+}
+
+// isTruncatedELF returns true if an ELF executable is shorter than its
+// header, or than its program header table, which the loader needs to
+// run it. Sections aren't checked: they're often at the end of the file,
+// and executables run without them. It relies on the class and byte
+// order found by readELFIdent. Errors are real read errors, truncation
+// isn't one.
+func isTruncatedELF(r io.ReadSeeker, size int64, info *LinuxInfo) (bool, error) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if info.ELFData == ELFDataBigEndian {
+		order = binary.BigEndian
+	}
+
+	// e_phoff, then e_phentsize and e_phnum, which all fit in the header
+	var phOffset uint64
+	var sizes []byte
+	switch info.ELFClass {
+	case 32:
+		if size < elfHeaderSize32 {
+			return true, nil
+		}
+		buf, err := readBytesAt(r, 0x1C, 4)
+		if err != nil {
+			return false, err
+		}
+		phOffset = uint64(order.Uint32(buf))
+		sizes, err = readBytesAt(r, 0x2A, 4)
+		if err != nil {
+			return false, err
+		}
+	case 64:
+		if size < elfHeaderSize64 {
+			return true, nil
+		}
+		buf, err := readBytesAt(r, 0x20, 8)
+		if err != nil {
+			return false, err
+		}
+		phOffset = order.Uint64(buf)
+		sizes, err = readBytesAt(r, 0x36, 4)
+		if err != nil {
+			return false, err
+		}
+	default:
+		// unknown class, can't tell
+		return false, nil
+	}
+
+	entrySize := uint64(order.Uint16(sizes[0:2]))
+	numEntries := uint64(order.Uint16(sizes[2:4]))
+	if phOffset > uint64(size) {
+		return true, nil
+	}
+	return entrySize*numEntries > uint64(size)-phOffset, nil
+}
+
+const (
+	// type of the section that lists the symbol versions an executable
+	// needs from its libraries (.gnu.version_r)
+	elfSectionGNUVerneed = 0x6FFFFFFE
+
+	// section headers are 40 (ELF32) or 64 (ELF64) bytes long. real
+	// executables have a few dozen sections, and need versions from a
+	// handful of libraries.
+	elfMaxSections          = 1024
+	elfMaxSectionHeaderSize = 128
+	elfMaxVerneedSize       = 64 * 1024
+	elfMaxVersionNeeds      = 1024
+	// longest version name we read from the string table
+	elfMaxVersionNameLength = 64
+
+	glibcVersionPrefix = "GLIBC_"
+)
+
+// readELFGlibcVersion returns the highest glibc version (like "2.17") an
+// ELF executable needs, from the GLIBC_x.yy versions of the symbols it
+// imports, as listed in its .gnu.version_r section. That section survives
+// stripping, but executables without section headers, statically-linked
+// ones and those that don't use glibc don't have one: it returns an empty
+// string for those, and for anything odd about the section. Reads are
+// bounded. It relies on the class and byte order found by readELFIdent.
+func readELFGlibcVersion(r io.ReadSeeker, info *LinuxInfo) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if info.ELFData == ELFDataBigEndian {
+		order = binary.BigEndian
+	}
+
+	// e_shoff, then e_shentsize and e_shnum
+	var shOffset uint64
+	var sizes []byte
+	var minEntrySize int
+	switch info.ELFClass {
+	case 32:
+		buf, err := readBytesAt(r, 0x20, 4)
+		if err != nil {
+			return ""
+		}
+		shOffset = uint64(order.Uint32(buf))
+		sizes, err = readBytesAt(r, 0x2E, 4)
+		if err != nil {
+			return ""
+		}
+		minEntrySize = 40
+	case 64:
+		buf, err := readBytesAt(r, 0x28, 8)
+		if err != nil {
+			return ""
+		}
+		shOffset = order.Uint64(buf)
+		sizes, err = readBytesAt(r, 0x3A, 4)
+		if err != nil {
+			return ""
+		}
+		minEntrySize = 64
+	default:
+		return ""
+	}
+
+	entrySize := int(order.Uint16(sizes[0:2]))
+	numEntries := int(order.Uint16(sizes[2:4]))
+	if numEntries == 0 || numEntries > elfMaxSections || entrySize < minEntrySize || entrySize > elfMaxSectionHeaderSize || shOffset > math.MaxInt32 {
+		return ""
+	}
+
+	headers, err := readBytesAt(r, int64(shOffset), numEntries*entrySize)
+	if err != nil {
+		return ""
+	}
+
+	// sh_offset, sh_size and sh_link of a section
+	section := func(i int) (offset uint64, size uint64, link int) {
+		h := headers[i*entrySize:]
+		if info.ELFClass == 32 {
+			return uint64(order.Uint32(h[16:])), uint64(order.Uint32(h[20:])), int(order.Uint32(h[24:]))
+		}
+		return order.Uint64(h[24:]), order.Uint64(h[32:]), int(order.Uint32(h[40:]))
+	}
+
+	for i := 0; i < numEntries; i++ {
+		if order.Uint32(headers[i*entrySize+4:]) != elfSectionGNUVerneed {
+			continue
+		}
+
+		offset, size, link := section(i)
+		if size > elfMaxVerneedSize || offset > math.MaxInt32 || link <= 0 || link >= numEntries {
+			return ""
+		}
+		verneed, err := readBytesAt(r, int64(offset), int(size))
+		if err != nil {
+			return ""
+		}
+		strOffset, strSize, _ := section(link)
+
+		var best string
+		for _, name := range readELFVersionNeeds(r, order, verneed, strOffset, strSize) {
+			if !strings.HasPrefix(name, glibcVersionPrefix) {
+				continue
+			}
+			version := strings.TrimPrefix(name, glibcVersionPrefix)
+			if parseVersion(version) == nil {
+				// GLIBC_PRIVATE, for example
+				continue
+			}
+			if best == "" || compareVersions(version, best) > 0 {
+				best = version
+			}
+		}
+		return best
+	}
+	return ""
+}
+
+// readELFVersionNeeds returns the names of the versions listed in the
+// contents of a .gnu.version_r section, looked up in the string table at
+// strOffset. Entries are the same size for ELF32 and ELF64.
+func readELFVersionNeeds(r io.ReadSeeker, order binary.ByteOrder, verneed []byte, strOffset uint64, strSize uint64) []string {
+	var names []string
+	readName := func(nameOffset uint64) (string, bool) {
+		if nameOffset >= strSize || strOffset+nameOffset > math.MaxInt32 {
+			return "", false
+		}
+		n := strSize - nameOffset
+		if n > elfMaxVersionNameLength {
+			n = elfMaxVersionNameLength
+		}
+		buf, err := readBytesAt(r, int64(strOffset+nameOffset), int(n))
+		if err != nil {
+			return "", false
+		}
+		if i := bytes.IndexByte(buf, 0); i >= 0 {
+			buf = buf[:i]
+		}
+		return string(buf), true
+	}
+
+	// Elf_Verneed: vn_version, vn_cnt, vn_file, vn_aux, vn_next
+	// Elf_Vernaux: vna_hash, vna_flags, vna_other, vna_name, vna_next
+	var offset uint64
+	for i := 0; i < elfMaxVersionNeeds; i++ {
+		if offset+16 > uint64(len(verneed)) {
+			break
+		}
+		entry := verneed[offset:]
+		count := int(order.Uint16(entry[2:]))
+		auxOffset := offset + uint64(order.Uint32(entry[8:]))
+		for j := 0; j < count && len(names) < elfMaxVersionNeeds; j++ {
+			if auxOffset+16 > uint64(len(verneed)) {
+				break
+			}
+			aux := verneed[auxOffset:]
+			if name, ok := readName(uint64(order.Uint32(aux[8:]))); ok {
+				names = append(names, name)
+			}
+			next := uint64(order.Uint32(aux[12:]))
+			if next == 0 {
+				break
+			}
+			auxOffset += next
+		}
+
+		next := uint64(order.Uint32(entry[12:]))
+		if next == 0 {
+			break
+		}
+		offset += next
+	}
+	return names
+}
+
+// parseVersion parses a dotted version like "2.17" or "2.2.5" into its
+// components, or returns nil if it isn't one.
+func parseVersion(version string) []int {
+	parts := strings.Split(version, ".")
+	res := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil
+		}
+		res[i] = n
+	}
+	return res
+}
+
+// compareVersions compares two dotted versions numerically, so that
+// "2.17" is above "2.2.5". It returns a negative number, zero or a positive
+// number if a is lower, equal to, or higher than b. Missing components
+// count as zero, and versions that can't be parsed are the lowest.
+func compareVersions(a, b string) int {
+	pa, pb := parseVersion(a), parseVersion(b)
+	switch {
+	case pa == nil && pb == nil:
+		return 0
+	case pa == nil:
+		return -1
+	case pb == nil:
+		return 1
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+const (
+	elfMachineARM     = 0x28
+	elfMachineAArch64 = 0xB7
+)
+
+// readELFMachine returns the e_machine field of an ELF header,
+// or 0 if it can't be read.
+func readELFMachine(r io.ReadSeeker) uint16 {
+	// EI_DATA, at offset 5, tells us the endianness of the file
+	// e_machine is a 16-bit value at offset 18, for both ELF32 and ELF64
+	ident, err := readBytesAt(r, 5, 1)
+	if err != nil {
+		return 0
+	}
+
+	buf, err := readBytesAt(r, 18, 2)
+	if err != nil {
+		return 0
+	}
+
+	if ident[0] == 2 {
+		return binary.BigEndian.Uint16(buf)
+	}
+	return binary.LittleEndian.Uint16(buf)
+}
@@ -0,0 +1,42 @@
+package dash
+
+import (
+	"path"
+	"strings"
+)
+
+// embeddedHelperPrefixes lists the folders in which other candidates keep
+// their helper executables: Contents/Frameworks for app bundles, and the
+// resources folder next to NW.js and Electron executables. Prefixes are
+// normalized, see normalizeBundlePath.
+func embeddedHelperPrefixes(candidates []*Candidate) []string {
+	var prefixes []string
+	for _, c := range candidates {
+		switch c.Flavor {
+		case FlavorAppMacos:
+			prefixes = append(prefixes, path.Join(normalizeBundlePath(c.Path), "contents", "frameworks")+"/")
+		case FlavorNWjs, FlavorElectron:
+			prefixes = append(prefixes, path.Join(path.Dir(normalizeBundlePath(c.Path)), "resources")+"/")
+		}
+	}
+	return prefixes
+}
+
+// isEmbeddedHelper returns true if a candidate lives in one of the
+// folders returned by embeddedHelperPrefixes
+func isEmbeddedHelper(prefixes []string, c *Candidate) bool {
+	candidatePath := normalizeBundlePath(c.Path)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(candidatePath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBundlePath returns a lower-case, slash-separated, clean version
+// of a candidate path, so bundle paths can be compared no matter how
+// they were spelled.
+func normalizeBundlePath(p string) string {
+	return strings.ToLower(path.Clean(strings.Replace(p, "\\", "/", -1)))
+}
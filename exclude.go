@@ -0,0 +1,37 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// compileExcludeGlobs lowercases globs and makes sure they're well-formed
+func compileExcludeGlobs(globs []string) ([]string, error) {
+	var res []string
+	for _, glob := range globs {
+		lowerGlob := strings.ToLower(glob)
+		if _, err := path.Match(lowerGlob, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid exclude glob %q", glob)
+		}
+		res = append(res, strings.TrimSuffix(lowerGlob, "/"))
+	}
+	return res, nil
+}
+
+// isExcluded returns true if a slash-separated path, or any of the
+// folders leading up to it, matches one of the (lowercased) globs, so
+// that `redist` or `redist/*` exclude everything in `redist/`.
+func isExcluded(globs []string, p string) bool {
+	lowerPath := strings.ToLower(p)
+	for lowerPath != "." && lowerPath != "/" && lowerPath != "" {
+		for _, glob := range globs {
+			if matched, _ := path.Match(glob, lowerPath); matched {
+				return true
+			}
+		}
+		lowerPath = path.Dir(lowerPath)
+	}
+	return false
+}
@@ -1,14 +1,28 @@
 package dash
 
 import (
+	"encoding/binary"
 	"io"
 
 	"github.com/itchio/spellbook"
 	"github.com/itchio/wizardry/wizardry/wizutil"
 )
 
+// Mach-O CPU types, cf. <mach/machine.h>
+const (
+	machoCPUArchABI64  = 0x01000000
+	machoCPUTypeX86    = 7
+	machoCPUTypeARM    = 12
+	machoCPUTypeX86_64 = machoCPUTypeX86 | machoCPUArchABI64
+	machoCPUTypeARM64  = machoCPUTypeARM | machoCPUArchABI64
+)
+
+// Fat binaries have a slice per architecture, tools that read them
+// give up well before this many
+const machoMaxFatArchs = 32
+
 func sniffFatMach(r io.ReadSeeker, size int64) (*Candidate, error) {
-	ra := &readerAtFromSeeker{r}
+	ra := readerAt(r)
 
 	sr := wizutil.NewSliceReader(ra, 0, size)
 	spell := spellbook.Identify(sr, 0)
@@ -18,8 +32,85 @@ func sniffFatMach(r io.ReadSeeker, size int64) (*Candidate, error) {
 		return nil, nil
 	}
 
-	return &Candidate{
-		Flavor: FlavorNativeMacos,
-		Spell:  spell,
-	}, nil
+	result := &Candidate{
+		Flavor:    FlavorNativeMacos,
+		Spell:     spell,
+		MacosInfo: &MacosInfo{},
+	}
+
+	archs, minVersion, ok := readFatArchs(r)
+	if !ok {
+		// way too many slices, that's not a fat binary
+		return nil, nil
+	}
+	result.MacosInfo.Archs = archs
+	if len(archs) == 1 {
+		result.Arch = archs[0]
+	}
+	result.MinOSVersion = formatMachOVersion(minVersion)
+
+	return result, nil
+}
+
+// machoArch maps a Mach-O CPU type to an Arch, or returns
+// an empty string for architectures we don't care about (PowerPC, etc.)
+func machoArch(cpuType uint32) Arch {
+	switch cpuType {
+	case machoCPUTypeX86:
+		return Arch386
+	case machoCPUTypeX86_64:
+		return ArchAmd64
+	case machoCPUTypeARM:
+		return ArchArm
+	case machoCPUTypeARM64:
+		return ArchArm64
+	}
+	return ""
+}
+
+// readFatArchs returns the architectures of the slices contained in a fat
+// Mach-O binary, along with the lowest minimum macOS version of the slices
+// that name one (see readMachOMinVersion). It returns false if the header
+// lists more slices than any fat binary has. All fields of the fat header
+// are big-endian.
+func readFatArchs(r io.ReadSeeker) ([]Arch, uint32, bool) {
+	header, err := readBytesAt(r, 0, 8)
+	if err != nil {
+		return nil, 0, true
+	}
+
+	const fatArchSize = 20
+	numArchs := binary.BigEndian.Uint32(header[4:8])
+	if numArchs > machoMaxFatArchs {
+		return nil, 0, false
+	}
+
+	var archs []Arch
+	var minVersion uint32
+	for i := uint32(0); i < numArchs; i++ {
+		entry, err := readBytesAt(r, 8+int64(i)*fatArchSize, fatArchSize)
+		if err != nil {
+			// truncated, keep what we've got so far
+			break
+		}
+
+		if arch := machoArch(binary.BigEndian.Uint32(entry[0:4])); arch != "" && !containsArch(archs, arch) {
+			archs = append(archs, arch)
+		}
+
+		offset := int64(binary.BigEndian.Uint32(entry[8:12]))
+		if version := readMachOMinVersion(r, offset); version != 0 && (minVersion == 0 || version < minVersion) {
+			minVersion = version
+		}
+	}
+	return archs, minVersion, true
+}
+
+func containsArch(archs []Arch, arch Arch) bool {
+	for _, a := range archs {
+		if a == arch {
+			return true
+		}
+	}
+	return false
 }
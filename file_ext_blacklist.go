@@ -128,6 +128,7 @@ var fileExtBlacklist map[string]struct{} = map[string]struct{}{
 	".pxd":      struct{}{},
 	".exr":      struct{}{},
 	".unityweb": struct{}{},
+	".xnb":      struct{}{}, // XNA / MonoGame content
 
 	// debug symbols
 	".pdb": struct{}{},
@@ -163,6 +164,12 @@ var fileExtBlacklist map[string]struct{} = map[string]struct{}{
 	".vtf": struct{}{}, // valve texture format
 	".nav": struct{}{}, // nav meshes
 
+	// gamemaker data files
+	".win":   struct{}{},
+	".unx":   struct{}{},
+	".ios":   struct{}{},
+	".droid": struct{}{},
+
 	// libraries
 	".dll":   struct{}{},
 	".ndll":  struct{}{}, // Haxe/Neko stuff
@@ -207,10 +214,25 @@ var fileExtBlacklist map[string]struct{} = map[string]struct{}{
 	".info":     struct{}{},
 
 	// html
-	".css": struct{}{},
+	".css":  struct{}{},
+	".wasm": struct{}{}, // looked for next to HTML candidates instead
+
+	// AppImage update metadata, and the copies zsync leaves behind
+	".zsync":  struct{}{},
+	".zs-old": struct{}{},
 
 	// flash
 	".swf": struct{}{},
+
+	// console ROMs and disc images, see rom.go (.bin files can be
+	// linux executables, so they're still sniffed)
+	".iso": struct{}{},
+	".cue": struct{}{},
+	".nes": struct{}{},
+	".gb":  struct{}{},
+	".gbc": struct{}{},
+	".gba": struct{}{},
+	".nds": struct{}{},
 }
 
 var soRegexp = regexp.MustCompile(`(?i)\.so(\.[0-9]+)*$`)
@@ -218,7 +240,7 @@ var soRegexp = regexp.MustCompile(`(?i)\.so(\.[0-9]+)*$`)
 // Note: ext must be lower-case, and include the dot,
 // so it could be ".swf", or "" - see the blacklist map definition
 func isBlacklistedExt(name string) bool {
-	if _, ok := fileExtBlacklist[getExt(name)]; ok {
+	if _, ok := fileExtBlacklist[GetExt(name)]; ok {
 		return true
 	}
 
@@ -15,7 +15,11 @@ func Test_Blacklist(t *testing.T) {
 	assert.False(isBlacklistedExt("game/game.x86_64"))
 
 	assert.True(isBlacklistedExt("game/maps/random.umap"))
+	assert.True(isBlacklistedExt("game/data.win"))
+	assert.True(isBlacklistedExt("game/assets/game.unx"))
 	assert.True(isBlacklistedExt("libs/x86_64/libSDL.so"))
 	assert.True(isBlacklistedExt("libs/x86_64/libSDL.so.2"))
 	assert.True(isBlacklistedExt("libs/x86_64/libSDL.so.2.0.0"))
+	assert.True(isBlacklistedExt("roms/game.iso"))
+	assert.False(isBlacklistedExt("game/game.bin"))
 }
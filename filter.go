@@ -0,0 +1,1135 @@
+package dash
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itchio/headway/state"
+)
+
+type biggestFirst struct {
+	candidates []*Candidate
+}
+
+var _ sort.Interface = (*biggestFirst)(nil)
+
+func (bf *biggestFirst) Len() int {
+	return len(bf.candidates)
+}
+
+func (bf *biggestFirst) Less(i, j int) bool {
+	return bf.candidates[i].Size > bf.candidates[j].Size
+}
+
+func (bf *biggestFirst) Swap(i, j int) {
+	bf.candidates[i], bf.candidates[j] = bf.candidates[j], bf.candidates[i]
+}
+
+type biggestScoredFirst struct {
+	candidates []ScoredCandidate
+}
+
+var _ sort.Interface = (*biggestScoredFirst)(nil)
+
+func (bsf *biggestScoredFirst) Len() int {
+	return len(bsf.candidates)
+}
+
+func (bsf *biggestScoredFirst) Less(i, j int) bool {
+	return bsf.candidates[i].Candidate.Size > bsf.candidates[j].Candidate.Size
+}
+
+func (bsf *biggestScoredFirst) Swap(i, j int) {
+	bsf.candidates[i], bsf.candidates[j] = bsf.candidates[j], bsf.candidates[i]
+}
+
+type HighestScoreFirst struct {
+	candidates []ScoredCandidate
+}
+
+var _ sort.Interface = (*HighestScoreFirst)(nil)
+
+func (hsf *HighestScoreFirst) Len() int {
+	return len(hsf.candidates)
+}
+
+func (hsf *HighestScoreFirst) Less(i, j int) bool {
+	return hsf.candidates[i].Score > hsf.candidates[j].Score
+}
+
+func (hsf *HighestScoreFirst) Swap(i, j int) {
+	hsf.candidates[i], hsf.candidates[j] = hsf.candidates[j], hsf.candidates[i]
+}
+
+// A BlacklistEntry penalizes candidates whose path matches a pattern
+type BlacklistEntry struct {
+	pattern *regexp.Regexp
+	penalty Penalty
+}
+
+// NewBlacklistEntry returns an entry that applies penalty to candidates
+// whose path matches pattern. Paths are relative to the verdict's base path,
+// and always use forward slashes.
+func NewBlacklistEntry(pattern *regexp.Regexp, penalty Penalty) BlacklistEntry {
+	return BlacklistEntry{pattern, penalty}
+}
+
+type PenaltyKind int
+
+const (
+	PenaltyExclude PenaltyKind = iota
+	PenaltyScore
+)
+
+type Penalty struct {
+	kind  PenaltyKind
+	delta int64
+}
+
+// ScorePenalty returns a penalty that subtracts delta from a candidate's score
+// (which starts at 100).
+func ScorePenalty(delta int64) Penalty {
+	return Penalty{PenaltyScore, delta}
+}
+
+// ExcludePenalty returns a penalty that brings a candidate's score down to 0,
+// excluding it.
+func ExcludePenalty() Penalty {
+	return Penalty{PenaltyExclude, 0}
+}
+
+var blacklist = []BlacklistEntry{
+	// Penalties
+	{regexp.MustCompile(`(?i)unins.*\.exe$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)kick\.bin$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)\.vshost\.exe$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)nacl_helper`), Penalty{PenaltyScore, 20}},
+	{regexp.MustCompile(`(?i)nwjc\.exe$`), Penalty{PenaltyScore, 20}},
+	{regexp.MustCompile(`(?i)crashpad_handler(\.exe)?$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)[^/]*helper[^/]*\.app(/|$)`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)(^|[/ ._(-])(gpu|renderer)([ ._)-][^/]*)?$`), Penalty{PenaltyScore, 30}},
+	{regexp.MustCompile(`(?i)chrome[-_]sandbox$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)(^|/)adl(64)?(\.exe)?$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)(^|/)adobe air(\.framework)?/`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)flixel\.exe$`), Penalty{PenaltyScore, 20}},
+	// patchers, but not games named like `Patchwork.exe`
+	{regexp.MustCompile(`(?i)(^|[/ ._-])patch(er)?([ ._-][^/]*)?\.exe$`), Penalty{PenaltyScore, 50}},
+	// stale copies like `game.old`, `game_old.exe` or `Game.exe.bak`
+	{regexp.MustCompile(`(?i)[._](old|bak|orig)(\.exe)?$`), Penalty{PenaltyScore, 30}},
+	// Unreal Engine helpers, and command-line builds of the game
+	{regexp.MustCompile(`(?i)(^|/)(crashreportclient|unrealcefsubprocess)(\.exe)?$`), Penalty{PenaltyScore, 50}},
+	{regexp.MustCompile(`(?i)-cmd(\.exe)?$`), Penalty{PenaltyScore, 50}},
+	// Steam client helpers, sometimes shipped by accident
+	{regexp.MustCompile(`(?i)(^|/)(steam|steamservice|steamwebhelper|steamcmd|steamerrorreporter(64)?|gameoverlayui)\.exe$`), Penalty{PenaltyScore, 50}},
+
+	// Excludes
+	{regexp.MustCompile(`(?i)\.(so|dylib)$`), Penalty{PenaltyExclude, 0}},
+	{regexp.MustCompile(`(?i)dxwebsetup\.exe$`), Penalty{PenaltyExclude, 0}},
+	{regexp.MustCompile(`(?i)vcredist.*\.exe$`), Penalty{PenaltyExclude, 0}},
+	{regexp.MustCompile(`(?i)unitycrashhandler.*\.exe$`), Penalty{PenaltyExclude, 0}},
+	{regexp.MustCompile(`(?i)patch ?maker.*\.exe$`), Penalty{PenaltyExclude, 0}},
+}
+
+type FilterParams struct {
+	// OS is a shorthand for OSes, when filtering for a single OS
+	OS string
+	// OSes keeps candidates that run on any of the given operating
+	// systems ("windows", "linux", "darwin", or OSWeb). When set, OS is ignored.
+	OSes []string
+	Arch string
+
+	// ExtraPenalties are applied when scoring candidates, after the built-in
+	// blacklist. Entries are applied in order, so a score penalty that comes
+	// after an exclusion still brings the score further down.
+	ExtraPenalties []BlacklistEntry
+
+	// FlavorWeights are added to the base score of candidates of a given
+	// flavor, before penalties apply. Flavors that have a weight are no
+	// longer excluded by the "loses if there's anything else" rules (HTML,
+	// archives, jars): their score decides instead.
+	FlavorWeights map[Flavor]int64
+
+	// KeepAll keeps every compatible candidate instead of pruning them
+	// down to the best ones. Candidates that would have been pruned come
+	// after the others, best-first, so the first candidate is always the
+	// same as without KeepAll.
+	KeepAll bool
+
+	// LauncherNames are the names (case-insensitive, extension excluded) of
+	// hand-written scripts that usually start a game, like `start.sh`. When
+	// there's more than one launcher script, those are kept alongside
+	// native executables and get LauncherBonus, so they outrank the binary
+	// they wrap. A nil value means DefaultLauncherNames, an empty one
+	// disables the bonus.
+	LauncherNames []string
+	// LauncherBonus is added to the score of scripts that match
+	// LauncherNames. Zero means DefaultLauncherBonus.
+	LauncherBonus int64
+
+	// ProbeTimeout limits how long Filter spends inspecting each windows
+	// executable for installer traits. Executables that take longer are
+	// kept, as if they couldn't be inspected. Zero means no limit.
+	ProbeTimeout time.Duration
+
+	// AllowInstallers keeps windows installers and self-extracting script
+	// installers (makeself, MojoSetup), which are normally excluded when
+	// there's anything else: no installer type check, and no inspection of
+	// executables for installer traits.
+	AllowInstallers bool
+
+	// PreferFlavors, when non-nil, replaces the built-in preferences between
+	// flavors: the first flavor in the list that has candidates wins. See
+	// FlavorPriority for the default order.
+	PreferFlavors []Flavor
+
+	// MinCandidateSize is the size, in bytes, under which native executables
+	// look like stubs (launchers, uninstallers, helpers) rather than games.
+	// Those get a score penalty that grows with how far below the threshold
+	// they are, and don't keep bigger candidates out because they're
+	// shallower. They still win if there's nothing else. Zero disables it.
+	MinCandidateSize int64
+
+	// GlibcVersion is the glibc version of the system candidates are meant
+	// to run on, like "2.27". Linux executables that need a newer one (see
+	// LinuxInfo.MinGlibcVersion, looked up with ConfigureParams.DeepELF)
+	// aren't excluded, since there may be nothing else to run, but Filter
+	// logs a warning for those it picks, so players on older distributions
+	// can be told. Empty means no check.
+	GlibcVersion string
+}
+
+// OSWeb is an OS filter for launching in a browser: native candidates are
+// excluded, and love.js exports win over the .love bundle they came from.
+const OSWeb = "web"
+
+// DefaultLauncherNames are used when FilterParams.LauncherNames is nil
+var DefaultLauncherNames = []string{"start", "run", "play", "launch", "game"}
+
+// DefaultLauncherBonus is used when FilterParams.LauncherBonus is zero
+const DefaultLauncherBonus int64 = 20
+
+func (params FilterParams) launcherNames() []string {
+	if params.LauncherNames == nil {
+		return DefaultLauncherNames
+	}
+	return params.LauncherNames
+}
+
+// isUndersized returns true for native candidates smaller than
+// MinCandidateSize
+func (params FilterParams) isUndersized(c *Candidate) bool {
+	return params.MinCandidateSize > 0 && isNativeFlavor(c.Flavor) && c.Size < params.MinCandidateSize
+}
+
+func (params FilterParams) launcherBonus() int64 {
+	if params.LauncherBonus == 0 {
+		return DefaultLauncherBonus
+	}
+	return params.LauncherBonus
+}
+
+// FilteredCopy returns a new Verdict with only the candidates that pass
+// the filter, best first. OS and Arch may be empty strings.
+//
+// When filtering for several OSes, candidates are filtered for each OS
+// separately, then the survivors are ranked together, by score. Ties
+// are broken by the order of OSes.
+//
+// Unix executables that have their executable bit set get a small
+// bonus, so Filter should be called before FixPermissions, which clears
+// candidate modes.
+//
+// This Verdict is never modified: the result's candidates are deep copies,
+// so changing them (or calling FixPermissions on the result) doesn't affect
+// the candidates of this Verdict. The reasoning behind the result is
+// available from its ScoredCandidates method.
+func (v *Verdict) FilteredCopy(consumer *state.Consumer, params FilterParams) *Verdict {
+	res := v.filter(consumer, params)
+	if params.KeepAll {
+		res = res.keepAll(consumer, params)
+	}
+	res = res.cloneCandidates()
+	return &res
+}
+
+// Filter is like FilteredCopy, but returns a Verdict value.
+//
+// Deprecated: use FilteredCopy, which makes it clear that the result
+// is a copy, and that this Verdict isn't modified.
+func (v Verdict) Filter(consumer *state.Consumer, params FilterParams) Verdict {
+	return *v.FilteredCopy(consumer, params)
+}
+
+// HasCandidates returns true if the verdict has at least one candidate
+func (v Verdict) HasCandidates() bool {
+	return len(v.Candidates) > 0
+}
+
+// BestCandidate returns the top-ranked candidate, and false if there
+// are none. It's only meaningful on verdicts returned by Filter: Configure
+// lists candidates in the order it finds them, not best-first.
+func (v Verdict) BestCandidate() (*Candidate, bool) {
+	if !v.HasCandidates() {
+		return nil, false
+	}
+	return v.Candidates[0], true
+}
+
+// bestPerPlatformOSes are the OSes BestPerPlatform picks candidates for
+var bestPerPlatformOSes = []string{"windows", "darwin", "linux"}
+
+// BestPerPlatform returns the best candidate for each of windows, darwin
+// and linux, as picked by FilteredCopy for that OS, with no arch preference.
+// Platforms without any runnable candidate are absent from the map.
+// It's only meaningful on verdicts returned by Configure.
+//
+// Windows executables are only inspected for installers when filtering
+// for windows, so that happens once no matter how many platforms there are.
+func (v Verdict) BestPerPlatform(consumer *state.Consumer) map[string]*Candidate {
+	best := make(map[string]*Candidate)
+	for _, osFilter := range bestPerPlatformOSes {
+		filtered := v.FilteredCopy(consumer, FilterParams{OS: osFilter})
+		if c, ok := filtered.BestCandidate(); ok {
+			best[osFilter] = c
+		}
+	}
+	return best
+}
+
+func (v Verdict) filter(consumer *state.Consumer, params FilterParams) Verdict {
+	oses := params.OSes
+	if len(oses) == 0 {
+		return v.filterOS(consumer, params, params.OS)
+	}
+	if len(oses) == 1 {
+		return v.filterOS(consumer, params, oses[0])
+	}
+
+	var winners []ScoredCandidate
+	won := make(map[*Candidate]bool)
+	eliminated := make(map[*Candidate]ScoredCandidate)
+	// each pass starts from this verdict's warnings
+	warnings := newWarningLog(consumer, v.Warnings)
+
+	for _, osFilter := range oses {
+		filtered := v.filterOS(consumer, params, osFilter)
+		for _, w := range filtered.Warnings[len(v.Warnings):] {
+			warnings.add(w)
+		}
+		for _, sc := range filtered.scores {
+			if sc.EliminatedBy == "" {
+				if !won[sc.Candidate] {
+					won[sc.Candidate] = true
+					winners = append(winners, sc)
+				}
+				continue
+			}
+
+			// if a candidate is eliminated by several passes, the pass
+			// for its own OS has the most relevant explanation
+			if _, ok := eliminated[sc.Candidate]; !ok || candidateOS(sc.Candidate) == osFilter {
+				eliminated[sc.Candidate] = sc
+			}
+		}
+	}
+	sort.Stable(&HighestScoreFirst{winners})
+
+	candidates := v.Candidates
+	v.Candidates = nil
+	v.Warnings = warnings.warnings
+	v.scores = nil
+	for _, sc := range winners {
+		v.Candidates = append(v.Candidates, sc.Candidate)
+		v.scores = append(v.scores, sc)
+	}
+	for _, c := range candidates {
+		if sc, ok := eliminated[c]; ok && !won[c] {
+			v.scores = append(v.scores, sc)
+		}
+	}
+	return v
+}
+
+// keepAll adds the candidates that were pruned by the filter for reasons
+// other than compatibility back to a filtered verdict, after the ones
+// that made it through, best-first.
+func (v Verdict) keepAll(consumer *state.Consumer, params FilterParams) Verdict {
+	var pruned []ScoredCandidate
+	var incompatible []ScoredCandidate
+	for _, sc := range v.scores {
+		switch sc.EliminatedBy {
+		case "":
+			continue
+		case FilterStageCompatibility:
+			incompatible = append(incompatible, sc)
+		default:
+			// some were eliminated before scoring, score them all the same way
+			scored := scoreCandidate(consumer, params, sc.Candidate)
+			sc.Score = scored.Score
+			sc.Penalties = scored.Penalties
+			pruned = append(pruned, sc)
+		}
+	}
+
+	sort.Stable(&biggestScoredFirst{pruned})
+	sort.Stable(&HighestScoreFirst{pruned})
+
+	candidates := v.Candidates[:len(v.Candidates):len(v.Candidates)]
+	scores := v.scores[:len(v.Candidates):len(v.Candidates)]
+	for _, sc := range pruned {
+		candidates = append(candidates, sc.Candidate)
+		scores = append(scores, sc)
+	}
+	v.Candidates = candidates
+	v.scores = append(scores, incompatible...)
+	return v
+}
+
+// executableBitBonus is added to the score of unix executables that were
+// shipped with their executable bit set, it's a hint they're the intended
+// launcher. It's smaller than any penalty, so it only breaks ties.
+const executableBitBonus = 5
+
+// staticLinkingBonus is added to the score of statically-linked linux
+// executables, which don't depend on system libraries. It's only meant
+// to break ties.
+const staticLinkingBonus = 2
+
+// archSuffixBonus is added to the score of linux executables named after
+// the arch they're for, like Unity and Godot builds (`Game.x86_64`), when
+// it's the arch being filtered for. It's only meant to break ties.
+const archSuffixBonus = 3
+
+// steamAPIBonus is added to the score of windows executables that sit
+// next to the Steamworks API library, which are usually the game itself
+const steamAPIBonus = 2
+
+// undersizedMaxPenalty is taken off the score of an empty native executable
+// when FilterParams.MinCandidateSize is set. Executables closer to the
+// threshold get a proportionally smaller penalty.
+const undersizedMaxPenalty = 40
+
+// emulatedArchPenalty is taken off the score of x86 and x64 windows
+// executables when filtering for arm64, where they run emulated
+const emulatedArchPenalty = 10
+
+// scoreCandidate starts candidates at 100, adds bonuses, and applies
+// penalties: built-in rules first, then the caller's.
+func scoreCandidate(consumer *state.Consumer, params FilterParams, candidate *Candidate) ScoredCandidate {
+	var score int64 = 100
+	if weight, ok := params.FlavorWeights[candidate.Flavor]; ok {
+		consumer.Debugf("Weighing (%s) - %d for flavor %s", candidate.Path, weight, candidate.Flavor)
+		score += weight
+	}
+	if hasExecutableBit(candidate) {
+		consumer.Debugf("Favoring (%s) - %d for executable bit", candidate.Path, executableBitBonus)
+		score += executableBitBonus
+	}
+	if hasArchSuffix(candidate, params.Arch) {
+		consumer.Debugf("Favoring (%s) - %d for arch suffix", candidate.Path, archSuffixBonus)
+		score += archSuffixBonus
+	}
+	if candidate.LinuxInfo != nil && candidate.LinuxInfo.StaticallyLinked {
+		consumer.Debugf("Favoring (%s) - %d for static linking", candidate.Path, staticLinkingBonus)
+		score += staticLinkingBonus
+	}
+	if candidate.WindowsInfo != nil && candidate.WindowsInfo.SteamAPI {
+		consumer.Debugf("Favoring (%s) - %d for Steamworks API library", candidate.Path, steamAPIBonus)
+		score += steamAPIBonus
+	}
+	if isConventionalLauncher(params.launcherNames())(candidate) {
+		bonus := params.launcherBonus()
+		consumer.Debugf("Favoring (%s) - %d for launcher name", candidate.Path, bonus)
+		score += bonus
+	}
+	if params.Arch == "arm64" && candidateOS(candidate) == "windows" && (candidate.Arch == Arch386 || candidate.Arch == ArchAmd64) {
+		consumer.Debugf("Penalizing (%s) - %d for running emulated on arm64", candidate.Path, emulatedArchPenalty)
+		score -= emulatedArchPenalty
+	}
+	if params.isUndersized(candidate) {
+		missing := float64(params.MinCandidateSize-candidate.Size) / float64(params.MinCandidateSize)
+		penalty := int64(undersizedMaxPenalty * missing)
+		consumer.Debugf("Penalizing (%s) - %d for being under %d bytes", candidate.Path, penalty, params.MinCandidateSize)
+		score -= penalty
+	}
+	var applied []AppliedPenalty
+
+	apply := func(entry BlacklistEntry) {
+		if !entry.pattern.MatchString(candidate.Path) {
+			return
+		}
+
+		switch entry.penalty.kind {
+		case PenaltyScore:
+			consumer.Debugf("Penalizing (%s) - %d score penalty for pattern %q", candidate.Path, entry.penalty.delta, entry.pattern)
+			score -= entry.penalty.delta
+		case PenaltyExclude:
+			consumer.Debugf("0-scoring (%s) - penalty exclude for pattern %q", candidate.Path, entry.pattern)
+			score = 0
+		}
+		applied = append(applied, AppliedPenalty{
+			Pattern: entry.pattern.String(),
+			Kind:    entry.penalty.kind,
+			Delta:   entry.penalty.delta,
+		})
+	}
+
+	for _, entry := range blacklist {
+		apply(entry)
+	}
+	for _, entry := range params.ExtraPenalties {
+		apply(entry)
+	}
+
+	return ScoredCandidate{
+		Candidate: candidate,
+		Score:     score,
+		Penalties: applied,
+	}
+}
+
+// filterOS filters candidates for a single OS (which may be empty)
+func (v Verdict) filterOS(consumer *state.Consumer, params FilterParams, osFilter string) Verdict {
+	archFilter := params.Arch
+
+	hasOS := func(os string) bool {
+		return osFilter != "" && osFilter == os
+	}
+	excludesOS := func(os string) bool {
+		return osFilter != "" && osFilter != os
+	}
+	hasArch := func(arch string) bool {
+		return archFilter != "" && archFilter == arch
+	}
+
+	consumer.Debugf("Filtering %d candidates to os (%s), arch (%s)", len(v.Candidates), osFilter, archFilter)
+
+	tracker := newFilterTracker(consumer, v.Candidates)
+	warnings := newWarningLog(consumer, v.Warnings)
+
+	computeScore := func(candidate *Candidate) ScoredCandidate {
+		return scoreCandidate(consumer, params, candidate)
+	}
+
+	finish := func(stage FilterStage, winners []*Candidate, format string, args ...interface{}) Verdict {
+		if params.GlibcVersion != "" {
+			for _, c := range winners {
+				if c.LinuxInfo != nil && c.LinuxInfo.MinGlibcVersion != "" && compareVersions(c.LinuxInfo.MinGlibcVersion, params.GlibcVersion) > 0 {
+					warnings.warnf(c.Path, "(%s) needs glibc %s, but the system has %s, it may not run", c.Path, c.LinuxInfo.MinGlibcVersion, params.GlibcVersion)
+				}
+			}
+		}
+		v.Candidates = winners
+		v.Warnings = warnings.warnings
+		v.scores = tracker.finish(stage, winners, computeScore, format, args...)
+		return v
+	}
+
+	// preferHTML excludes the HTML candidates that don't match pred, if
+	// some of them do. what describes those that do, in the plural.
+	preferHTML := func(candidates []*Candidate, pred func(c *Candidate) bool, what string) []*Candidate {
+		htmlCandidates := selectByFlavor(candidates, FlavorHTML)
+		preferredCandidates := selectByFunc(htmlCandidates, pred)
+		if len(preferredCandidates) == 0 || len(preferredCandidates) == len(htmlCandidates) {
+			return candidates
+		}
+
+		consumer.Debugf("Found %d %s, excluding other HTML candidates", len(preferredCandidates), what)
+		return tracker.narrow(FilterStageFlavor, candidates, selectByFunc(candidates, func(c *Candidate) bool {
+			return c.Flavor != FlavorHTML || pred(c)
+		}), "HTML, but not one of the %s that were found", what)
+	}
+
+	var compatibleCandidates []*Candidate
+
+	// exclude things we can't run at all
+	for _, c := range v.Candidates {
+		keep := true
+		exclude := func(format string, args ...interface{}) {
+			tracker.eliminate(c, FilterStageCompatibility, format, args...)
+			keep = false
+		}
+
+		consumer.Debugf("Reviewing (%s) flavor %v", c.Path, c.Flavor)
+		if c.Flavor == FlavorMobile {
+			exclude("mobile package, never runs on desktop")
+		}
+		switch candidateOS(c) {
+		case "linux":
+			if excludesOS("linux") {
+				exclude("linux native, os filter is (%s)", osFilter)
+			}
+
+			if hasArch("386") && (c.Arch != "" && c.Arch != Arch386) {
+				exclude("not 32-bit, but arch filter is (%s)", archFilter)
+			}
+
+			if hasArch("amd64") && (c.Arch == ArchArm || c.Arch == ArchArm64) {
+				exclude("ARM, but arch filter is (%s)", archFilter)
+			}
+
+			if hasArch("arm64") && (c.Arch == Arch386 || c.Arch == ArchAmd64) {
+				exclude("x86, but arch filter is (%s)", archFilter)
+			}
+		case "windows":
+			// .NET assemblies that bring Mono along run on linux and macOS too
+			onMono := runsOnMono(c) && (hasOS("linux") || hasOS("darwin"))
+			if excludesOS("windows") && !onMono {
+				exclude("windows native, os filter is (%s)", osFilter)
+			}
+
+			if hasArch("386") && (c.Arch == ArchAmd64 || c.Arch == ArchArm || c.Arch == ArchArm64) {
+				exclude("not 32-bit x86, but arch filter is (%s)", archFilter)
+			}
+
+			if hasArch("amd64") && (c.Arch == ArchArm || c.Arch == ArchArm64) {
+				exclude("ARM, but arch filter is (%s)", archFilter)
+			}
+		case "darwin":
+			if excludesOS("darwin") {
+				exclude("darwin (macOS) native, os filter is (%s)", osFilter)
+			}
+
+			// arm64 macs can run x86 binaries, but intel macs can't run arm64 binaries
+			if hasArch("amd64") && hasArchSlice(c, ArchArm64) && !hasArchSlice(c, ArchAmd64) && !hasArchSlice(c, Arch386) {
+				exclude("arm64 only, but arch filter is (%s)", archFilter)
+			}
+		}
+
+		if keep {
+			compatibleCandidates = append(compatibleCandidates, c)
+		}
+	}
+	bestCandidates := compatibleCandidates
+
+	if len(bestCandidates) == 1 {
+		return finish(FilterStageCompatibility, bestCandidates, "single compatible candidate left")
+	}
+
+	// the stages from here to the depth stage apply no matter how deep
+	// candidates are, and with or without PreferFlavors.
+	//
+	// helpers embedded in an app bundle, or in the resources of an NW.js or
+	// Electron app, lose against the app itself
+	{
+		prefixes := embeddedHelperPrefixes(compatibleCandidates)
+		ownCandidates := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+			return !isEmbeddedHelper(prefixes, c)
+		})
+
+		if len(ownCandidates) < len(compatibleCandidates) {
+			consumer.Debugf("Found %d helpers embedded in other candidates, excluding them", len(compatibleCandidates)-len(ownCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, ownCandidates, "embedded in another candidate's bundle")
+		}
+	}
+
+	// Ren'Py launchers win over the executables bundled with them
+	{
+		renpyLaunchers := selectByFunc(compatibleCandidates, isRenpyRole(RenpyRoleLauncher))
+
+		if len(renpyLaunchers) > 0 {
+			consumer.Debugf("Found %d Ren'Py launchers, excluding bundled executables", len(renpyLaunchers))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+				return !isRenpyRole(RenpyRoleBundled)(c)
+			}), "bundled with a Ren'Py game, and some launchers were found")
+		}
+	}
+
+	// everywhere, the extracted contents of an AppImage (its AppRun and
+	// the binaries next to it) lose to the AppImage
+	if len(selectByFunc(compatibleCandidates, isAppImage)) > 0 {
+		prefixes := appDirPrefixes(compatibleCandidates)
+		if len(prefixes) > 0 {
+			packedCandidates := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+				return !isExtractedAppImage(prefixes, c)
+			})
+			if len(packedCandidates) < len(compatibleCandidates) {
+				consumer.Debugf("Found %d candidates extracted from an AppImage, excluding them", len(compatibleCandidates)-len(packedCandidates))
+				compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, packedCandidates, "extracted from an AppImage, and there's an AppImage")
+			}
+		}
+	}
+
+	// on linux, AppImages win
+	if hasOS("linux") {
+		appImageCandidates := selectByFunc(compatibleCandidates, isAppImage)
+
+		if len(appImageCandidates) > 0 {
+			consumer.Debugf("Found %d AppImage candidates, excluding all others", len(appImageCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, appImageCandidates, "not an AppImage")
+		}
+	}
+
+	// everywhere, corrupt candidates (truncated or damaged files) lose if
+	// there's anything else
+	{
+		corruptCandidates := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+			return c.Corrupt
+		})
+		if len(corruptCandidates) > 0 && len(corruptCandidates) < len(compatibleCandidates) {
+			consumer.Debugf("Has %d corrupt candidates, but %d other candidates - excluding corrupt candidates", len(corruptCandidates), len(compatibleCandidates)-len(corruptCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+				return !c.Corrupt
+			}), "corrupt, and there are other candidates")
+		}
+	}
+
+	// everywhere, self-extracting script installers lose if there's
+	// anything else
+	if !params.AllowInstallers {
+		isScriptInstaller := func(c *Candidate) bool {
+			return c.ScriptInfo != nil && c.ScriptInfo.Installer != ""
+		}
+		installerCandidates := selectByFunc(compatibleCandidates, isScriptInstaller)
+		if len(installerCandidates) > 0 && len(installerCandidates) < len(compatibleCandidates) {
+			consumer.Debugf("Has %d script installers, but %d other candidates - excluding script installers", len(installerCandidates), len(compatibleCandidates)-len(installerCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageInstaller, compatibleCandidates, selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+				return !isScriptInstaller(c)
+			}), "self-extracting installer, and there are other candidates")
+		}
+	}
+
+	// in DOSBox setups, scripts that start DOSBox win (they pass it the
+	// right config), then DOSBox itself
+	{
+		dosboxCandidates := selectByFunc(compatibleCandidates, isDOSBoxScript)
+		if len(dosboxCandidates) == 0 {
+			dosboxCandidates = selectByFlavor(compatibleCandidates, FlavorDOSBox)
+		}
+
+		if len(dosboxCandidates) > 0 {
+			consumer.Debugf("Found %d DOSBox launchers, excluding all others", len(dosboxCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, dosboxCandidates, "not a DOSBox launcher, and some were found")
+		}
+	}
+
+	// MS-DOS executables lose against anything else
+	{
+		modernCandidates := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+			return !isDOSExecutable(c)
+		})
+
+		if len(modernCandidates) > 0 && len(modernCandidates) < len(compatibleCandidates) {
+			consumer.Debugf("Found %d MS-DOS executables, excluding them", len(compatibleCandidates)-len(modernCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, modernCandidates, "MS-DOS executable")
+		}
+	}
+
+	// Unreal Engine games win over other native executables (the shipping
+	// binary the launcher starts, the crash reporter, prerequisites
+	// installers)
+	{
+		unrealCandidates := selectByFlavor(compatibleCandidates, FlavorUnreal)
+
+		if len(unrealCandidates) > 0 {
+			consumer.Debugf("Found %d Unreal Engine games, excluding other native executables", len(unrealCandidates))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+				return !isNativeFlavor(c.Flavor)
+			}), "native executable, and some Unreal Engine games were found")
+		}
+	}
+
+	// among HTML files, web app manifest start pages win, then Construct
+	// exports, Twine stories, and pages that ship with a WebAssembly module
+	compatibleCandidates = preferHTML(compatibleCandidates, isWebManifestStart, "HTML files started by web app manifests")
+	compatibleCandidates = preferHTML(compatibleCandidates, isConstructExport, "Construct exports")
+	compatibleCandidates = preferHTML(compatibleCandidates, isTwineStoryCandidate, "Twine stories")
+	compatibleCandidates = preferHTML(compatibleCandidates, hasWasmModule, "HTML files with WebAssembly modules")
+
+	// on windows, whatever a batch script launches doesn't count as a
+	// separate candidate
+	scriptLaunchers := make(map[*Candidate]bool)
+	if hasOS("windows") {
+		scriptCandidates := selectByFlavor(compatibleCandidates, FlavorScriptWindows)
+		launchedBy := func(c *Candidate) *Candidate {
+			for _, sc := range scriptCandidates {
+				if sc.ScriptTarget != "" && strings.EqualFold(sc.ScriptTarget, c.Path) {
+					return sc
+				}
+			}
+			return nil
+		}
+
+		var ownCandidates []*Candidate
+		for _, c := range compatibleCandidates {
+			if sc := launchedBy(c); sc != nil {
+				consumer.Debugf("Windows script (%s) launches (%s)", sc.Path, c.Path)
+				tracker.eliminate(c, FilterStageFlavor, "launched by windows script (%s)", sc.Path)
+				scriptLaunchers[sc] = true
+				continue
+			}
+			ownCandidates = append(ownCandidates, c)
+		}
+		compatibleCandidates = ownCandidates
+	}
+
+	// on linux, whatever a desktop entry launches wins
+	if hasOS("linux") {
+		entryTargets := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+			return c.DesktopEntry != ""
+		})
+		if len(entryTargets) > 0 && len(entryTargets) < len(compatibleCandidates) {
+			consumer.Debugf("Found %d candidates launched by desktop entries, excluding all others", len(entryTargets))
+			compatibleCandidates = tracker.narrow(FilterStageFlavor, compatibleCandidates, entryTargets, "not launched by a desktop entry, and some candidates are")
+		}
+	}
+
+	// now keep all candidates of the lowest depth. stubs that are too
+	// small don't count, but they're kept if they're even shallower, and
+	// scoring decides.
+	sizedCandidates := selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+		return !params.isUndersized(c)
+	})
+	if len(sizedCandidates) == 0 {
+		sizedCandidates = compatibleCandidates
+	}
+	lowestDepth := 4096
+	for _, c := range sizedCandidates {
+		if c.Depth < lowestDepth {
+			lowestDepth = c.Depth
+		}
+	}
+
+	bestCandidates = selectByFunc(compatibleCandidates, func(c *Candidate) bool {
+		pass := c.Depth == lowestDepth || (params.isUndersized(c) && c.Depth < lowestDepth)
+		if !pass {
+			tracker.eliminate(c, FilterStageDepth, "depth %d > lowest depth %d", c.Depth, lowestDepth)
+		}
+		return pass
+	})
+
+	if len(bestCandidates) == 1 {
+		return finish(FilterStageDepth, bestCandidates, "single candidate left at lowest depth")
+	}
+
+	// with an explicit flavor order, the first flavor that has candidates
+	// wins, and the flavor rules below are skipped: love bundles and engine
+	// flavors winning, macOS scripts and app bundles winning on macOS,
+	// windows and launcher scripts winning, JNLP descriptors and jars
+	// winning without native executables, and HTML, archives and jars
+	// losing to anything else. If none of the flavors has candidates, they
+	// all stay. OS-specific rules that don't pick between flavors (arch
+	// preferences, leaving out installers, GUI executables winning on
+	// windows) still apply, and scoring decides between what's left.
+	defaultFlavors := params.PreferFlavors == nil
+	if !defaultFlavors {
+		for _, flavor := range params.PreferFlavors {
+			preferredCandidates := selectByFlavor(bestCandidates, flavor)
+			if len(preferredCandidates) > 0 {
+				consumer.Debugf("Found %d candidates of preferred flavor %v, excluding all others", len(preferredCandidates), flavor)
+				bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, preferredCandidates, "not %v, and it comes first in the preferred flavors", flavor)
+				break
+			}
+		}
+
+		if len(bestCandidates) == 1 {
+			return finish(FilterStageFlavor, bestCandidates, "single candidate of a preferred flavor left")
+		}
+	}
+
+	// love always wins, in the end, unless we're launching in a browser
+	// and there's a love.js export
+	if defaultFlavors {
+		loveCandidates := selectByFlavor(bestCandidates, FlavorLove)
+		loveJSCandidates := selectByFunc(bestCandidates, isLoveJSExport)
+
+		if hasOS(OSWeb) && len(loveCandidates) > 0 && len(loveJSCandidates) > 0 {
+			consumer.Debugf("Found %d love.js exports, excluding .love candidates", len(loveJSCandidates))
+			bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return c.Flavor != FlavorLove
+			}), "love bundle, and a love.js export was found")
+
+			if len(bestCandidates) == 1 {
+				return finish(FilterStageFlavor, bestCandidates, "single love.js export left")
+			}
+		} else if len(loveCandidates) == 1 {
+			consumer.Debugf("Found single .love candidate")
+			return finish(FilterStageFlavor, loveCandidates, "found single .love candidate (%s)", loveCandidates[0].Path)
+		}
+	}
+
+	// games made with an engine win over loose executables, see engineFlavors
+	if defaultFlavors {
+		for _, ef := range engineFlavors {
+			engineCandidates := selectByFlavor(bestCandidates, ef.flavor)
+
+			if len(engineCandidates) == 1 {
+				consumer.Debugf("Found single %s (%s)", ef.name, engineCandidates[0].Path)
+				return finish(FilterStageFlavor, engineCandidates, "found single %s (%s)", ef.name, engineCandidates[0].Path)
+			}
+		}
+	}
+
+	// everywhere, RPG Maker projects win over other HTML files. this comes
+	// before the OS-specific rules, which don't keep HTML files.
+	{
+		htmlCandidates := selectByFlavor(bestCandidates, FlavorHTML)
+		rpgMakerCandidates := selectByFunc(htmlCandidates, isRPGMakerProject)
+		if len(rpgMakerCandidates) > 0 && len(rpgMakerCandidates) < len(htmlCandidates) {
+			consumer.Debugf("Found %d RPG Maker projects, excluding other HTML candidates", len(rpgMakerCandidates))
+			bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return c.Flavor != FlavorHTML || isRPGMakerProject(c)
+			}), "HTML, but not an RPG Maker project, and some were found")
+
+			if len(bestCandidates) == 1 {
+				return finish(FilterStageFlavor, bestCandidates, "single RPG Maker project left")
+			}
+		}
+	}
+
+	// on macOS, .command scripts (and other macOS scripts) win, even over
+	// app bundles, which they usually start with the right arguments.
+	// scripts written for linux lose.
+	if defaultFlavors && hasOS("darwin") {
+		linuxScripts := selectByFunc(bestCandidates, isLinuxOnlyScript)
+		if len(linuxScripts) > 0 && len(linuxScripts) < len(bestCandidates) {
+			consumer.Debugf("Has %d linux-only scripts, but %d other candidates - excluding linux-only scripts", len(linuxScripts), len(bestCandidates)-len(linuxScripts))
+			bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return !isLinuxOnlyScript(c)
+			}), "script written for linux, and there are other candidates")
+
+			if len(bestCandidates) == 1 {
+				return finish(FilterStageFlavor, bestCandidates, "single candidate left that isn't a linux-only script")
+			}
+		}
+
+		scriptCandidates := selectByFlavor(bestCandidates, FlavorScriptMacos)
+
+		if len(scriptCandidates) == 1 {
+			consumer.Debugf("Found single macOS script (%s)", scriptCandidates[0].Path)
+			return finish(FilterStageFlavor, scriptCandidates, "found single macOS script (%s)", scriptCandidates[0].Path)
+		}
+	}
+
+	// on macOS, app bundles win
+	if defaultFlavors && hasOS("darwin") {
+		appCandidates := selectByFlavor(bestCandidates, FlavorAppMacos)
+
+		if len(appCandidates) > 0 {
+			consumer.Debugf("Found some .app bundles")
+			bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, appCandidates, "not an .app bundle, and some were found")
+		}
+	}
+
+	// on macOS, installer packages lose if there's anything else
+	if hasOS("darwin") {
+		pkgCandidates := selectByFlavor(bestCandidates, FlavorPkgMacos)
+
+		if len(pkgCandidates) > 0 && len(pkgCandidates) < len(bestCandidates) {
+			consumer.Debugf("Has %d macOS installer packages, but %d other candidates - excluding installer packages", len(pkgCandidates), len(bestCandidates)-len(pkgCandidates))
+			bestCandidates = tracker.narrow(FilterStageInstaller, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return c.Flavor != FlavorPkgMacos
+			}), "macOS installer package, and there are other candidates")
+
+			if len(bestCandidates) == 1 {
+				return finish(FilterStageInstaller, bestCandidates, "single non-installer left")
+			}
+		}
+	}
+
+	// on windows, scripts win, and those that launch another candidate
+	// win over those that don't
+	if defaultFlavors && hasOS("windows") {
+		scriptCandidates := selectByFlavor(bestCandidates, FlavorScriptWindows)
+		launchingCandidates := selectByFunc(scriptCandidates, func(c *Candidate) bool {
+			return scriptLaunchers[c]
+		})
+		if len(launchingCandidates) > 0 {
+			scriptCandidates = launchingCandidates
+		}
+
+		if len(scriptCandidates) == 1 {
+			consumer.Debugf("Found single windows script (%s)", scriptCandidates[0].Path)
+			return finish(FilterStageFlavor, scriptCandidates, "found single windows script (%s)", scriptCandidates[0].Path)
+		}
+	}
+
+	// on linux, launcher scripts win. scripts for other interpreters
+	// (python, node...) don't beat native executables, since they may
+	// only be tools and their interpreter may not be installed.
+	// when there are several, the conventionally-named ones (`start.sh`,
+	// `run.sh`...) survive the arch rules below, and win by score.
+	isLauncher := func(c *Candidate) bool { return false }
+	if hasOS("linux") {
+		scriptCandidates := selectByFunc(bestCandidates, isLauncherScript)
+
+		if defaultFlavors && len(scriptCandidates) == 1 {
+			consumer.Debugf("Found single Linux script (%s)", scriptCandidates[0].Path)
+			return finish(FilterStageFlavor, scriptCandidates, "found single Linux script (%s)", scriptCandidates[0].Path)
+		}
+
+		isLauncher = isConventionalLauncher(params.launcherNames())
+	}
+
+	if hasOS("linux") && hasArch("amd64") {
+		consumer.Debugf("Oh boy, we're on 64-bit Linux, let's filter some stuff")
+
+		linuxCandidates := selectByFlavor(bestCandidates, FlavorNativeLinux)
+		linux64Candidates := selectByArch(linuxCandidates, ArchAmd64)
+
+		if len(linux64Candidates) > 0 {
+			consumer.Debugf("Found some native 64-bit Linux candidates, excluding all others but self-contained jars")
+
+			// on linux 64, 64-bit binaries win, along with jars that don't
+			// need Java to be installed
+			bestCandidates = tracker.narrow(FilterStageArch, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return (c.Flavor == FlavorNativeLinux && c.Arch == ArchAmd64) || isSelfContainedJar(c) || isLauncher(c)
+			}), "not a native 64-bit Linux candidate, and some were found")
+		} else if defaultFlavors {
+			consumer.Debugf("No native 64-bit Linux candidates, looking for jars")
+
+			// if no 64-bit binaries, Java Web Start descriptors win,
+			// then jars
+			jnlpCandidates := selectByFlavor(bestCandidates, FlavorJNLP)
+			if len(jnlpCandidates) > 0 {
+				consumer.Debugf("Found some JNLP candidates, excluding all others")
+				return finish(FilterStageFlavor, jnlpCandidates, "no native 64-bit Linux candidates, and some JNLP descriptors were found")
+			}
+			jarCandidates := selectByFlavor(bestCandidates, FlavorJar)
+			if len(jarCandidates) > 0 {
+				consumer.Debugf("Found some jar candidates, excluding all others")
+				return finish(FilterStageFlavor, jarCandidates, "no native 64-bit Linux candidates, and some jars were found")
+			}
+		}
+	}
+
+	if hasOS("linux") && hasArch("arm64") {
+		linuxCandidates := selectByFlavor(bestCandidates, FlavorNativeLinux)
+		linuxArm64Candidates := selectByArch(linuxCandidates, ArchArm64)
+
+		if len(linuxArm64Candidates) > 0 {
+			consumer.Debugf("Found some native arm64 Linux candidates, excluding all others but launcher scripts")
+			bestCandidates = tracker.narrow(FilterStageArch, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return (c.Flavor == FlavorNativeLinux && c.Arch == ArchArm64) || isLauncher(c)
+			}), "not a native arm64 Linux candidate, and some were found")
+		}
+	}
+
+	if hasOS("darwin") && hasArch("arm64") {
+		macosCandidates := selectByFlavor(bestCandidates, FlavorNativeMacos)
+		macosArm64Candidates := selectByFunc(macosCandidates, func(c *Candidate) bool {
+			return hasArchSlice(c, ArchArm64)
+		})
+
+		if len(macosArm64Candidates) > 0 {
+			consumer.Debugf("Found some native arm64 (or universal) macOS candidates, excluding other native macOS candidates")
+			bestCandidates = tracker.narrow(FilterStageArch, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return c.Flavor != FlavorNativeMacos || hasArchSlice(c, ArchArm64)
+			}), "no arm64 slice, and some arm64 macOS candidates were found")
+		}
+	}
+
+	// on windows amd64, 32-bit executables lose if there are 64-bit ones.
+	// on windows arm64, x86 and x64 executables aren't excluded, since they
+	// run emulated: they get emulatedArchPenalty, so arm64 ones come first.
+	if hasOS("windows") && hasArch("amd64") {
+		windowsCandidates := selectByFlavor(bestCandidates, FlavorNativeWindows)
+		windows64Candidates := selectByArch(windowsCandidates, ArchAmd64)
+
+		if len(windows64Candidates) > 0 && len(windows64Candidates) < len(windowsCandidates) {
+			consumer.Debugf("Found some native amd64 Windows candidates, excluding other native Windows candidates")
+			bestCandidates = tracker.narrow(FilterStageArch, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+				return c.Flavor != FlavorNativeWindows || c.Arch == ArchAmd64
+			}), "not a native amd64 Windows candidate, and some were found")
+		}
+	}
+
+	// on windows, Java Web Start descriptors win if there are no native
+	// executables: the rules below only keep those
+	if defaultFlavors && hasOS("windows") && len(selectByFlavor(bestCandidates, FlavorNativeWindows)) == 0 {
+		jnlpCandidates := selectByFlavor(bestCandidates, FlavorJNLP)
+		if len(jnlpCandidates) > 0 {
+			consumer.Debugf("Found some JNLP candidates, excluding all others")
+			return finish(FilterStageFlavor, jnlpCandidates, "no native windows executables, and some JNLP descriptors were found")
+		}
+	}
+
+	// on windows, non-installers win
+	if hasOS("windows") && !params.AllowInstallers {
+		windowsCandidates := selectByFlavor(bestCandidates, FlavorNativeWindows)
+		nonInstallerCandidates := selectByFunc(windowsCandidates, func(c *Candidate) bool {
+			if reason := c.installerReason(v.BasePath, warnings, params.ProbeTimeout); reason != "" {
+				tracker.eliminate(c, FilterStageInstaller, "%s", reason)
+				return false // false means "is an installer"
+			}
+			return true // can't tell if installer or not
+		})
+
+		bestCandidates = tracker.narrow(FilterStageInstaller, bestCandidates, nonInstallerCandidates, "not a native windows executable")
+
+		if len(bestCandidates) == 1 {
+			return finish(FilterStageInstaller, bestCandidates, "single non-installer left")
+		}
+	}
+
+	// on windows, gui executables win
+	if hasOS("windows") {
+		windowsCandidates := selectByFlavor(bestCandidates, FlavorNativeWindows)
+		guiCandidates := selectByFunc(windowsCandidates, func(c *Candidate) bool {
+			pass := c.WindowsInfo != nil && c.WindowsInfo.Gui
+			if !pass {
+				consumer.Debugf("Considering (%s) for exclusion - not a GUI executable", c.Path)
+			}
+			return pass
+		})
+
+		if len(guiCandidates) > 0 {
+			bestCandidates = tracker.narrow(FilterStageGui, bestCandidates, guiCandidates, "not a GUI executable, and some were found")
+		}
+
+		if len(bestCandidates) == 1 {
+			return finish(FilterStageGui, bestCandidates, "single GUI executable left")
+		}
+	}
+
+	weighted := func(f Flavor) bool {
+		_, ok := params.FlavorWeights[f]
+		return ok
+	}
+
+	// everywhere, HTML files, then archives (and disk images), then jars
+	// lose if there's anything else good, see losingFlavors. flavors that
+	// have a weight stay, and so do jars that bring their own runtime.
+	if defaultFlavors {
+		for _, lf := range losingFlavors {
+			loses := func(c *Candidate) bool {
+				return hasFlavor(lf.flavors, c.Flavor) && !weighted(c.Flavor) && !isSelfContainedJar(c)
+			}
+			losingCandidates := selectByFunc(bestCandidates, loses)
+			if len(losingCandidates) > 0 && len(losingCandidates) < len(bestCandidates) {
+				consumer.Debugf("Has %d %s candidates, but %d other candidates - excluding %s candidates", len(losingCandidates), lf.name, len(bestCandidates)-len(losingCandidates), lf.name)
+				bestCandidates = tracker.narrow(FilterStageFlavor, bestCandidates, selectByFunc(bestCandidates, func(c *Candidate) bool {
+					return !loses(c)
+				}), "%s", lf.reason)
+			}
+		}
+	}
+
+	sort.Stable(&biggestFirst{bestCandidates})
+
+	// score, filter & sort
+	var scoredCandidates []ScoredCandidate
+	for _, candidate := range bestCandidates {
+		scored := computeScore(candidate)
+		tracker.recordScore(scored)
+		if scored.Score > 0 {
+			scoredCandidates = append(scoredCandidates, scored)
+		} else {
+			tracker.eliminate(candidate, FilterStageScore, "non-positive score %d", scored.Score)
+		}
+	}
+	sort.Stable(&HighestScoreFirst{scoredCandidates})
+	consumer.Debugf("Sorted candidates: ")
+	for _, sc := range scoredCandidates {
+		consumer.Debugf("- [%d] (%s)", sc.Score, sc.Candidate.Path)
+	}
+
+	var finalCandidates []*Candidate
+	for _, scored := range scoredCandidates {
+		finalCandidates = append(finalCandidates, scored.Candidate)
+	}
+
+	return finish(FilterStageScore, finalCandidates, "not among the scored candidates")
+}
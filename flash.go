@@ -0,0 +1,45 @@
+package dash
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Flash projectors are a standalone Flash Player with the game's SWF
+// appended to it, followed by an 8-byte trailer: this magic number, then
+// the size of the SWF, both little-endian. Windows, linux and macOS
+// players all use the same layout.
+const flashProjectorMagic = 0xFA123456
+
+// SWF files start with 'FWS' (uncompressed), 'CWS' (zlib) or 'ZWS' (LZMA)
+var swfMagics = []string{"FWS", "CWS", "ZWS"}
+
+// hasAppendedSWF returns true if a native executable ends with a SWF
+// and a Flash projector trailer
+func hasAppendedSWF(r io.ReadSeeker, size int64) bool {
+	const trailerSize = 8
+	if size < trailerSize {
+		return false
+	}
+
+	trailer, err := readBytesAt(r, size-trailerSize, trailerSize)
+	if err != nil || binary.LittleEndian.Uint32(trailer[0:4]) != flashProjectorMagic {
+		return false
+	}
+
+	swfSize := int64(binary.LittleEndian.Uint32(trailer[4:8]))
+	if swfSize < 8 || swfSize > size-trailerSize {
+		return false
+	}
+
+	magic, err := readBytesAt(r, size-trailerSize-swfSize, 3)
+	if err != nil {
+		return false
+	}
+	for _, m := range swfMagics {
+		if string(magic) == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package dash
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// flavorsMutex guards allFlavors, which RegisterFlavor appends to
+var flavorsMutex sync.RWMutex
+
+// allFlavors lists every flavor dash knows about. Their string values
+// are stored by callers (databases, JSON payloads), so they must never
+// change once released.
+var allFlavors = []Flavor{
+	FlavorNativeLinux,
+	FlavorNativeMacos,
+	FlavorNativeWindows,
+	FlavorAppMacos,
+	FlavorScript,
+	FlavorScriptWindows,
+	FlavorJar,
+	FlavorHTML,
+	FlavorLove,
+	FlavorMSI,
+	FlavorGodot,
+	FlavorGameMaker,
+	FlavorNWjs,
+	FlavorElectron,
+	FlavorArchive,
+	FlavorDOSBox,
+	FlavorPkgMacos,
+	FlavorDmgMacos,
+	FlavorClickTeam,
+	FlavorAIR,
+	FlavorUnity,
+	FlavorScriptMacos,
+	FlavorJNLP,
+	FlavorFlashProjector,
+	FlavorUnreal,
+	FlavorMobile,
+}
+
+// String returns the stable identifier of a flavor, e.g. "windows",
+// "app-macos", "love" or "html".
+func (f Flavor) String() string {
+	return string(f)
+}
+
+// ParseFlavor returns the flavor matching the given identifier,
+// or an error if it's not a flavor dash knows about.
+func ParseFlavor(s string) (Flavor, error) {
+	flavorsMutex.RLock()
+	defer flavorsMutex.RUnlock()
+
+	for _, f := range allFlavors {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown flavor %q", s)
+}
+
+// RegisterFlavor makes a flavor returned by a sniffer registered with
+// RegisterSniffer known to ParseFlavor.
+// Candidates of registered flavors aren't tied to any OS, so Filter
+// doesn't exclude them. It panics if the flavor is empty or already known.
+func RegisterFlavor(f Flavor) {
+	if f == "" {
+		panic("dash: RegisterFlavor with empty flavor")
+	}
+
+	flavorsMutex.Lock()
+	defer flavorsMutex.Unlock()
+
+	for _, known := range allFlavors {
+		if known == f {
+			panic(fmt.Sprintf("dash: RegisterFlavor called twice for %q", f))
+		}
+	}
+	allFlavors = append(allFlavors, f)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f Flavor) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Unlike ParseFlavor,
+// it keeps flavors it doesn't know about as-is, so verdicts stored by a
+// newer version of dash, or by a process that called RegisterFlavor, can
+// still be read. Only empty flavors are rejected.
+func (f *Flavor) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty flavor")
+	}
+	*f = Flavor(text)
+	return nil
+}
@@ -0,0 +1,117 @@
+package dash
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Flavor(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.EqualValues("windows", FlavorNativeWindows.String())
+	assert.EqualValues("app-macos", FlavorAppMacos.String())
+
+	for _, f := range allFlavors {
+		parsed, err := ParseFlavor(f.String())
+		assert.NoError(err)
+		assert.EqualValues(f, parsed)
+
+		marshalled, err := json.Marshal(f)
+		assert.NoError(err)
+
+		var unmarshalled Flavor
+		assert.NoError(json.Unmarshal(marshalled, &unmarshalled))
+		assert.EqualValues(f, unmarshalled)
+	}
+
+	_, err := ParseFlavor("native-toaster")
+	assert.Error(err)
+
+	var f Flavor
+	assert.NoError(json.Unmarshal([]byte(`"native-toaster"`), &f), "keeps unknown flavors")
+	assert.EqualValues(Flavor("native-toaster"), f)
+	assert.Error(json.Unmarshal([]byte(`""`), &f), "rejects empty flavors")
+
+	// as written by a newer version of dash
+	v := Verdict{
+		BasePath: "game",
+		Candidates: []*Candidate{
+			{Path: "game.toast", Flavor: Flavor("native-toaster")},
+			{Path: "game.exe", Flavor: FlavorNativeWindows},
+		},
+	}
+	marshalled, err := json.Marshal(v)
+	assert.NoError(err)
+
+	var unmarshalled Verdict
+	assert.NoError(json.Unmarshal(marshalled, &unmarshalled), "reads verdicts with unknown flavors")
+	assert.EqualValues(v, unmarshalled, "round-trips unknown flavors")
+}
+
+func Test_FlavorPriority(t *testing.T) {
+	seen := make(map[Flavor]bool)
+	for _, tier := range flavorTiers {
+		for _, f := range tier {
+			assert.False(t, seen[f], "%s is only listed once", f)
+			seen[f] = true
+		}
+	}
+	assert.EqualValues(t, FlavorPriority(FlavorNativeWindows), FlavorPriority(Flavor("native-toaster")), "unknown flavors rank like native executables")
+
+	// Filter agrees with FlavorPriority: for each pair, the first flavor wins
+	ordered := []Flavor{FlavorLove}
+	for _, ef := range engineFlavors {
+		ordered = append(ordered, ef.flavor)
+	}
+	ordered = append(ordered, FlavorNativeWindows)
+
+	type pair struct {
+		higher, lower Flavor
+		os            string
+	}
+	var pairs []pair
+	for i := 1; i < len(ordered); i++ {
+		pairs = append(pairs, pair{ordered[i-1], ordered[i], "windows"})
+	}
+	// on windows, everything but native executables loses
+	pairs = append(pairs,
+		pair{FlavorNativeWindows, FlavorJar, "windows"},
+		pair{FlavorMSI, FlavorJar, ""},
+		pair{FlavorMSI, FlavorHTML, ""},
+	)
+	// then each losing flavor loses against the ones Filter leaves out later
+	for i := 1; i < len(losingFlavors); i++ {
+		pairs = append(pairs, pair{losingFlavors[i].flavors[0], losingFlavors[i-1].flavors[0], ""})
+	}
+
+	for _, p := range pairs {
+		higher, lower := p.higher, p.lower
+		assert.True(t, FlavorPriority(higher) > FlavorPriority(lower), "%s comes before %s", higher, lower)
+
+		candidate := func(f Flavor) *Candidate {
+			c := &Candidate{
+				Path:   "game-" + string(f),
+				Depth:  1,
+				Flavor: f,
+				Size:   1000,
+			}
+			switch f {
+			case FlavorJar:
+				c.JarInfo = &JarInfo{Runnable: true}
+			case FlavorArchive:
+				c.ArchiveInfo = &ArchiveInfo{Format: ArchiveFormat7z}
+			case FlavorHTML, FlavorLove:
+			default:
+				c.WindowsInfo = &WindowsInfo{Gui: true}
+			}
+			return c
+		}
+		v := &Verdict{Candidates: []*Candidate{candidate(lower), candidate(higher)}}
+		vcopy := v.FilteredCopy(nil, FilterParams{OS: p.os})
+		if assert.EqualValues(t, 1, len(vcopy.Candidates), "picks one candidate (%s over %s)", higher, lower) {
+			assert.EqualValues(t, higher, vcopy.Candidates[0].Flavor, "Filter picks %s over %s", higher, lower)
+		}
+	}
+}
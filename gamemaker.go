@@ -0,0 +1,68 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// GameMaker: Studio exports ship a generic runner executable, renamed
+// after the game, and a data file that holds the actual game. The name
+// of the data file depends on the target platform.
+var gameMakerDataFiles = map[string]string{
+	"data.win":   "windows",
+	"game.unx":   "linux",
+	"game.ios":   "darwin",
+	"game.droid": "",
+}
+
+// detectGameMakerRunners looks for GameMaker data files in the container
+// and marks the native executable that runs them as a GameMaker candidate.
+// Data files are either next to the runner or in an assets/ folder next
+// to it (macOS app bundles keep theirs in Contents/Resources).
+//
+// When several executables could be the runner (crash reporters, etc.),
+// the biggest one wins: runners embed the whole engine.
+func detectGameMakerRunners(container *tlc.Container, candidates []*Candidate) {
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+		dataOS, ok := gameMakerDataFiles[path.Base(lowerPath)]
+		if !ok {
+			continue
+		}
+
+		dataDir := path.Dir(lowerPath)
+		runnerDirs := []string{dataDir}
+		switch path.Base(dataDir) {
+		case "assets":
+			runnerDirs = append(runnerDirs, path.Dir(dataDir))
+		case "resources":
+			runnerDirs = append(runnerDirs, path.Join(path.Dir(dataDir), "macos"))
+		}
+
+		var runner *Candidate
+		for _, c := range candidates {
+			if !isNativeFlavor(c.Flavor) {
+				continue
+			}
+			if dataOS != "" && candidateOS(c) != dataOS {
+				continue
+			}
+
+			candidateDir := path.Dir(strings.ToLower(c.Path))
+			for _, runnerDir := range runnerDirs {
+				if candidateDir == runnerDir && (runner == nil || c.Size > runner.Size) {
+					runner = c
+				}
+			}
+		}
+
+		if runner != nil {
+			runner.Flavor = FlavorGameMaker
+			runner.GameMakerInfo = &GameMakerInfo{
+				DataPath: f.Path,
+			}
+		}
+	}
+}
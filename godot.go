@@ -0,0 +1,80 @@
+package dash
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// Godot packs start with the "GDPC" magic. When a pack is embedded into
+// an executable, the exporter appends the pack, its size, and the magic
+// again, so that the engine can find it by looking at the end of its
+// own binary.
+var godotPackMagic = []byte("GDPC")
+
+// isGodotPack returns true if r looks like a Godot pack, either
+// because it starts or ends with the pack magic.
+func isGodotPack(r io.ReadSeeker, size int64) bool {
+	return hasMagicAt(r, 0, godotPackMagic) || hasEmbeddedGodotPack(r, size)
+}
+
+// hasEmbeddedGodotPack returns true if r ends with the pack magic.
+func hasEmbeddedGodotPack(r io.ReadSeeker, size int64) bool {
+	magicSize := int64(len(godotPackMagic))
+	if size < magicSize {
+		return false
+	}
+	return hasMagicAt(r, size-magicSize, godotPackMagic)
+}
+
+// markGodot turns a native candidate into a Godot candidate. The
+// platform-specific info (WindowsInfo, LinuxInfo, MacosInfo) is kept,
+// so we still know which OS the executable runs on.
+func markGodot(c *Candidate, packPath string) {
+	c.Flavor = FlavorGodot
+	c.GodotInfo = &GodotInfo{
+		PackPath: packPath,
+		Embedded: packPath == "",
+	}
+}
+
+// detectGodotPacks looks for .pck files in the container, and marks
+// native candidates with the same base name (in the same folder) as
+// Godot candidates.
+func detectGodotPacks(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	for fileIndex, f := range container.Files {
+		if !hasExt(f.Path, ".pck") {
+			continue
+		}
+
+		r, err := pool.GetReadSeeker(int64(fileIndex))
+		if err != nil {
+			return errors.Wrap(err, "while getting read seeker for godot pack")
+		}
+
+		if !isGodotPack(r, f.Size) {
+			continue
+		}
+
+		packBase := trimExt(f.Path)
+		for _, c := range candidates {
+			if !isNativeFlavor(c.Flavor) {
+				continue
+			}
+
+			if strings.EqualFold(trimExt(c.Path), packBase) {
+				markGodot(c, f.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+func trimExt(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
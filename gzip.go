@@ -0,0 +1,54 @@
+package dash
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// gzipPeekSize is how much of a gzip-compressed file is inflated to find
+// out what's inside. Executable headers fit comfortably in it.
+const gzipPeekSize = 4 * 1024
+
+// sniffGzip looks at the beginning of a gzip-compressed file, and returns
+// a candidate marked as compressed if it's a native executable. Only the
+// first member of the stream is read, and at most gzipPeekSize bytes are
+// inflated.
+func sniffGzip(r io.ReadSeeker, path string) (*Candidate, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		// corrupt or not gzip after all
+		return nil, nil
+	}
+	defer gr.Close()
+	gr.Multistream(false)
+
+	peek := make([]byte, gzipPeekSize)
+	n, err := io.ReadFull(gr, peek)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, nil
+	}
+	peek = peek[:n]
+
+	// the name without .gz tells us what to expect, like `.exe`
+	innerPath := path
+	if strings.HasSuffix(strings.ToLower(innerPath), ".gz") {
+		innerPath = innerPath[:len(innerPath)-len(".gz")]
+	}
+
+	c, err := doSniff(bytes.NewReader(peek), innerPath, int64(len(peek)), peek)
+	if err != nil || c == nil || !isNativeFlavor(c.Flavor) {
+		return nil, nil
+	}
+
+	// the rest of sniff fills these in for the compressed file
+	c.Path = ""
+	c.Compressed = true
+	return c, nil
+}
@@ -0,0 +1,91 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Extensions of files HTML5 games usually load at runtime
+var htmlAssetExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".webp": true,
+	".ogg":  true,
+	".mp3":  true,
+	".wav":  true,
+	".m4a":  true,
+	".json": true,
+	".wasm": true,
+	".data": true,
+}
+
+// minHTMLAssets is how many asset files it takes for a top-level HTML
+// file to look like a game when there's no script around
+const minHTMLAssets = 2
+
+// hasHTMLGameSignals returns true if a folder holds something that
+// suggests its top-level HTML files are a game rather than documentation:
+// scripts (like a game.js runtime), a web app manifest, or a few assets.
+func hasHTMLGameSignals(container *tlc.Container) bool {
+	assets := 0
+	for _, f := range container.Files {
+		ext := GetExt(f.Path)
+		switch {
+		case ext == ".js":
+			return true
+		case isWebManifest(f.Path):
+			return true
+		case htmlAssetExts[ext]:
+			assets++
+		}
+	}
+	return assets >= minHTMLAssets
+}
+
+// htmlFallbackCandidates returns the .html files closest to the root, up
+// to maxDepth, as HTML candidates. If some of them are named index.html,
+// only those are returned.
+func htmlFallbackCandidates(container *tlc.Container, maxDepth int) []*Candidate {
+	var htmlFiles []*tlc.File
+	shallowest := maxDepth + 1
+	for _, f := range container.Files {
+		depth := PathDepth(f.Path)
+		if depth > maxDepth || !hasExt(f.Path, ".html") {
+			continue
+		}
+		if depth < shallowest {
+			shallowest = depth
+			htmlFiles = nil
+		}
+		if depth == shallowest {
+			htmlFiles = append(htmlFiles, f)
+		}
+	}
+
+	var indexFiles []*tlc.File
+	for _, f := range htmlFiles {
+		if strings.EqualFold(path.Base(f.Path), "index.html") {
+			indexFiles = append(indexFiles, f)
+		}
+	}
+	if len(indexFiles) > 0 {
+		htmlFiles = indexFiles
+	}
+
+	var candidates []*Candidate
+	for _, f := range htmlFiles {
+		// ok, that's an HTML5 game
+		candidates = append(candidates, &Candidate{
+			Size:   f.Size,
+			Path:   f.Path,
+			Mode:   f.Mode,
+			Depth:  PathDepth(f.Path),
+			Flavor: FlavorHTML,
+		})
+	}
+	return candidates
+}
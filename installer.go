@@ -0,0 +1,144 @@
+package dash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/itchio/pelican"
+)
+
+// IsInstaller returns true if c looks like an installer rather than a
+// game. Scripts are installers if they're self-extracting (makeself,
+// MojoSetup). Native windows executables are if their installer type is
+// known, their version info looks setup-like, they require elevation, or
+// they have no assembly info and a setup-like name. basePath is the
+// folder c.Path is relative to.
+//
+// The last two checks need a pelican probe, whose results are cached by
+// path, size and modification time, so asking again about the same file
+// is cheap.
+func (c *Candidate) IsInstaller(basePath string, consumer *state.Consumer) bool {
+	return c.installerReason(basePath, newWarningLog(consumer, nil), 0) != ""
+}
+
+// installerReason returns why c looks like an installer, or an empty
+// string if it doesn't. A non-zero probeTimeout bounds how long pelican
+// may take, after which the probe-based checks are skipped. Files that
+// can't be inspected are reported to warnings.
+func (c *Candidate) installerReason(basePath string, warnings *warningLog, probeTimeout time.Duration) string {
+	if c.ScriptInfo != nil && c.ScriptInfo.Installer != "" {
+		return fmt.Sprintf("self-extracting installer (%s)", c.ScriptInfo.Installer)
+	}
+
+	if c.Flavor != FlavorNativeWindows {
+		return ""
+	}
+
+	if c.WindowsInfo != nil && c.WindowsInfo.InstallerType != "" {
+		return fmt.Sprintf("installer of type (%s)", c.WindowsInfo.InstallerType)
+	}
+
+	// renamed installers still tell on themselves in their version resource
+	if HasSuspiciousVersionInfo(c.WindowsInfo) {
+		return fmt.Sprintf("version info looks setup-like (original filename %q, description %q)", c.WindowsInfo.OriginalFilename, c.WindowsInfo.FileDescription)
+	}
+
+	fullTargetPath := filepath.FromSlash(c.Path)
+	f, err := os.Open(filepath.Join(basePath, fullTargetPath))
+	if err != nil {
+		warnings.warnf(c.Path, "Could not open native windows candidate (%s) for inspection", fullTargetPath)
+		warnings.consumer.Warnf("Full error: %#v", err)
+		return ""
+	}
+
+	peInfo, peLines, err := probeCachedPE(f, probeTimeout)
+	if err == errProbeTimeout {
+		warnings.warnf(c.Path, "Gave up probing (%s) with pelican after %s", fullTargetPath, probeTimeout)
+		return ""
+	} else if err != nil {
+		warnings.warnf(c.Path, "Could not probe (%s) with pelican", fullTargetPath)
+		warnings.consumer.Warnf("Full error: %#v", err)
+		warnings.consumer.Warnf("Full pelican log:\n%s", strings.Join(peLines, "\n"))
+		return ""
+	}
+
+	// unless Configure read it already, the version resource only gets
+	// looked at now
+	versionInfo := &WindowsInfo{}
+	setPEVersionInfo(versionInfo, peInfo.VersionProperties)
+	if HasSuspiciousVersionInfo(versionInfo) {
+		return fmt.Sprintf("version info looks setup-like (original filename %q, description %q)", versionInfo.OriginalFilename, versionInfo.FileDescription)
+	}
+
+	if peInfo.RequiresElevation() {
+		return "requires elevation"
+	}
+
+	if peInfo.AssemblyInfo == nil && HasSuspiciouslySetupLikeName(filepath.Base(c.Path)) {
+		return "no assembly info + has suspiciously setup-like name"
+	}
+
+	return ""
+}
+
+// maxProbeCacheEntries bounds the probe cache, which is simply emptied
+// when it's full
+const maxProbeCacheEntries = 256
+
+type probeCacheKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+var (
+	probeCacheMutex sync.Mutex
+	probeCache      = make(map[probeCacheKey]*pelican.PeInfo)
+)
+
+// probeCachedPE probes f with probePE, unless a file with the same path,
+// size and modification time was already probed successfully. Like
+// probePE, it takes ownership of f. Timeouts and errors aren't cached.
+func probeCachedPE(f *os.File, timeout time.Duration) (*pelican.PeInfo, []string, error) {
+	stats, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	key := probeCacheKey{
+		path:    f.Name(),
+		size:    stats.Size(),
+		modTime: stats.ModTime(),
+	}
+	if abs, err := filepath.Abs(f.Name()); err == nil {
+		key.path = abs
+	}
+
+	probeCacheMutex.Lock()
+	info, ok := probeCache[key]
+	probeCacheMutex.Unlock()
+	if ok {
+		f.Close()
+		return info, nil, nil
+	}
+
+	info, peLines, err := probePE(f, timeout)
+	if err != nil {
+		return nil, peLines, err
+	}
+
+	probeCacheMutex.Lock()
+	if len(probeCache) >= maxProbeCacheEntries {
+		probeCache = make(map[probeCacheKey]*pelican.PeInfo)
+	}
+	probeCache[key] = info
+	probeCacheMutex.Unlock()
+
+	return info, peLines, nil
+}
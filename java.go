@@ -0,0 +1,90 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+)
+
+// Where Java games usually bundle their runtime, relative to the jar
+var bundledJavaPaths = []string{
+	"jre/bin/java",
+	"jre/bin/java.exe",
+	"jdk/bin/java",
+	"jdk/bin/java.exe",
+}
+
+// detectBundledJava marks jars that ship with their own Java runtime
+// as self-contained, so they can be launched even if Java isn't
+// installed on the system.
+func detectBundledJava(container *tlc.Container, candidates []*Candidate) {
+	lowerFiles := make(map[string]string)
+	for _, f := range container.Files {
+		lowerFiles[strings.ToLower(f.Path)] = f.Path
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorJar {
+			continue
+		}
+
+		dir := path.Dir(strings.ToLower(c.Path))
+		for _, javaPath := range bundledJavaPaths {
+			if actualPath, ok := lowerFiles[path.Join(dir, javaPath)]; ok {
+				if c.JarInfo == nil {
+					c.JarInfo = &JarInfo{}
+				}
+				c.JarInfo.BundledJava = actualPath
+				break
+			}
+		}
+	}
+}
+
+func isSelfContainedJar(c *Candidate) bool {
+	return c.Flavor == FlavorJar && c.JarInfo != nil && c.JarInfo.BundledJava != ""
+}
+
+// zipMagic is how zip archives, and so jars, start
+var zipMagic = []byte{0x50, 0x4B, 0x03, 0x04}
+
+// jarFallbackCandidate returns the only top-level .jar of a container, if
+// it's a zip archive, as a jar candidate. It's meant as a last resort for
+// uploads where a jar is all there is, but its manifest doesn't name a
+// main class, so the jar isn't marked as runnable.
+func jarFallbackCandidate(pool lake.Pool, container *tlc.Container) (*Candidate, error) {
+	var jarIndex int64 = -1
+	for fileIndex, f := range container.Files {
+		if PathDepth(f.Path) != 1 || !hasExt(f.Path, ".jar") {
+			continue
+		}
+		if jarIndex != -1 {
+			// more than one, can't tell which one is the game
+			return nil, nil
+		}
+		jarIndex = int64(fileIndex)
+	}
+	if jarIndex == -1 {
+		return nil, nil
+	}
+
+	r, err := pool.GetReadSeeker(jarIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hasMagicAt(r, 0, zipMagic) {
+		return nil, nil
+	}
+
+	f := container.Files[jarIndex]
+	return &Candidate{
+		Size:    f.Size,
+		Path:    f.Path,
+		Mode:    f.Mode,
+		Depth:   PathDepth(f.Path),
+		Flavor:  FlavorJar,
+		JarInfo: &JarInfo{},
+	}, nil
+}
@@ -0,0 +1,118 @@
+package dash
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// maxJNLPSize is how much of a .jnlp file is parsed. Descriptors are
+// a few kilobytes at most.
+const maxJNLPSize = 256 * 1024
+
+type jnlpJar struct {
+	Href string `xml:"href,attr"`
+	Main string `xml:"main,attr"`
+}
+
+type jnlpDescriptor struct {
+	XMLName   xml.Name `xml:"jnlp"`
+	Codebase  string   `xml:"codebase,attr"`
+	Resources []struct {
+		Jars []jnlpJar `xml:"jar"`
+	} `xml:"resources"`
+	ApplicationDesc struct {
+		MainClass string `xml:"main-class,attr"`
+	} `xml:"application-desc"`
+	AppletDesc struct {
+		MainClass string `xml:"main-class,attr"`
+	} `xml:"applet-desc"`
+}
+
+// sniffJNLP returns a JNLP candidate for Java Web Start descriptors that
+// name a main class or reference at least one jar. Parsing is lenient:
+// whatever was read before a syntax error is used.
+func sniffJNLP(r io.ReadSeeker, size int64) (*Candidate, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(io.LimitReader(r, maxJNLPSize))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// descriptors are ASCII in practice, whatever they claim
+		return input, nil
+	}
+
+	var desc jnlpDescriptor
+	// mangled descriptors are common enough, don't make a fuss about it
+	_ = decoder.Decode(&desc)
+	if desc.XMLName.Local != "jnlp" {
+		return nil, nil
+	}
+
+	info := &JNLPInfo{
+		MainClass: desc.ApplicationDesc.MainClass,
+	}
+	if info.MainClass == "" {
+		info.MainClass = desc.AppletDesc.MainClass
+	}
+
+	codebase, _ := url.Parse(strings.TrimSpace(desc.Codebase))
+	remote := len(desc.Resources) > 0
+	for _, res := range desc.Resources {
+		for _, jar := range res.Jars {
+			href := strings.TrimSpace(jar.Href)
+			if href == "" {
+				continue
+			}
+			if strings.EqualFold(jar.Main, "true") {
+				info.MainJar = href
+			}
+			info.Jars = append(info.Jars, href)
+			if !isRemoteJNLPResource(codebase, href) {
+				remote = false
+			}
+		}
+	}
+	if info.MainJar == "" && len(info.Jars) > 0 {
+		// without a main="true" attribute, the first jar is the main one
+		info.MainJar = info.Jars[0]
+	}
+
+	if info.MainClass == "" && info.MainJar == "" {
+		return nil, nil
+	}
+	info.RequiresNetwork = remote && len(info.Jars) > 0
+
+	return &Candidate{
+		Flavor:   FlavorJNLP,
+		JNLPInfo: info,
+	}, nil
+}
+
+// isRemoteJNLPResource returns true if a resource referenced by a JNLP
+// descriptor has to be downloaded, either because it's an absolute http(s)
+// URL, or because it's relative to an http(s) codebase.
+func isRemoteJNLPResource(codebase *url.URL, href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	if u.IsAbs() {
+		return isRemoteScheme(u.Scheme)
+	}
+	return codebase != nil && isRemoteScheme(codebase.Scheme)
+}
+
+func isRemoteScheme(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "http", "https", "ftp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package dash
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// A LaunchSpec describes how to run a candidate: which program to start,
+// with which arguments, from which folder.
+type LaunchSpec struct {
+	// Path is the program to start. It's either absolute, or the name of
+	// a program to look up in the PATH, like `java` or `love`.
+	Path string
+	// Args are passed to the program, not including its name
+	Args []string
+	// Dir is the absolute folder to start the program from, the one that
+	// contains the candidate
+	Dir string
+}
+
+// LaunchSpec returns how to run a candidate on targetOS ("windows",
+// "linux" or "darwin", or empty to skip compatibility checks), given
+// basePath, the folder its path is relative to.
+//
+// Native executables (and engine flavors) are run as-is, .NET assemblies
+// are handed to `mono` outside of windows. Scripts are handed to their
+// interpreter, jars to `java -jar` (or the runtime they bundle), Java Web
+// Start descriptors to `javaws`, love2D bundles to `love`, app bundles
+// and installer packages to `open` on macOS, MSI packages to `msiexec`.
+//
+// It returns an error for candidates that can't run on targetOS, and for
+// those that can't be launched at all: archives and disk images (they
+// need extracting first), compressed executables (they need
+// decompressing first), HTML files, mobile packages, and DOS executables
+// that aren't run by DOSBox.
+func (c *Candidate) LaunchSpec(basePath string, targetOS string) (*LaunchSpec, error) {
+	fullPath, err := filepath.Abs(filepath.Join(basePath, filepath.FromSlash(c.Path)))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &LaunchSpec{
+		Path: fullPath,
+		Dir:  filepath.Dir(fullPath),
+	}
+	runsOn := func(oses ...string) error {
+		if targetOS == "" {
+			return nil
+		}
+		for _, o := range oses {
+			if o == targetOS {
+				return nil
+			}
+		}
+		return errors.Errorf("(%s) is a %s candidate, it doesn't run on %s", c.Path, c.Flavor, targetOS)
+	}
+	wrap := func(program string, args ...string) {
+		res.Path = program
+		res.Args = append(args, fullPath)
+	}
+
+	if isNativeFlavor(c.Flavor) || keepsNativeInfo(c.Flavor) {
+		if c.Compressed {
+			return nil, errors.Errorf("(%s) is compressed, it needs to be decompressed before it can be launched", c.Path)
+		}
+		if c.WindowsInfo != nil && c.WindowsInfo.DOS {
+			return nil, errors.Errorf("(%s) is a DOS executable, it needs DOSBox", c.Path)
+		}
+
+		switch candidateOS(c) {
+		case "windows":
+			if targetOS != "" && targetOS != "windows" {
+				if !runsOnMono(c) {
+					return nil, runsOn("windows")
+				}
+				wrap("mono")
+			}
+		case "linux":
+			if err := runsOn("linux"); err != nil {
+				return nil, err
+			}
+		case "darwin":
+			if err := runsOn("darwin"); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Flavor == FlavorDOSBox && c.DOSBoxInfo != nil && c.DOSBoxInfo.ConfigPath != "" {
+			confPath, err := filepath.Abs(filepath.Join(basePath, filepath.FromSlash(c.DOSBoxInfo.ConfigPath)))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			res.Args = []string{"-conf", confPath}
+		}
+
+		return res, nil
+	}
+
+	switch c.Flavor {
+	case FlavorArchive, FlavorDmgMacos:
+		return nil, errors.Errorf("(%s) needs to be extracted before it can be launched", c.Path)
+	case FlavorMobile:
+		return nil, errors.Errorf("(%s) is a mobile package, it doesn't run on desktop", c.Path)
+	case FlavorHTML:
+		return nil, errors.Errorf("(%s) is an HTML file, it needs to be opened in a browser", c.Path)
+
+	case FlavorAppMacos:
+		if err := runsOn("darwin"); err != nil {
+			return nil, err
+		}
+		// -W waits for the app to quit, like other launch targets
+		wrap("open", "-W")
+	case FlavorPkgMacos:
+		if err := runsOn("darwin"); err != nil {
+			return nil, err
+		}
+		wrap("open", "-W")
+	case FlavorMSI:
+		if err := runsOn("windows"); err != nil {
+			return nil, err
+		}
+		wrap("msiexec", "/i")
+
+	case FlavorScript, FlavorScriptMacos:
+		if c.Flavor == FlavorScriptMacos {
+			if err := runsOn("darwin"); err != nil {
+				return nil, err
+			}
+		} else if c.ScriptInfo != nil && c.ScriptInfo.LinuxOnly {
+			if err := runsOn("linux"); err != nil {
+				return nil, err
+			}
+		} else if err := runsOn("linux", "darwin"); err != nil {
+			return nil, err
+		}
+
+		interpreter := "/bin/sh"
+		if c.ScriptInfo != nil && c.ScriptInfo.Interpreter != "" {
+			interpreter = c.ScriptInfo.Interpreter
+		}
+		wrap(interpreter)
+	case FlavorScriptWindows:
+		if err := runsOn("windows"); err != nil {
+			return nil, err
+		}
+		wrap("cmd.exe", "/c")
+
+	case FlavorJar:
+		java := "java"
+		if isSelfContainedJar(c) {
+			javaPath, err := filepath.Abs(filepath.Join(basePath, filepath.FromSlash(c.JarInfo.BundledJava)))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			java = javaPath
+		}
+		wrap(java, "-jar")
+	case FlavorJNLP:
+		wrap("javaws")
+	case FlavorLove:
+		wrap("love")
+
+	default:
+		return nil, errors.Errorf("(%s) is a %s candidate, dash doesn't know how to launch those", c.Path, c.Flavor)
+	}
+
+	return res, nil
+}
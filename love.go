@@ -3,9 +3,16 @@ package dash
 import (
 	"bufio"
 	"io"
+	"path"
 	"regexp"
+	"strings"
+
+	"github.com/itchio/arkive/zip"
+	"github.com/itchio/lake/tlc"
 )
 
+var loveVersionRegexp = regexp.MustCompile(`t\.version\s*=\s*"([^"]+)"`)
+
 func sniffLove(r io.ReadSeeker, size int64, path string) (*Candidate, error) {
 	res := &Candidate{
 		Flavor:   FlavorLove,
@@ -13,18 +20,95 @@ func sniffLove(r io.ReadSeeker, size int64, path string) (*Candidate, error) {
 		LoveInfo: &LoveInfo{},
 	}
 
-	s := bufio.NewScanner(r)
+	res.LoveInfo.Version = readLoveVersion(r)
+
+	return res, nil
+}
 
-	re := regexp.MustCompile(`t\.version\s*=\s*"([^"]+)"`)
+// readLoveVersion looks for the version of love2D required by a conf.lua
+// file, and returns an empty string if it's not specified.
+func readLoveVersion(r io.Reader) string {
+	s := bufio.NewScanner(r)
 
 	for s.Scan() {
 		line := s.Bytes()
-		matches := re.FindSubmatch(line)
+		matches := loveVersionRegexp.FindSubmatch(line)
 		if len(matches) == 2 {
-			res.LoveInfo.Version = string(matches[1])
-			break
+			return string(matches[1])
 		}
 	}
 
-	return res, nil
+	return ""
+}
+
+// sniffFusedLove detects love2D games "fused" into an executable, ie.
+// a .love archive appended to the love2D executable. It returns nil if
+// the executable doesn't end with a zip, or if that zip doesn't look
+// like a love2D game (self-extracting archives and other executables
+// with a zip overlay don't have a main.lua at their root).
+// cf. https://love2d.org/wiki/Game_Distribution
+func sniffFusedLove(r io.ReadSeeker, size int64) *LoveInfo {
+	zr, err := zip.NewReader(readerAt(r), size)
+	if err != nil {
+		return nil
+	}
+
+	var info *LoveInfo
+	var conf *zip.File
+	for _, f := range zr.File {
+		switch path.Clean(f.Name) {
+		case "main.lua":
+			info = &LoveInfo{Fused: true}
+		case "conf.lua":
+			conf = f
+		}
+	}
+
+	if info != nil && conf != nil {
+		rc, err := conf.Open()
+		if err == nil {
+			defer rc.Close()
+			info.Version = readLoveVersion(rc)
+		}
+	}
+
+	return info
+}
+
+// Runtime files of love.js web exports, next to their index.html
+var loveJSSignatures = []string{"love.js", "love.wasm"}
+
+// detectLoveJSExports marks HTML candidates that sit next to a love.js
+// runtime as love.js web exports. Those often ship alongside the .love
+// bundle they were built from.
+func detectLoveJSExports(container *tlc.Container, candidates []*Candidate) {
+	loveJSDirs := make(map[string]bool)
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+		for _, sig := range loveJSSignatures {
+			if path.Base(lowerPath) == sig {
+				loveJSDirs[path.Dir(lowerPath)] = true
+			}
+		}
+	}
+	if len(loveJSDirs) == 0 {
+		return
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorHTML || !loveJSDirs[path.Dir(strings.ToLower(c.Path))] {
+			continue
+		}
+
+		if c.HTMLInfo == nil {
+			c.HTMLInfo = &HTMLInfo{}
+		}
+		if c.HTMLInfo.Engine == "" {
+			c.HTMLInfo.Engine = HTMLEngineLoveJS
+		}
+	}
+}
+
+func isLoveJSExport(c *Candidate) bool {
+	return c.HTMLInfo != nil && c.HTMLInfo.Engine == HTMLEngineLoveJS
 }
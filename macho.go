@@ -0,0 +1,98 @@
+package dash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// Mach-O magic numbers, cf. <mach-o/loader.h>
+	machoMagic32 = 0xFEEDFACE
+	machoMagic64 = 0xFEEDFACF
+
+	// load commands that name the minimum macOS version
+	machoLoadCmdVersionMinMacOS = 0x24
+	machoLoadCmdBuildVersion    = 0x32
+
+	// platform of LC_BUILD_VERSION for macOS (as opposed to iOS, etc.)
+	machoPlatformMacOS = 1
+
+	// real executables have a few dozen load commands, which take a few
+	// kilobytes at most. anything bigger is left alone.
+	machoMaxLoadCommands     = 1024
+	machoMaxLoadCommandsSize = 256 * 1024
+)
+
+// readMachOMinVersion returns the minimum macOS version a thin Mach-O
+// executable found at the given offset targets, as an encoded version
+// (xxxx.yy.zz nibbles), or 0 if it doesn't say or can't be read.
+func readMachOMinVersion(r io.ReadSeeker, offset int64) uint32 {
+	header, err := readBytesAt(r, offset, 28)
+	if err != nil {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	var headerSize int64
+	for _, o := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch o.Uint32(header[0:4]) {
+		case machoMagic32:
+			order, headerSize = o, 28
+		case machoMagic64:
+			order, headerSize = o, 32
+		}
+	}
+	if order == nil {
+		return 0
+	}
+
+	numCommands := order.Uint32(header[16:20])
+	commandsSize := order.Uint32(header[20:24])
+	if numCommands > machoMaxLoadCommands || commandsSize > machoMaxLoadCommandsSize {
+		return 0
+	}
+
+	commands, err := readBytesAt(r, offset+headerSize, int(commandsSize))
+	if err != nil {
+		// truncated
+		return 0
+	}
+
+	for i := uint32(0); i < numCommands && len(commands) >= 8; i++ {
+		cmd := order.Uint32(commands[0:4])
+		cmdSize := order.Uint32(commands[4:8])
+		if cmdSize < 8 || uint64(cmdSize) > uint64(len(commands)) {
+			return 0
+		}
+
+		switch cmd {
+		case machoLoadCmdVersionMinMacOS:
+			// version, then sdk
+			if cmdSize >= 16 {
+				return order.Uint32(commands[8:12])
+			}
+		case machoLoadCmdBuildVersion:
+			// platform, minos, sdk, then the tools
+			if cmdSize >= 24 && order.Uint32(commands[8:12]) == machoPlatformMacOS {
+				return order.Uint32(commands[12:16])
+			}
+		}
+		commands = commands[cmdSize:]
+	}
+	return 0
+}
+
+// formatMachOVersion formats an encoded version (xxxx.yy.zz nibbles) like
+// "10.9" or "10.13.4", or returns an empty string for 0.
+func formatMachOVersion(version uint32) string {
+	if version == 0 {
+		return ""
+	}
+
+	major, minor, patch := version>>16, (version>>8)&0xFF, version&0xFF
+	if patch == 0 {
+		return fmt.Sprintf("%d.%d", major, minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+}
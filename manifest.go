@@ -0,0 +1,144 @@
+package dash
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// maxWebManifestSize is how much of a web app manifest is read, they're
+// usually a few hundred bytes
+const maxWebManifestSize = 256 * 1024
+
+// isWebManifest returns true for files named like web app manifests:
+// `manifest.json`, or anything ending in `.webmanifest`
+func isWebManifest(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	return base == "manifest.json" || strings.HasSuffix(base, ".webmanifest")
+}
+
+// detectWebManifests looks for web app manifests whose `start_url` points
+// to an HTML file in the container, and marks that file as the page the
+// manifest starts. It's added as an HTML candidate if it isn't one already,
+// since the page it points to isn't necessarily named index.html.
+func detectWebManifests(pool lake.Pool, container *tlc.Container, candidates []*Candidate) ([]*Candidate, error) {
+	fileIndices := make(map[string]int64)
+	for fileIndex, f := range container.Files {
+		fileIndices[strings.ToLower(f.Path)] = int64(fileIndex)
+	}
+
+	for fileIndex, f := range container.Files {
+		if !isWebManifest(f.Path) {
+			continue
+		}
+
+		startURL, err := readStartURL(pool, int64(fileIndex))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading web manifest (%s)", f.Path)
+		}
+		startPath, ok := resolveStartURL(path.Dir(f.Path), startURL)
+		if !ok || !(hasExt(startPath, ".html") || hasExt(startPath, ".htm")) {
+			continue
+		}
+		startIndex, ok := fileIndices[strings.ToLower(startPath)]
+		if !ok {
+			continue
+		}
+		startFile := container.Files[startIndex]
+
+		var candidate *Candidate
+		for _, c := range candidates {
+			if c.Path == startFile.Path {
+				candidate = c
+				break
+			}
+		}
+		if candidate == nil {
+			candidate = &Candidate{
+				Size:   startFile.Size,
+				Path:   startFile.Path,
+				Mode:   startFile.Mode,
+				Depth:  PathDepth(startFile.Path),
+				Flavor: FlavorHTML,
+			}
+			candidates = append(candidates, candidate)
+		}
+		if candidate.Flavor != FlavorHTML {
+			continue
+		}
+
+		if candidate.HTMLInfo == nil {
+			candidate.HTMLInfo = &HTMLInfo{}
+		}
+		if candidate.HTMLInfo.WebManifestPath == "" {
+			candidate.HTMLInfo.WebManifestPath = f.Path
+		}
+	}
+
+	return candidates, nil
+}
+
+// readStartURL returns the `start_url` of a web app manifest. Malformed
+// manifests don't have one.
+func readStartURL(pool lake.Pool, fileIndex int64) (string, error) {
+	r, err := pool.GetReadSeeker(fileIndex)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxWebManifestSize))
+	if err != nil {
+		return "", err
+	}
+
+	var manifest struct {
+		StartURL string `json:"start_url"`
+	}
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return "", nil
+	}
+	return manifest.StartURL, nil
+}
+
+// resolveStartURL turns the `start_url` of a web app manifest in dir into
+// a path relative to the container. Query strings and fragments are
+// dropped, URLs that point to a folder (like `.` or `./`) get `index.html`,
+// and absolute paths are relative to the root of the container. URLs to
+// other sites, or outside of the container, aren't resolved.
+func resolveStartURL(dir string, startURL string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(startURL))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" {
+		return "", false
+	}
+
+	p := u.Path
+	if strings.HasPrefix(p, "/") {
+		dir = "."
+		p = strings.TrimLeft(p, "/")
+	}
+
+	res := path.Join(dir, p)
+	if p == "" || strings.HasSuffix(p, "/") || path.Base(p) == "." || path.Base(p) == ".." {
+		res = path.Join(res, "index.html")
+	}
+	if res == ".." || strings.HasPrefix(res, "../") {
+		return "", false
+	}
+	return res, true
+}
+
+func isWebManifestStart(c *Candidate) bool {
+	return c.HTMLInfo != nil && c.HTMLInfo.WebManifestPath != ""
+}
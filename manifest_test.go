@@ -0,0 +1,33 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveStartURL(t *testing.T) {
+	assert := assert.New(t)
+
+	resolve := func(dir string, startURL string) string {
+		res, ok := resolveStartURL(dir, startURL)
+		if !ok {
+			return "<none>"
+		}
+		return res
+	}
+
+	assert.EqualValues("play.html", resolve(".", "play.html"))
+	assert.EqualValues("play.html", resolve(".", "./play.html?source=pwa"))
+	assert.EqualValues("web/play.html", resolve("web", "play.html#title"))
+	assert.EqualValues("web/index.html", resolve("web", "."))
+	assert.EqualValues("web/index.html", resolve("web", "./"))
+	assert.EqualValues("web/index.html", resolve("web", "?utm_source=homescreen"))
+	assert.EqualValues("index.html", resolve("web", ".."))
+	assert.EqualValues("game/index.html", resolve("web", "/game/"))
+	assert.EqualValues("web/my game.html", resolve("web", "my%20game.html"))
+
+	assert.EqualValues("<none>", resolve(".", "https://example.org/play.html"))
+	assert.EqualValues("<none>", resolve(".", "//example.org/play.html"))
+	assert.EqualValues("<none>", resolve(".", "../play.html"))
+}
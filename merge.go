@@ -0,0 +1,65 @@
+package dash
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// MergeVerdicts combines verdicts for parts of the same folder (like a
+// game and its DLC, extracted from separate archives into basePath and
+// configured one by one), which must all have basePath as their BasePath,
+// give or take resolving relative paths against the working directory.
+//
+// Candidates are deep copies, in order, and candidates with the same path
+// only appear once, as they were in the first verdict that had them. Total
+// sizes are added up, and warnings are all kept. The result can be ranked
+// with Filter, like a verdict returned by Configure.
+func MergeVerdicts(basePath string, verdicts ...*Verdict) (*Verdict, error) {
+	res := &Verdict{
+		BasePath:   basePath,
+		Candidates: make([]*Candidate, 0),
+	}
+
+	var diagnosis *Diagnosis
+	seen := make(map[string]bool)
+	for i, v := range verdicts {
+		if v == nil {
+			continue
+		}
+		if !sameBasePath(v.BasePath, basePath) {
+			return nil, errors.Errorf("verdict %d has base path (%s), expected (%s)", i, v.BasePath, basePath)
+		}
+
+		res.TotalSize += v.TotalSize
+		res.Warnings = append(res.Warnings, v.Warnings...)
+		for _, c := range v.Candidates {
+			if seen[c.Path] {
+				continue
+			}
+			seen[c.Path] = true
+			res.Candidates = append(res.Candidates, c.Clone())
+		}
+		if diagnosis == nil && v.Diagnosis != nil {
+			d := *v.Diagnosis
+			diagnosis = &d
+		}
+	}
+
+	if len(res.Candidates) == 0 {
+		// none of the parts had anything, the first explanation will do
+		res.Diagnosis = diagnosis
+	}
+	return res, nil
+}
+
+// sameBasePath tells whether two base paths are the same folder, even if
+// one of them is relative and the other isn't.
+func sameBasePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,130 @@
+package dash
+
+import (
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+)
+
+// Files that come with a bundled Mono runtime (like MonoKickstart), by
+// lower-cased name or name prefix
+var monoRuntimeNames = []string{
+	"monoconfig",
+	"monomachineconfig",
+	"mono",
+	"mono64",
+}
+
+var monoRuntimePrefixes = []string{
+	"libmonosgen-2.0.",
+	"libmono-2.0.",
+}
+
+// monoWrapperRegexp matches scripts that hand an assembly over to mono
+var monoWrapperRegexp = regexp.MustCompile(`(^|[\s/"'])mono(64)?\s`)
+
+// monoWrapperHeadSize is how much of a script is read to find a mono
+// invocation
+const monoWrapperHeadSize = 4 * 1024
+
+// detectMonoRuntimes looks for what lets .NET assemblies run on linux and
+// macOS: a Mono runtime shipped in the same folder (or one of its direct
+// subfolders), or a script next to them that runs them with mono.
+func detectMonoRuntimes(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	runtimeByDir := make(map[string]string)
+	for _, f := range container.Files {
+		if isMonoRuntimeFile(f.Path) {
+			dir := path.Dir(strings.ToLower(f.Path))
+			runtimeByDir[dir] = f.Path
+			if dir != "." {
+				// runtimes are often in a lib/ or lib64/ folder
+				runtimeByDir[path.Dir(dir)] = f.Path
+			}
+		}
+	}
+
+	fileIndices := make(map[string]int64)
+	for fileIndex, f := range container.Files {
+		fileIndices[f.Path] = int64(fileIndex)
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorNativeWindows || c.WindowsInfo == nil || !c.WindowsInfo.DotNet {
+			continue
+		}
+
+		dir := path.Dir(strings.ToLower(c.Path))
+		if runtime, ok := runtimeByDir[dir]; ok {
+			c.WindowsInfo.MonoRuntime = runtime
+			continue
+		}
+
+		for _, s := range candidates {
+			if s.Flavor != FlavorScript || path.Dir(strings.ToLower(s.Path)) != dir {
+				continue
+			}
+			fileIndex, ok := fileIndices[s.Path]
+			if !ok {
+				continue
+			}
+
+			r, err := pool.GetReadSeeker(fileIndex)
+			if err != nil {
+				return err
+			}
+			wraps, err := isMonoWrapper(r, path.Base(c.Path))
+			if err != nil {
+				return err
+			}
+			if wraps {
+				c.WindowsInfo.MonoRuntime = s.Path
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func isMonoRuntimeFile(p string) bool {
+	name := strings.ToLower(path.Base(p))
+	for _, n := range monoRuntimeNames {
+		if name == n {
+			return true
+		}
+	}
+	for _, prefix := range monoRuntimePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMonoWrapper returns true if a script runs mono and mentions the
+// assembly by name.
+func isMonoWrapper(r io.ReadSeeker, assemblyName string) (bool, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return false, err
+	}
+
+	head := make([]byte, monoWrapperHeadSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	script := string(head[:n])
+
+	return monoWrapperRegexp.MatchString(script) &&
+		strings.Contains(strings.ToLower(script), strings.ToLower(assemblyName)), nil
+}
+
+// runsOnMono returns true for .NET assemblies that ship with what they
+// need to run on linux and macOS
+func runsOnMono(c *Candidate) bool {
+	return c.WindowsInfo != nil && c.WindowsInfo.DotNet && c.WindowsInfo.MonoRuntime != ""
+}
@@ -0,0 +1,53 @@
+package dash
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// FullPath returns the absolute path of a candidate on disk, ie. its path
+// joined with the verdict's BasePath, resolved against the working directory
+// if BasePath is relative.
+func (v *Verdict) FullPath(c *Candidate) string {
+	fullPath := filepath.Join(v.BasePath, filepath.FromSlash(c.Path))
+	if absPath, err := filepath.Abs(fullPath); err == nil {
+		return absPath
+	}
+	return fullPath
+}
+
+// WithAbsolutePaths returns something that marshals to the same JSON as
+// the verdict, except every candidate also has an `absolutePath` field.
+// Absolute paths say a lot about the machine they come from, which is
+// why they're left out unless asked for.
+func (v *Verdict) WithAbsolutePaths() json.Marshaler {
+	return &verdictWithAbsolutePaths{v}
+}
+
+type verdictWithAbsolutePaths struct {
+	v *Verdict
+}
+
+type candidateWithAbsolutePath struct {
+	*Candidate
+	AbsolutePath string `json:"absolutePath"`
+}
+
+func (vwap *verdictWithAbsolutePaths) MarshalJSON() ([]byte, error) {
+	type verdict Verdict
+	payload := struct {
+		*verdict
+		Candidates []candidateWithAbsolutePath `json:"candidates"`
+	}{
+		verdict:    (*verdict)(vwap.v),
+		Candidates: make([]candidateWithAbsolutePath, 0, len(vwap.v.Candidates)),
+	}
+
+	for _, c := range vwap.v.Candidates {
+		payload.Candidates = append(payload.Candidates, candidateWithAbsolutePath{
+			Candidate:    c,
+			AbsolutePath: vwap.v.FullPath(c),
+		})
+	}
+	return json.Marshal(payload)
+}
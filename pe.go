@@ -1,15 +1,28 @@
 package dash
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
-
-	"github.com/itchio/spellbook"
-	"github.com/itchio/wizardry/wizardry/wizutil"
 )
 
 func sniffPE(r io.ReadSeeker, size int64) (*Candidate, error) {
-	sr := wizutil.NewSliceReader(&readerAtFromSeeker{r}, 0, size)
-	spell := spellbook.Identify(sr, 0)
+	machine, ok := readPEMachine(r)
+	if !ok {
+		if hasMagicAt(r, 0, []byte("MZ")) {
+			// MS-DOS executables only have an MZ header, they're not
+			// something windows can run by itself (think DOSBox games)
+			return &Candidate{
+				Flavor: FlavorNativeWindows,
+				WindowsInfo: &WindowsInfo{
+					DOS: true,
+				},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	spell := identifySpell(r, size)
 
 	if !spellHas(spell, "PE") {
 		// uh oh
@@ -22,10 +35,21 @@ func sniffPE(r io.ReadSeeker, size int64) (*Candidate, error) {
 		WindowsInfo: &WindowsInfo{},
 	}
 
-	if spellHas(spell, "\\b32 executable") {
+	switch machine {
+	case peMachineI386:
 		result.Arch = Arch386
-	} else if spellHas(spell, "\\b32+ executable") {
+	case peMachineAmd64:
 		result.Arch = ArchAmd64
+	case peMachineArm64:
+		result.Arch = ArchArm64
+	case peMachineArmNT:
+		result.Arch = ArchArm
+	default:
+		if spellHas(spell, "\\b32 executable") {
+			result.Arch = Arch386
+		} else if spellHas(spell, "\\b32+ executable") {
+			result.Arch = ArchAmd64
+		}
 	}
 
 	if spellHas(spell, "\\b, InnoSetup installer") {
@@ -39,13 +63,269 @@ func sniffPE(r io.ReadSeeker, size int64) (*Candidate, error) {
 		result.WindowsInfo.InstallerType = WindowsInstallerTypeArchive
 	}
 
+	if result.WindowsInfo.InstallerType == "" {
+		result.WindowsInfo.InstallerType = scanInstallerSignatures(r, size)
+	}
+
+	result.LoveInfo = sniffFusedLove(r, size)
+
+	if isClickTeamRuntime(r, size) {
+		result.Flavor = FlavorClickTeam
+	}
+
 	if spellHas(spell, "(GUI)") {
 		result.WindowsInfo.Gui = true
 	}
 
-	if spellHas(spell, "Mono/.Net assembly") {
+	if spellHas(spell, "Mono/.Net assembly") || hasCLRHeader(r) {
 		result.WindowsInfo.DotNet = true
 	}
 
 	return result, nil
 }
+
+const (
+	peMachineI386  = 0x14c
+	peMachineArmNT = 0x1c4
+	peMachineAmd64 = 0x8664
+	peMachineArm64 = 0xaa64
+)
+
+// readPEMachine returns the Machine field of a PE file header, and
+// false if the file doesn't have a PE header.
+func readPEMachine(r io.ReadSeeker) (uint16, bool) {
+	// the offset of the PE header is stored at 0x3C, in the MS-DOS stub
+	lfanew, err := readBytesAt(r, 0x3C, 4)
+	if err != nil {
+		return 0, false
+	}
+	peOffset := int64(binary.LittleEndian.Uint32(lfanew))
+
+	// the PE header starts with "PE\0\0", followed by the Machine field
+	header, err := readBytesAt(r, peOffset, 6)
+	if err != nil {
+		return 0, false
+	}
+	if string(header[:4]) != "PE\x00\x00" {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint16(header[4:6]), true
+}
+
+// peCLRDirectory is the index of the data directory that points to the
+// CLR runtime header, which only managed (.NET) executables have
+const peCLRDirectory = 14
+
+// hasCLRHeader returns true if a PE file has a CLR runtime header, ie.
+// it's a managed (.NET) assembly.
+func hasCLRHeader(r io.ReadSeeker) bool {
+	lfanew, err := readBytesAt(r, 0x3C, 4)
+	if err != nil {
+		return false
+	}
+	// the optional header follows the 4-byte signature and the
+	// 20-byte file header
+	optOffset := int64(binary.LittleEndian.Uint32(lfanew)) + 24
+
+	magic, err := readBytesAt(r, optOffset, 2)
+	if err != nil {
+		return false
+	}
+
+	// NumberOfRvaAndSizes comes right before the data directories
+	var dirsOffset int64
+	switch binary.LittleEndian.Uint16(magic) {
+	case 0x10b: // PE32
+		dirsOffset = optOffset + 96
+	case 0x20b: // PE32+
+		dirsOffset = optOffset + 112
+	default:
+		return false
+	}
+
+	numDirs, err := readBytesAt(r, dirsOffset-4, 4)
+	if err != nil || binary.LittleEndian.Uint32(numDirs) <= peCLRDirectory {
+		return false
+	}
+
+	dir, err := readBytesAt(r, dirsOffset+peCLRDirectory*8, 8)
+	if err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint32(dir[0:4]) != 0 && binary.LittleEndian.Uint32(dir[4:8]) != 0
+}
+
+// Actual executables have a few sections, the PE loader refuses
+// more than this
+const peMaxSections = 96
+
+// peSection is where the raw data of a PE section is in the file
+type peSection struct {
+	name   string
+	offset int64
+	size   int64
+}
+
+// readPESections returns the sections of a PE file, and false if its
+// section table can't be read.
+func readPESections(r io.ReadSeeker) ([]peSection, bool) {
+	lfanew, err := readBytesAt(r, 0x3C, 4)
+	if err != nil {
+		return nil, false
+	}
+	peOffset := int64(binary.LittleEndian.Uint32(lfanew))
+
+	// NumberOfSections and SizeOfOptionalHeader are in the file header,
+	// which follows the 4-byte signature. section headers come right
+	// after the optional header.
+	header, err := readBytesAt(r, peOffset, 24)
+	if err != nil || string(header[:4]) != "PE\x00\x00" {
+		return nil, false
+	}
+	numSections := int(binary.LittleEndian.Uint16(header[6:8]))
+	if numSections > peMaxSections {
+		return nil, false
+	}
+	sectionsOffset := peOffset + 24 + int64(binary.LittleEndian.Uint16(header[20:22]))
+
+	table, err := readBytesAt(r, sectionsOffset, numSections*40)
+	if err != nil {
+		return nil, false
+	}
+	sections := make([]peSection, numSections)
+	for i := range sections {
+		// SizeOfRawData, then PointerToRawData
+		header := table[i*40:]
+		sections[i] = peSection{
+			name:   string(bytes.TrimRight(header[:8], "\x00")),
+			offset: int64(binary.LittleEndian.Uint32(header[20:24])),
+			size:   int64(binary.LittleEndian.Uint32(header[16:20])),
+		}
+	}
+	return sections, true
+}
+
+// peOverlayOffset returns where the data appended to a PE file (its
+// overlay) starts: right after the section that ends last.
+func peOverlayOffset(sections []peSection) int64 {
+	var res int64
+	for _, s := range sections {
+		if end := s.offset + s.size; end > res {
+			res = end
+		}
+	}
+	return res
+}
+
+// Installers that wizardry doesn't recognize still leave traces in their
+// resources, manifests, or in the data appended to them.
+var installerSignatures = []struct {
+	marker        []byte
+	installerType WindowsInstallerType
+}{
+	{[]byte("NullsoftInst"), WindowsInstallerTypeNullsoft},
+	{[]byte("Inno Setup Setup Data"), WindowsInstallerTypeInno},
+	{[]byte("JR.Inno.Setup"), WindowsInstallerTypeInno},
+	// names of the setup stream, and of the prerequisites it installs.
+	// games mention InstallShield itself (in their credits, or in the
+	// redistributables they ship), so that's not enough.
+	{[]byte("ISSetupStream"), WindowsInstallerTypeInstallShield},
+	{[]byte("ISSetupPrerequisites"), WindowsInstallerTypeInstallShield},
+	{[]byte("MSI Wrapper"), WindowsInstallerTypeMsiWrapper},
+}
+
+// InstallShield setups carry their files in data appended to them,
+// which starts with this header
+var installShieldOverlayMagic = []byte("InstallShield\x00")
+
+// Scanning whole executables would be too slow for big games. Markers
+// are found in resources (manifests, version info), and at the start of
+// appended data, so only that much of each is scanned.
+const installerScanSize = 1024 * 1024
+
+// The scan reads that much at a time, so sniffing many executables
+// at once doesn't hold megabytes each
+const installerScanChunkSize = 64 * 1024
+
+// scanInstallerSignatures looks for installer markers in the resource
+// section and the appended data (overlay) of a PE file, and for the header
+// of InstallShield's appended data, and returns the matching installer
+// type, or an empty string if none was found.
+func scanInstallerSignatures(r io.ReadSeeker, size int64) WindowsInstallerType {
+	sections, ok := readPESections(r)
+	if !ok {
+		return ""
+	}
+
+	// markers can straddle two chunks, so each chunk is scanned along
+	// with the end of the previous one
+	overlap := 0
+	for _, sig := range installerSignatures {
+		if len(sig.marker)-1 > overlap {
+			overlap = len(sig.marker) - 1
+		}
+	}
+	buf := make([]byte, installerScanChunkSize+overlap)
+
+	// signatures that come first win, wherever they are
+	found := len(installerSignatures)
+	scan := func(offset int64, length int64) {
+		if length > installerScanSize {
+			length = installerScanSize
+		}
+		if offset+length > size {
+			length = size - offset
+		}
+		if length <= 0 {
+			return
+		}
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+
+		kept := 0
+		for scanned := int64(0); scanned < length && found > 0; {
+			chunkSize := int64(installerScanChunkSize)
+			if length-scanned < chunkSize {
+				chunkSize = length - scanned
+			}
+
+			n, err := io.ReadFull(r, buf[kept:kept+int(chunkSize)])
+			window := buf[:kept+n]
+			for i, sig := range installerSignatures[:found] {
+				if bytes.Contains(window, sig.marker) {
+					found = i
+					break
+				}
+			}
+			if err != nil {
+				return
+			}
+			scanned += int64(n)
+
+			tail := overlap
+			if tail > len(window) {
+				tail = len(window)
+			}
+			kept = copy(buf, window[len(window)-tail:])
+		}
+	}
+
+	for _, section := range sections {
+		if section.name == ".rsrc" {
+			scan(section.offset, section.size)
+		}
+	}
+	overlayOffset := peOverlayOffset(sections)
+	scan(overlayOffset, size-overlayOffset)
+
+	if found < len(installerSignatures) {
+		return installerSignatures[found].installerType
+	}
+
+	if hasMagicAt(r, overlayOffset, installShieldOverlayMagic) {
+		return WindowsInstallerTypeInstallShield
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package dash
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/itchio/pelican"
+	"github.com/pkg/errors"
+)
+
+// versionInfoProbeTimeout bounds how long reading the version info of a
+// single executable may take, so a pathological one can't stall Configure.
+// It's shortened in tests.
+var versionInfoProbeTimeout = 10 * time.Second
+
+// readPEVersionInfo fills in the version properties (product name,
+// description, etc.) of a windows executable. Executables without a
+// version resource, that pelican can't make sense of (or panics on), or
+// files on disk that take too long to probe, are left alone.
+func readPEVersionInfo(r io.ReadSeeker, size int64, info *WindowsInfo) {
+	if !peSectionsFit(r, size) {
+		// pelican allocates as much as section headers say, even when
+		// the file is nowhere near as big
+		return
+	}
+
+	var peInfo *pelican.PeInfo
+	var err error
+	if f, ok := r.(*os.File); ok {
+		// files on disk go through the probe cache, so Filter doesn't
+		// probe them again when looking for installers. The probe gets
+		// its own handle, since it closes it.
+		pf, openErr := os.Open(f.Name())
+		if openErr != nil {
+			return
+		}
+		peInfo, _, err = probeCachedPE(pf, versionInfoProbeTimeout)
+	} else {
+		peInfo, err = probeSharedPE(&sniffedFile{
+			ReadSeeker: r,
+			ReaderAt:   readerAt(r),
+			size:       size,
+		})
+	}
+	if err != nil {
+		return
+	}
+
+	setPEVersionInfo(info, peInfo.VersionProperties)
+}
+
+// probeSharedPE probes a reader the rest of sniffing uses too. There's no
+// timeout, since a probe that timed out may still be reading from it, but
+// pelican's panics are still turned into errors.
+func probeSharedPE(sf *sniffedFile) (info *pelican.PeInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			info = nil
+			err = errors.Errorf("pelican panicked: %v", r)
+		}
+	}()
+
+	info, _, err = probePE(sf, 0)
+	return info, err
+}
+
+// setPEVersionInfo fills in the version properties of info from those
+// pelican found
+func setPEVersionInfo(info *WindowsInfo, props map[string]string) {
+	info.ProductName = props["ProductName"]
+	info.FileDescription = props["FileDescription"]
+	info.CompanyName = props["CompanyName"]
+	info.OriginalFilename = props["OriginalFilename"]
+}
+
+// peSectionsFit returns true if the raw data of every section of a PE
+// file lies within the file, ie. if it's not truncated or lying.
+func peSectionsFit(r io.ReadSeeker, size int64) bool {
+	sections, ok := readPESections(r)
+	return ok && peOverlayOffset(sections) <= size
+}
+
+// sniffedFile adapts a file being sniffed to what pelican expects
+type sniffedFile struct {
+	io.ReadSeeker
+	io.ReaderAt
+	size int64
+}
+
+func (sf *sniffedFile) Close() error {
+	// whoever gave us the reader closes it
+	return nil
+}
+
+func (sf *sniffedFile) Stat() (os.FileInfo, error) {
+	return &sniffedFileInfo{size: sf.size}, nil
+}
+
+type sniffedFileInfo struct {
+	size int64
+}
+
+var _ os.FileInfo = (*sniffedFileInfo)(nil)
+
+func (sfi *sniffedFileInfo) Name() string       { return "" }
+func (sfi *sniffedFileInfo) Size() int64        { return sfi.size }
+func (sfi *sniffedFileInfo) Mode() os.FileMode  { return 0644 }
+func (sfi *sniffedFileInfo) ModTime() time.Time { return time.Time{} }
+func (sfi *sniffedFileInfo) IsDir() bool        { return false }
+func (sfi *sniffedFileInfo) Sys() interface{}   { return nil }
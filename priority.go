@@ -0,0 +1,113 @@
+package dash
+
+// engineFlavors are the flavors of games made with an engine (or shipped
+// in an app shell), in the order Filter picks them: a single candidate of
+// one of those flavors wins over loose executables, like the tools,
+// helpers and patchers shipped with the game, or the runtime's own
+// executables (Flash Player, AIR...).
+var engineFlavors = []struct {
+	flavor Flavor
+	// what Filter calls candidates of that flavor when it picks one
+	name string
+}{
+	{FlavorGodot, "Godot candidate"},
+	{FlavorGameMaker, "GameMaker candidate"},
+	{FlavorUnity, "Unity candidate"},
+	{FlavorUnreal, "Unreal Engine candidate"},
+	{FlavorAIR, "AIR candidate"},
+	{FlavorFlashProjector, "Flash projector"},
+	{FlavorClickTeam, "ClickTeam Fusion candidate"},
+	{FlavorNWjs, "NW.js candidate"},
+	{FlavorElectron, "Electron candidate"},
+}
+
+// isEngineFlavor returns true for the flavors listed in engineFlavors
+func isEngineFlavor(f Flavor) bool {
+	for _, ef := range engineFlavors {
+		if ef.flavor == f {
+			return true
+		}
+	}
+	return false
+}
+
+// losingFlavors are the flavors of candidates that lose against anything
+// else, in the order Filter leaves them out: HTML files first, then things
+// that need extracting, then jars (unless they bring their own runtime).
+var losingFlavors = []struct {
+	flavors []Flavor
+	// what Filter calls candidates of those flavors when it leaves them out
+	name string
+	// why it leaves them out
+	reason string
+}{
+	{[]Flavor{FlavorHTML}, "HTML", "HTML, and there are non-HTML candidates"},
+	{[]Flavor{FlavorArchive, FlavorDmgMacos}, "archive", "archive, and there are other candidates"},
+	{[]Flavor{FlavorJar}, "JAR", "JAR without a bundled runtime, and there are other candidates"},
+}
+
+// defaultFlavorTier is where flavors Filter has no preference for go,
+// native executables first among them
+var defaultFlavorTier = []Flavor{
+	FlavorNativeWindows,
+	FlavorNativeMacos,
+	FlavorNativeLinux,
+	FlavorDOSBox,
+}
+
+// flavorTiers lists flavors from most to least preferred when there's no
+// PreferFlavors. Engine flavors, and flavors that lose against anything
+// else, come from the tables Filter goes through; the others follow its
+// OS-specific rules. Flavors in the same tier are equally preferred, and
+// Filter's scoring decides between them.
+var flavorTiers = func() [][]Flavor {
+	tiers := [][]Flavor{
+		// love bundles always win
+		{FlavorLove},
+	}
+	for _, ef := range engineFlavors {
+		tiers = append(tiers, []Flavor{ef.flavor})
+	}
+	tiers = append(tiers,
+		// scripts usually start the game with the right arguments, so they
+		// win over what they start, on the OS they're for
+		[]Flavor{FlavorScriptMacos},
+		[]Flavor{FlavorAppMacos},
+		[]Flavor{FlavorScriptWindows},
+		[]Flavor{FlavorScript},
+		defaultFlavorTier,
+		// Java Web Start descriptors only win when there are no native
+		// executables
+		[]Flavor{FlavorJNLP},
+		// installers are left out on windows, and on macOS if there's
+		// anything else
+		[]Flavor{FlavorMSI, FlavorPkgMacos},
+	)
+	for i := len(losingFlavors) - 1; i >= 0; i-- {
+		tiers = append(tiers, losingFlavors[i].flavors)
+	}
+	// mobile packages never run on desktop
+	return append(tiers, []Flavor{FlavorMobile})
+}()
+
+// FlavorPriority returns how much Filter prefers candidates of a flavor by
+// default, for callers that rank candidates themselves and want to stay
+// consistent with it, or that build PreferFlavors from it. Higher values
+// win: love bundles come first, then engine flavors (Godot, Unity...),
+// then scripts and app bundles, then native executables, then JNLP
+// descriptors, installers, jars, archives, HTML files, and mobile
+// packages. Flavors registered with RegisterFlavor (and any other flavor
+// Filter has no preference for) have the same priority as native
+// executables.
+//
+// It's a baseline: the actual rules depend on the OS Filter runs for
+// (macOS scripts only win on macOS, jars only win over native executables
+// that can't run...), on the arch, and on how deep candidates are.
+func FlavorPriority(f Flavor) int {
+	for i, tier := range flavorTiers {
+		if hasFlavor(tier, f) {
+			return len(flavorTiers) - i
+		}
+	}
+	return FlavorPriority(defaultFlavorTier[0])
+}
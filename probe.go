@@ -0,0 +1,154 @@
+package dash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/itchio/pelican"
+	"github.com/pkg/errors"
+)
+
+// errProbeTimeout is returned by probePE when pelican takes too long
+var errProbeTimeout = errors.New("pelican probe timed out")
+
+// peFile is what pelican needs to probe a windows executable
+type peFile interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// pelicanProbe is replaced in tests, to simulate probes that hang
+var pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+	return pelican.Probe(f, params)
+}
+
+type probeResult struct {
+	info *pelican.PeInfo
+	log  []string
+	err  error
+}
+
+// probePE inspects a windows executable with pelican, and returns what it
+// found along with pelican's log. It takes ownership of f, and closes it.
+//
+// Without a timeout, pelican runs on the caller's goroutine, and its panics
+// reach the caller. With a non-zero timeout, it runs on its own goroutine,
+// its panics are returned as errors, and probePE gives up after that long
+// and returns errProbeTimeout right away. Every read pelican makes after
+// that fails, so the probe stops at its next read: only the work pelican
+// does between two reads, and a read that was already in progress (unless
+// closing f interrupts it), can outlive the timeout. Until then, f may
+// still be read from, so callers must not share the reader behind it.
+func probePE(f peFile, timeout time.Duration) (*pelican.PeInfo, []string, error) {
+	if timeout == 0 {
+		defer f.Close()
+
+		var res probeResult
+		res.info, res.err = pelicanProbe(f, pelican.ProbeParams{
+			Consumer: res.consumer(),
+		})
+		return res.info, res.log, res.err
+	}
+
+	probe := pelicanProbe
+	cf := &cancellableFile{peFile: f, cancelled: make(chan struct{})}
+	done := make(chan probeResult, 1)
+	go func() {
+		var res probeResult
+		defer func() {
+			if r := recover(); r != nil {
+				res.info = nil
+				res.err = errors.Errorf("pelican panicked: %v", r)
+			}
+			// buffered, so this never blocks, even if nobody is waiting anymore
+			done <- res
+		}()
+
+		res.info, res.err = probe(cf, pelican.ProbeParams{
+			Consumer: res.consumer(),
+		})
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		f.Close()
+		return res.info, res.log, res.err
+	case <-timer.C:
+		cf.cancel()
+		// interrupts reads that would block forever, like reads from pipes
+		f.Close()
+		return nil, nil, errProbeTimeout
+	}
+}
+
+// consumer returns a consumer that collects pelican's messages in res.log
+func (res *probeResult) consumer() *state.Consumer {
+	return &state.Consumer{
+		OnMessage: func(lvl string, msg string) {
+			res.log = append(res.log, fmt.Sprintf("pelican> [%s] %s", lvl, msg))
+		},
+	}
+}
+
+// cancellableFile fails every read and seek once cancelled, so that probes
+// that timed out stop instead of carrying on in the background
+type cancellableFile struct {
+	peFile
+	cancelled chan struct{}
+}
+
+// cancel makes all the following reads and seeks fail, along with the one
+// in progress, if any, once it returns. It doesn't wait for it.
+func (cf *cancellableFile) cancel() {
+	close(cf.cancelled)
+}
+
+func (cf *cancellableFile) check() error {
+	select {
+	case <-cf.cancelled:
+		return errProbeTimeout
+	default:
+		return nil
+	}
+}
+
+func (cf *cancellableFile) Read(p []byte) (int, error) {
+	if err := cf.check(); err != nil {
+		return 0, err
+	}
+	n, err := cf.peFile.Read(p)
+	if cerr := cf.check(); cerr != nil {
+		return 0, cerr
+	}
+	return n, err
+}
+
+func (cf *cancellableFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := cf.check(); err != nil {
+		return 0, err
+	}
+	n, err := cf.peFile.ReadAt(p, off)
+	if cerr := cf.check(); cerr != nil {
+		return 0, cerr
+	}
+	return n, err
+}
+
+func (cf *cancellableFile) Seek(offset int64, whence int) (int64, error) {
+	if err := cf.check(); err != nil {
+		return 0, err
+	}
+	n, err := cf.peFile.Seek(offset, whence)
+	if cerr := cf.check(); cerr != nil {
+		return 0, cerr
+	}
+	return n, err
+}
@@ -0,0 +1,211 @@
+package dash
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itchio/pelican"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProbePETimeout(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "opens executable")
+	_, _, err = probePE(f, 0)
+	assert.NotEqual(t, errProbeTimeout, err, "doesn't time out without a timeout")
+
+	defer func(original func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error)) {
+		pelicanProbe = original
+	}(pelicanProbe)
+
+	exited := make(chan struct{})
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		// blocks until the file is closed
+		defer close(exited)
+		_, err := ioutil.ReadAll(f)
+		return nil, err
+	}
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err, "creates pipe")
+	defer w.Close()
+
+	_, _, err = probePE(r, 10*time.Millisecond)
+	assert.EqualValues(t, errProbeTimeout, err, "times out")
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Errorf("probe is still running after timing out")
+	}
+}
+
+func Test_ProbePEStopsAfterTimeout(t *testing.T) {
+	defer func(original func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error)) {
+		pelicanProbe = original
+	}(pelicanProbe)
+
+	exited := make(chan struct{})
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		// keeps reading the same bytes until reads fail
+		defer close(exited)
+		buf := make([]byte, 2)
+		for {
+			if _, err := f.ReadAt(buf, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f, err := os.Open(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "opens executable")
+	_, _, err = probePE(f, 10*time.Millisecond)
+	assert.EqualValues(t, errProbeTimeout, err, "times out")
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Errorf("probe is still running after timing out")
+	}
+}
+
+// blockingReader blocks on every read until unblocked is closed
+type blockingReader struct {
+	unblocked chan struct{}
+}
+
+func (br *blockingReader) Read(p []byte) (int, error) {
+	<-br.unblocked
+	return 0, io.EOF
+}
+
+func (br *blockingReader) ReadAt(p []byte, off int64) (int, error) {
+	return br.Read(p)
+}
+
+func (br *blockingReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
+
+func Test_ProbePETimeoutUnclosable(t *testing.T) {
+	defer func(original func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error)) {
+		pelicanProbe = original
+	}(pelicanProbe)
+
+	exited := make(chan error, 1)
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		_, err := f.Read(make([]byte, 2))
+		exited <- err
+		return nil, err
+	}
+
+	br := &blockingReader{unblocked: make(chan struct{})}
+	returned := make(chan error, 1)
+	go func() {
+		// closing a sniffedFile does nothing, so it can't interrupt reads
+		_, _, err := probePE(&sniffedFile{ReadSeeker: br, ReaderAt: br}, 10*time.Millisecond)
+		returned <- err
+	}()
+
+	select {
+	case err := <-returned:
+		assert.EqualValues(t, errProbeTimeout, err, "times out")
+	case <-time.After(5 * time.Second):
+		t.Errorf("probe doesn't return while a read is stuck")
+	}
+
+	close(br.unblocked)
+	select {
+	case err := <-exited:
+		assert.EqualValues(t, errProbeTimeout, err, "fails the read that was in progress")
+	case <-time.After(5 * time.Second):
+		t.Errorf("probe is still running after its read returned")
+	}
+}
+
+func Test_ProbePEPanics(t *testing.T) {
+	defer func(original func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error)) {
+		pelicanProbe = original
+	}(pelicanProbe)
+
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		params.Consumer.Infof("about to panic")
+		panic("hostile input")
+	}
+
+	f, err := os.Open(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "opens executable")
+	_, peLines, err := probePE(f, 5*time.Second)
+	if assert.Error(t, err, "turns panics into errors with a timeout") {
+		assert.Contains(t, err.Error(), "hostile input", "says what went wrong")
+	}
+	assert.EqualValues(t, 1, len(peLines), "keeps pelican's log")
+
+	f, err = os.Open(filepath.Join("testdata", "windows", "game.exe"))
+	assert.NoError(t, err, "opens executable")
+	assert.Panics(t, func() {
+		probePE(f, 0)
+	}, "lets panics through without a timeout")
+}
+
+func Test_ReadPEVersionInfoProbe(t *testing.T) {
+	defer func(original func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error)) {
+		pelicanProbe = original
+	}(pelicanProbe)
+
+	exePath := filepath.Join("testdata", "gamemaker", "Game.exe")
+	data, err := ioutil.ReadFile(exePath)
+	assert.NoError(t, err, "reads executable")
+
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		panic("hostile input")
+	}
+	info := &WindowsInfo{}
+	assert.NotPanics(t, func() {
+		readPEVersionInfo(bytes.NewReader(data), int64(len(data)), info)
+	}, "survives pelican panics")
+	assert.EqualValues(t, WindowsInfo{}, *info, "leaves info alone")
+
+	probeCacheMutex.Lock()
+	probeCache = make(map[probeCacheKey]*pelican.PeInfo)
+	probeCacheMutex.Unlock()
+
+	probes := 0
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		probes++
+		return &pelican.PeInfo{VersionProperties: map[string]string{"ProductName": "Game"}}, nil
+	}
+	f, err := os.Open(exePath)
+	assert.NoError(t, err, "opens executable")
+	defer f.Close()
+	readPEVersionInfo(f, int64(len(data)), info)
+	assert.EqualValues(t, "Game", info.ProductName, "reads version info")
+
+	f2, err := os.Open(exePath)
+	assert.NoError(t, err, "opens executable")
+	_, _, err = probeCachedPE(f2, 0)
+	assert.NoError(t, err, "probes without problems")
+	assert.EqualValues(t, 1, probes, "probes files on disk only once")
+
+	defer func(original time.Duration) {
+		versionInfoProbeTimeout = original
+	}(versionInfoProbeTimeout)
+	versionInfoProbeTimeout = 10 * time.Millisecond
+
+	pelicanProbe = func(f peFile, params pelican.ProbeParams) (*pelican.PeInfo, error) {
+		time.Sleep(5 * time.Second)
+		return nil, nil
+	}
+	probeCacheMutex.Lock()
+	probeCache = make(map[probeCacheKey]*pelican.PeInfo)
+	probeCacheMutex.Unlock()
+
+	start := time.Now()
+	readPEVersionInfo(f, int64(len(data)), info)
+	assert.True(t, time.Since(start) < 5*time.Second, "gives up on slow probes of files on disk")
+}
@@ -0,0 +1,50 @@
+package dash
+
+import (
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between two OnProgress calls
+const progressInterval = 100 * time.Millisecond
+
+// progressReporter calls ConfigureParams.OnProgress as files are processed,
+// at most once every progressInterval, and always once all files are done.
+// It's safe for concurrent use: calls are serialized, and done never goes
+// down from one call to the next. A nil progressReporter does nothing.
+type progressReporter struct {
+	onProgress func(done, total int)
+	total      int
+
+	mu         sync.Mutex
+	done       int
+	lastReport time.Time
+}
+
+func newProgressReporter(onProgress func(done, total int), total int) *progressReporter {
+	if onProgress == nil {
+		return nil
+	}
+	return &progressReporter{
+		onProgress: onProgress,
+		total:      total,
+	}
+}
+
+// add records that n more files were processed
+func (pr *progressReporter) add(n int) {
+	if pr == nil {
+		return
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.done += n
+	now := time.Now()
+	if pr.done < pr.total && now.Sub(pr.lastReport) < progressInterval {
+		return
+	}
+	pr.lastReport = now
+	pr.onProgress(pr.done, pr.total)
+}
@@ -0,0 +1,24 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProgressReporterThrottles(t *testing.T) {
+	var calls [][2]int
+	pr := newProgressReporter(func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	}, 1000)
+
+	for i := 0; i < 1000; i++ {
+		pr.add(1)
+	}
+
+	assert.True(t, len(calls) < 1000, "throttles calls")
+	assert.EqualValues(t, [2]int{1000, 1000}, calls[len(calls)-1], "always reports the end")
+
+	var nilReporter *progressReporter
+	nilReporter.add(1)
+}
@@ -0,0 +1,58 @@
+package dash
+
+// Public returns the stable part of a candidate, see PublicCandidate.
+// The info it holds is a deep copy, so it can be modified without
+// affecting c.
+func (c *Candidate) Public() PublicCandidate {
+	cc := c.Clone()
+	return PublicCandidate{
+		Path:          cc.Path,
+		Depth:         cc.Depth,
+		Flavor:        cc.Flavor,
+		Arch:          cc.Arch,
+		Size:          cc.Size,
+		Compressed:    cc.Compressed,
+		Corrupt:       cc.Corrupt,
+		MinOSVersion:  cc.MinOSVersion,
+		WindowsInfo:   cc.WindowsInfo,
+		LinuxInfo:     cc.LinuxInfo,
+		MacosInfo:     cc.MacosInfo,
+		LoveInfo:      cc.LoveInfo,
+		ScriptInfo:    cc.ScriptInfo,
+		ScriptTarget:  cc.ScriptTarget,
+		DesktopEntry:  cc.DesktopEntry,
+		JarInfo:       cc.JarInfo,
+		GodotInfo:     cc.GodotInfo,
+		GameMakerInfo: cc.GameMakerInfo,
+		NWjsInfo:      cc.NWjsInfo,
+		ElectronInfo:  cc.ElectronInfo,
+		ArchiveInfo:   cc.ArchiveInfo,
+		HTMLInfo:      cc.HTMLInfo,
+		RenpyInfo:     cc.RenpyInfo,
+		DOSBoxInfo:    cc.DOSBoxInfo,
+		AIRInfo:       cc.AIRInfo,
+		UnityInfo:     cc.UnityInfo,
+		UnrealInfo:    cc.UnrealInfo,
+		JNLPInfo:      cc.JNLPInfo,
+		MobileInfo:    cc.MobileInfo,
+		Metadata:      cc.Metadata,
+	}
+}
+
+// Public returns the stable part of a verdict, see PublicVerdict.
+func (v *Verdict) Public() PublicVerdict {
+	res := PublicVerdict{
+		BasePath:   v.BasePath,
+		TotalSize:  v.TotalSize,
+		Candidates: make([]PublicCandidate, 0, len(v.Candidates)),
+	}
+	for _, c := range v.Candidates {
+		res.Candidates = append(res.Candidates, c.Public())
+	}
+	if v.Diagnosis != nil {
+		diagnosis := *v.Diagnosis
+		res.Diagnosis = &diagnosis
+	}
+	res.Warnings = append([]VerdictWarning(nil), v.Warnings...)
+	return res
+}
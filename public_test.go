@@ -0,0 +1,86 @@
+package dash
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// internalCandidateFields are the Candidate fields PublicCandidate leaves out
+var internalCandidateFields = map[string]bool{
+	"Mode":  true,
+	"Spell": true,
+}
+
+func Test_PublicCandidateFields(t *testing.T) {
+	assert := assert.New(t)
+
+	candidateType := reflect.TypeOf(Candidate{})
+	publicType := reflect.TypeOf(PublicCandidate{})
+
+	var publicFields []string
+	for i := 0; i < candidateType.NumField(); i++ {
+		field := candidateType.Field(i)
+		if internalCandidateFields[field.Name] {
+			continue
+		}
+		publicFields = append(publicFields, field.Name)
+
+		publicField, ok := publicType.FieldByName(field.Name)
+		if assert.True(ok, "PublicCandidate has field %s", field.Name) {
+			assert.EqualValues(field.Type, publicField.Type, "same type for %s", field.Name)
+			assert.EqualValues(field.Tag, publicField.Tag, "same tag for %s", field.Name)
+		}
+	}
+	assert.EqualValues(len(publicFields), publicType.NumField(), "PublicCandidate has no extra fields")
+}
+
+// nonZero returns a value of type t that isn't its zero value
+func nonZero(t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+	case reflect.Slice:
+		v.Set(reflect.Append(v, nonZero(t.Elem())))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		v.SetMapIndex(nonZero(t.Key()), nonZero(t.Elem()))
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf("x"))
+	}
+	return v
+}
+
+func Test_CandidatePublicCopiesFields(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Candidate{}
+	cv := reflect.ValueOf(c).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		cv.Field(i).Set(nonZero(cv.Type().Field(i).Type))
+	}
+
+	pv := reflect.ValueOf(c.Public())
+	for i := 0; i < cv.NumField(); i++ {
+		name := cv.Type().Field(i).Name
+		if internalCandidateFields[name] {
+			continue
+		}
+		publicField := pv.FieldByName(name)
+		if assert.True(publicField.IsValid(), "PublicCandidate has field %s", name) {
+			assert.EqualValues(cv.Field(i).Interface(), publicField.Interface(), "Public copies %s", name)
+		}
+	}
+}
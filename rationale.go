@@ -0,0 +1,155 @@
+package dash
+
+import (
+	"fmt"
+
+	"github.com/itchio/headway/state"
+)
+
+// FilterStage names the step of Filter that eliminated a candidate
+type FilterStage string
+
+const (
+	// The candidate can't run on the requested OS or architecture
+	FilterStageCompatibility FilterStage = "compatibility"
+	// Another candidate was closer to the root of the folder
+	FilterStageDepth FilterStage = "depth"
+	// Another flavor of candidate was preferred (app bundles, scripts, love, etc.)
+	FilterStageFlavor FilterStage = "flavor-preference"
+	// Another candidate was a better match for the requested architecture
+	FilterStageArch FilterStage = "arch-preference"
+	// The candidate looks like an installer
+	FilterStageInstaller FilterStage = "installer-detection"
+	// Another candidate was a GUI executable
+	FilterStageGui FilterStage = "gui-preference"
+	// The candidate's score was not positive
+	FilterStageScore FilterStage = "score"
+)
+
+// An AppliedPenalty records a blacklist entry that matched a candidate
+type AppliedPenalty struct {
+	Pattern string
+	Kind    PenaltyKind
+	Delta   int64
+}
+
+// A ScoredCandidate explains what Filter made of a candidate
+type ScoredCandidate struct {
+	Candidate *Candidate
+	// Score starts at 100, goes up with flavor weights and small bonuses
+	// (unix executables that have their executable bit set, statically-linked
+	// linux executables, linux executables named after the arch, launcher
+	// scripts), and goes down with penalties. It's zero for candidates that
+	// were eliminated before scoring.
+	Score int64
+	// Penalties lists all the blacklist entries that matched the candidate
+	Penalties []AppliedPenalty
+	// EliminatedBy is the stage that eliminated the candidate, or
+	// empty if it made it through Filter
+	EliminatedBy FilterStage
+	// Reason is a human-readable explanation of why the candidate
+	// was eliminated
+	Reason string
+}
+
+// ScoredCandidates returns the rationale behind the last call to Filter:
+// surviving candidates come first, in order, followed by the ones that
+// were eliminated. It returns nil for verdicts that weren't filtered.
+//
+// With FilterParams.KeepAll, pruned candidates stay in Candidates, but
+// their EliminatedBy still names the stage that ranked them lower.
+func (v Verdict) ScoredCandidates() []ScoredCandidate {
+	return v.scores
+}
+
+// filterTracker keeps track of which stage of Filter eliminated which candidate
+type filterTracker struct {
+	consumer   *state.Consumer
+	candidates []*Candidate
+	scores     map[*Candidate]*ScoredCandidate
+	scored     map[*Candidate]bool
+}
+
+func newFilterTracker(consumer *state.Consumer, candidates []*Candidate) *filterTracker {
+	ft := &filterTracker{
+		consumer:   consumer,
+		candidates: candidates,
+		scores:     make(map[*Candidate]*ScoredCandidate),
+		scored:     make(map[*Candidate]bool),
+	}
+	for _, c := range candidates {
+		ft.scores[c] = &ScoredCandidate{Candidate: c}
+	}
+	return ft
+}
+
+// eliminate records that a candidate was eliminated by a given stage.
+// Only the first elimination of a candidate is recorded.
+func (ft *filterTracker) eliminate(c *Candidate, stage FilterStage, format string, args ...interface{}) {
+	reason := fmt.Sprintf(format, args...)
+	ft.consumer.Debugf("Excluding (%s) - %s", c.Path, reason)
+
+	sc := ft.scores[c]
+	if sc == nil || sc.EliminatedBy != "" {
+		return
+	}
+	sc.EliminatedBy = stage
+	sc.Reason = reason
+}
+
+// narrow records that all candidates in `from` that aren't in `to`
+// were eliminated by a given stage, and returns `to`.
+func (ft *filterTracker) narrow(stage FilterStage, from []*Candidate, to []*Candidate, format string, args ...interface{}) []*Candidate {
+	kept := make(map[*Candidate]bool)
+	for _, c := range to {
+		kept[c] = true
+	}
+
+	for _, c := range from {
+		if !kept[c] {
+			ft.eliminate(c, stage, format, args...)
+		}
+	}
+	return to
+}
+
+// finish records that all candidates not in `winners` that weren't
+// eliminated yet were eliminated by a given stage, and returns the
+// rationale for all candidates, winners first.
+func (ft *filterTracker) finish(stage FilterStage, winners []*Candidate, score func(c *Candidate) ScoredCandidate, format string, args ...interface{}) []ScoredCandidate {
+	var remaining []*Candidate
+	for _, c := range ft.candidates {
+		if ft.scores[c].EliminatedBy == "" {
+			remaining = append(remaining, c)
+		}
+	}
+	ft.narrow(stage, remaining, winners, format, args...)
+
+	var res []ScoredCandidate
+	for _, c := range winners {
+		sc := ft.scores[c]
+		if sc == nil {
+			continue
+		}
+		if !ft.scored[c] {
+			// picked before scoring, score it for information purposes only
+			ft.recordScore(score(c))
+		}
+		res = append(res, *sc)
+	}
+	for _, c := range ft.candidates {
+		if sc := ft.scores[c]; sc.EliminatedBy != "" {
+			res = append(res, *sc)
+		}
+	}
+	return res
+}
+
+// recordScore stores the result of scoring a candidate
+func (ft *filterTracker) recordScore(scored ScoredCandidate) {
+	if sc := ft.scores[scored.Candidate]; sc != nil {
+		sc.Score = scored.Score
+		sc.Penalties = scored.Penalties
+		ft.scored[scored.Candidate] = true
+	}
+}
@@ -0,0 +1,58 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// detectRenpyGames looks for Ren'Py games in the container, ie. folders
+// that contain both a renpy/ folder and a compiled game/script.rpyc.
+// Candidates at the root of those folders are marked as launchers, and
+// the executables Ren'Py bundles in lib/ (Python interpreter, platform
+// binaries) are marked as such, so Filter can get rid of them.
+func detectRenpyGames(container *tlc.Container, candidates []*Candidate) {
+	lowerDirs := make(map[string]bool)
+	for _, d := range container.Dirs {
+		lowerDirs[strings.ToLower(d.Path)] = true
+	}
+
+	var roots []string
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+		if path.Base(lowerPath) != "script.rpyc" {
+			continue
+		}
+
+		gameDir := path.Dir(lowerPath)
+		if path.Base(gameDir) != "game" {
+			continue
+		}
+
+		root := path.Dir(gameDir)
+		if lowerDirs[path.Join(root, "renpy")] {
+			roots = append(roots, root)
+		}
+	}
+
+	for _, root := range roots {
+		libPrefix := path.Join(root, "lib") + "/"
+
+		for _, c := range candidates {
+			lowerPath := strings.ToLower(c.Path)
+			switch {
+			case path.Dir(lowerPath) == root:
+				c.RenpyInfo = &RenpyInfo{Role: RenpyRoleLauncher}
+			case strings.HasPrefix(lowerPath, libPrefix):
+				c.RenpyInfo = &RenpyInfo{Role: RenpyRoleBundled}
+			}
+		}
+	}
+}
+
+func isRenpyRole(role RenpyRole) candidateFilter {
+	return func(c *Candidate) bool {
+		return c.RenpyInfo != nil && c.RenpyInfo.Role == role
+	}
+}
@@ -0,0 +1,72 @@
+package dash
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// Console ROMs and disc images have no desktop runtime. They never become
+// candidates (they may just be data of a real game, like the ROMs bundled
+// with an emulator), but when a folder has nothing else, they explain why.
+type romSignature struct {
+	offset int64
+	magic  []byte
+}
+
+var romSignatures = map[string]romSignature{
+	// ISO 9660 volume descriptor, in the 17th sector
+	".iso": {0x8001, []byte("CD001")},
+	// raw CD sectors (PlayStation .bin/.cue dumps) start with a sync pattern
+	".bin": {0, []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}},
+	// iNES header
+	".nes": {0, []byte("NES\x1a")},
+	// Game Boy headers start with the Nintendo logo
+	".gb":  {0x104, []byte{0xCE, 0xED, 0x66, 0x66}},
+	".gbc": {0x104, []byte{0xCE, 0xED, 0x66, 0x66}},
+	// Game Boy Advance headers have a fixed value at 0xB2
+	".gba": {0xB2, []byte{0x96}},
+	// Nintendo DS headers have the CRC of the Nintendo logo at 0x15C
+	".nds": {0x15C, []byte{0x56, 0xCF}},
+}
+
+// cue sheets describe the tracks of a disc image, in plain text
+var cueSheetMarker = []byte("TRACK ")
+
+// countConsoleImages returns the number of console ROMs and disc images
+// in a container, recognized by their extension and contents.
+func countConsoleImages(pool lake.Pool, container *tlc.Container) (int, error) {
+	count := 0
+	for fileIndex, f := range container.Files {
+		ext := path.Ext(strings.ToLower(f.Path))
+		sig, isROM := romSignatures[ext]
+		if !isROM && ext != ".cue" {
+			continue
+		}
+
+		if isROM {
+			r, err := pool.GetReadSeeker(int64(fileIndex))
+			if err != nil {
+				return 0, errors.Wrapf(err, "opening (%s)", f.Path)
+			}
+			if hasMagicAt(r, sig.offset, sig.magic) {
+				count++
+			}
+			continue
+		}
+
+		// cue sheets are short text files, like scripts
+		head, err := readScriptHead(pool, int64(fileIndex))
+		if err != nil {
+			return 0, errors.Wrapf(err, "reading cue sheet (%s)", f.Path)
+		}
+		if bytes.Contains(bytes.ToUpper(head), cueSheetMarker) {
+			count++
+		}
+	}
+	return count, nil
+}
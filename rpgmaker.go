@@ -0,0 +1,68 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Files that only ship with RPG Maker MZ projects, relative to
+// the folder that contains index.html
+var rpgMakerMZSignatures = []string{
+	"js/rmmz_core.js",
+	"game.rmmzproject",
+}
+
+// Files that show up in RPG Maker MV projects. data/System.json is
+// also present in MZ projects, so MZ signatures are checked first.
+var rpgMakerMVSignatures = []string{
+	"js/rpg_core.js",
+	"game.rpgproject",
+	"data/system.json",
+}
+
+// detectRPGMakerProjects marks HTML candidates whose folder looks like
+// an RPG Maker MV or MZ project. Deployed MV games keep their project
+// in a www/ folder next to the NW.js executable, MZ games keep it at
+// the root: either way, signatures are looked up next to index.html.
+func detectRPGMakerProjects(container *tlc.Container, candidates []*Candidate) {
+	lowerPaths := make(map[string]bool)
+	for _, f := range container.Files {
+		lowerPaths[strings.ToLower(f.Path)] = true
+	}
+
+	hasAny := func(dir string, signatures []string) bool {
+		for _, sig := range signatures {
+			if lowerPaths[path.Join(dir, sig)] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorHTML {
+			continue
+		}
+
+		dir := path.Dir(strings.ToLower(c.Path))
+		switch {
+		case hasAny(dir, rpgMakerMZSignatures):
+			c.HTMLInfo = &HTMLInfo{Engine: HTMLEngineRPGMakerMZ}
+		case hasAny(dir, rpgMakerMVSignatures):
+			c.HTMLInfo = &HTMLInfo{Engine: HTMLEngineRPGMakerMV}
+		}
+	}
+}
+
+func isRPGMakerProject(c *Candidate) bool {
+	if c.HTMLInfo == nil {
+		return false
+	}
+	switch c.HTMLInfo.Engine {
+	case HTMLEngineRPGMakerMV, HTMLEngineRPGMakerMZ:
+		return true
+	}
+	return false
+}
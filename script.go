@@ -1,11 +1,30 @@
 package dash
 
 import (
-	"bufio"
+	"bytes"
 	"io"
+	"path"
 	"strings"
 )
 
+// scriptHeadSize is how much of a script is searched for hints of the
+// platform it was written for
+const scriptHeadSize = 4 * 1024
+
+// installerHeadSize is how much of a script is searched for the marks of
+// self-extracting installers, whose headers are longer than most scripts
+const installerHeadSize = 16 * 1024
+
+// makeselfMarker is in the first lines of every makeself archive
+// cf. https://makeself.io
+var makeselfMarker = []byte("generated using Makeself")
+
+// MojoSetup installers are makeself archives, which say so in their label
+var mojoSetupMarkers = [][]byte{
+	[]byte("MojoSetup"),
+	[]byte("Mojo Setup"),
+}
+
 func sniffScript(r io.ReadSeeker, size int64) (*Candidate, error) {
 	res := &Candidate{
 		Flavor:     FlavorScript,
@@ -17,15 +36,185 @@ func sniffScript(r io.ReadSeeker, size int64) (*Candidate, error) {
 		return nil, err
 	}
 
-	s := bufio.NewScanner(r)
+	head := make([]byte, installerHeadSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	res.ScriptInfo.Installer = scriptInstaller(head[:n])
+	if n > scriptHeadSize {
+		n = scriptHeadSize
+	}
+	head = head[:n]
+
+	line := string(head)
+	if i := strings.IndexByte(line, '\n'); i != -1 {
+		line = line[:i]
+	}
+	line = strings.TrimSuffix(line, "\r")
+	if len(line) > 2 {
+		// skip over the shebang
+		parseShebang(res.ScriptInfo, line[2:])
+	}
+
+	switch scriptPlatform(res.ScriptInfo, head) {
+	case "darwin":
+		res.Flavor = FlavorScriptMacos
+	case "linux":
+		res.ScriptInfo.LinuxOnly = true
+	}
+
+	return res, nil
+}
+
+// Things only scripts written for macOS mention
+var macosScriptHints = []string{
+	"/Applications/",
+	".app/Contents/",
+	"/Library/",
+	"/Volumes/",
+	"DYLD_",
+	"osascript",
+	"open -a ",
+	"sw_vers",
+}
+
+// Things only scripts written for linux mention
+var linuxScriptHints = []string{
+	"-linux-gnu",
+	"LD_LIBRARY_PATH",
+	"/proc/",
+	"ldconfig",
+	"xdg-open",
+	".x86_64",
+}
+
+// scriptPlatform returns "darwin" or "linux" if a script only makes sense
+// on that platform, going by its interpreter and the paths and tools it
+// mentions. Scripts that mention both, or neither, could run anywhere,
+// and get an empty string.
+func scriptPlatform(info *ScriptInfo, head []byte) string {
+	macos := path.Base(info.Interpreter) == "osascript"
+	for _, hint := range macosScriptHints {
+		if bytes.Contains(head, []byte(hint)) {
+			macos = true
+			break
+		}
+	}
 
-	if s.Scan() {
-		line := s.Text()
-		if len(line) > 2 {
-			// skip over the shebang
-			res.ScriptInfo.Interpreter = strings.TrimSpace(line[2:])
+	linux := false
+	for _, hint := range linuxScriptHints {
+		if bytes.Contains(head, []byte(hint)) {
+			linux = true
+			break
 		}
 	}
 
+	switch {
+	case macos && !linux:
+		return "darwin"
+	case linux && !macos:
+		return "linux"
+	}
+	return ""
+}
+
+// scriptInstaller returns the kind of self-extracting installer a script
+// is, going by the marks makeself leaves in its header, or an empty string
+// if it's not one.
+func scriptInstaller(head []byte) ScriptInstallerType {
+	if !bytes.Contains(head, makeselfMarker) {
+		return ""
+	}
+	for _, marker := range mojoSetupMarkers {
+		if bytes.Contains(head, marker) {
+			return ScriptInstallerMojoSetup
+		}
+	}
+	return ScriptInstallerMakeself
+}
+
+// commandHeadSize is how much of a .command file is checked for binary data
+const commandHeadSize = 512
+
+// sniffCommand returns a macOS script candidate for .command files that
+// look like text, with or without a shebang. Files with NUL bytes in
+// their first bytes are data that happens to have that extension.
+func sniffCommand(r io.ReadSeeker, size int64) (*Candidate, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, commandHeadSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	if len(head) == 0 || bytes.IndexByte(head, 0) != -1 {
+		return nil, nil
+	}
+
+	res := &Candidate{
+		Flavor:     FlavorScriptMacos,
+		ScriptInfo: &ScriptInfo{},
+	}
+	if bytes.HasPrefix(head, []byte("#!")) {
+		line := string(head[2:])
+		if i := strings.IndexByte(line, '\n'); i != -1 {
+			line = line[:i]
+		}
+		parseShebang(res.ScriptInfo, line)
+	} else {
+		res.ScriptInfo.Kind = ScriptKindShell
+	}
 	return res, nil
 }
+
+// parseShebang fills in the interpreter of a script given what comes
+// after the `#!`, seeing through `/usr/bin/env` so that
+// `#!/usr/bin/env python3` is reported as `python3`.
+func parseShebang(info *ScriptInfo, shebang string) {
+	fields := strings.Fields(shebang)
+	if len(fields) == 0 {
+		return
+	}
+
+	interpreter := fields[0]
+	if path.Base(interpreter) == "env" {
+		info.Env = true
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// skip env's own flags (like -S) and variable assignments
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = field
+			break
+		}
+	}
+
+	info.Interpreter = interpreter
+	info.Kind = scriptKind(interpreter)
+}
+
+// scriptKind classifies an interpreter by its name, ignoring
+// its folder and version suffixes (`/usr/bin/python3.8` is python)
+func scriptKind(interpreter string) ScriptKind {
+	if interpreter == "" {
+		return ""
+	}
+
+	name := strings.TrimRight(path.Base(interpreter), "0123456789.")
+	switch name {
+	case "sh", "bash", "dash", "zsh", "ksh", "mksh", "ash":
+		return ScriptKindShell
+	case "python":
+		return ScriptKindPython
+	case "node", "nodejs":
+		return ScriptKindNode
+	}
+	return ScriptKindOther
+}
@@ -0,0 +1,48 @@
+package dash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseShebang(t *testing.T) {
+	assert := assert.New(t)
+
+	parse := func(shebang string) ScriptInfo {
+		var info ScriptInfo
+		parseShebang(&info, shebang)
+		return info
+	}
+
+	assert.EqualValues(ScriptInfo{Interpreter: "/bin/bash", Kind: ScriptKindShell}, parse("/bin/bash"))
+	assert.EqualValues(ScriptInfo{Interpreter: "/bin/sh", Kind: ScriptKindShell}, parse("  /bin/sh -e"))
+	assert.EqualValues(ScriptInfo{Interpreter: "bash", Env: true, Kind: ScriptKindShell}, parse("/usr/bin/env bash"))
+	assert.EqualValues(ScriptInfo{Interpreter: "python3", Env: true, Kind: ScriptKindPython}, parse(" /usr/bin/env -S python3 -u"))
+	assert.EqualValues(ScriptInfo{Interpreter: "python", Env: true, Kind: ScriptKindPython}, parse("/usr/bin/env LANG=C python"))
+	assert.EqualValues(ScriptInfo{Interpreter: "/usr/bin/python3.8", Kind: ScriptKindPython}, parse("/usr/bin/python3.8"))
+	assert.EqualValues(ScriptInfo{Interpreter: "/usr/bin/node", Kind: ScriptKindNode}, parse("/usr/bin/node"))
+	assert.EqualValues(ScriptInfo{Interpreter: "/usr/bin/perl", Kind: ScriptKindOther}, parse("/usr/bin/perl -w"))
+	assert.EqualValues(ScriptInfo{Env: true}, parse("/usr/bin/env"))
+	assert.EqualValues(ScriptInfo{}, parse("   "))
+}
+
+func Test_ScriptPlatform(t *testing.T) {
+	assert := assert.New(t)
+
+	platform := func(script string) string {
+		var info ScriptInfo
+		if strings.HasPrefix(script, "#!") {
+			parseShebang(&info, strings.SplitN(script[2:], "\n", 2)[0])
+		}
+		return scriptPlatform(&info, []byte(script))
+	}
+
+	assert.EqualValues("", platform("#!/bin/sh\n./game \"$@\"\n"))
+	assert.EqualValues("darwin", platform("#!/bin/sh\nexec \"$(dirname \"$0\")/Game.app/Contents/MacOS/Game\"\n"))
+	assert.EqualValues("darwin", platform("#!/usr/bin/osascript\ntell application \"Game\" to activate\n"))
+	assert.EqualValues("linux", platform("#!/bin/bash\nexport LD_LIBRARY_PATH=./lib\n./Game.x86_64\n"))
+	assert.EqualValues("linux", platform("#!/bin/sh\nexec /lib/x86_64-linux-gnu/ld-linux-x86-64.so.2 ./game\n"))
+	assert.EqualValues("", platform("#!/bin/sh\nif [ -d /Applications ]; then open -a Game.app; else LD_LIBRARY_PATH=. ./game; fi\n"))
+}
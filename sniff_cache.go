@@ -0,0 +1,100 @@
+package dash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A SniffCache remembers what sniffing files found, so that configuring
+// the same folder again (after applying a patch, for example) doesn't have
+// to read and identify files that haven't changed.
+//
+// Keys are derived from a file's path, its size, and its first and last
+// bytes. An entry goes stale when a file is renamed or moved (since the
+// path is part of the candidate), or when its size or either end of it
+// changes: those get a different key. Changes in the middle of a file that
+// keep its size untouched are not noticed, callers that modify files that
+// way should use a fresh cache.
+//
+// Files that aren't candidates are cached too, as nil candidates. Configure
+// may call Get and Put from several goroutines at once, and implementations
+// must be safe for concurrent use.
+type SniffCache interface {
+	Get(key string) (*Candidate, bool)
+	Put(key string, c *Candidate)
+}
+
+type memorySniffCache struct {
+	mu      sync.Mutex
+	entries map[string]*Candidate
+}
+
+var _ SniffCache = (*memorySniffCache)(nil)
+
+// maxSniffCacheEntries bounds the in-memory sniff cache, which is simply
+// emptied when it's full
+const maxSniffCacheEntries = 100000
+
+// NewSniffCache returns a SniffCache that keeps everything in memory.
+// It can be shared across Configure calls. It holds up to 100 000 files,
+// after which it starts over.
+func NewSniffCache() SniffCache {
+	return &memorySniffCache{
+		entries: make(map[string]*Candidate),
+	}
+}
+
+func (msc *memorySniffCache) Get(key string) (*Candidate, bool) {
+	msc.mu.Lock()
+	defer msc.mu.Unlock()
+
+	c, ok := msc.entries[key]
+	return c, ok
+}
+
+func (msc *memorySniffCache) Put(key string, c *Candidate) {
+	msc.mu.Lock()
+	defer msc.mu.Unlock()
+
+	if _, ok := msc.entries[key]; !ok && len(msc.entries) >= maxSniffCacheEntries {
+		msc.entries = make(map[string]*Candidate)
+	}
+	msc.entries[key] = c
+}
+
+// sniffSignatureLength is how many bytes at each end of a file
+// go into its cache key
+const sniffSignatureLength = 64
+
+// sniffCacheKey computes the cache key for a file
+func sniffCacheKey(r io.ReadSeeker, path string, size int64, collectSpells bool, deepELF bool, peVersionInfo bool) (string, error) {
+	n := int64(sniffSignatureLength)
+	if size < n {
+		n = size
+	}
+
+	head, err := readBytesAt(r, 0, int(n))
+	if err != nil {
+		return "", err
+	}
+	tail, err := readBytesAt(r, size-n, int(n))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\x00%d\x00%s\x00%s", path, size, hex.EncodeToString(head), hex.EncodeToString(tail))
+	if collectSpells {
+		buf.WriteString("\x00spells")
+	}
+	if deepELF {
+		buf.WriteString("\x00deep-elf")
+	}
+	if peVersionInfo {
+		buf.WriteString("\x00pe-version-info")
+	}
+	return buf.String(), nil
+}
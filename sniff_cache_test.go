@@ -0,0 +1,28 @@
+package dash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SniffCacheBounded(t *testing.T) {
+	cache := NewSniffCache()
+	for i := 0; i < maxSniffCacheEntries; i++ {
+		cache.Put(fmt.Sprintf("file-%d", i), nil)
+	}
+
+	_, ok := cache.Get("file-0")
+	assert.True(t, ok, "keeps entries until it's full")
+
+	cache.Put("file-0", &Candidate{Path: "file-0"})
+	_, ok = cache.Get("file-1")
+	assert.True(t, ok, "replacing an entry doesn't count")
+
+	cache.Put("one-too-many", nil)
+	_, ok = cache.Get("file-1")
+	assert.False(t, ok, "starts over once full")
+	_, ok = cache.Get("one-too-many")
+	assert.True(t, ok, "keeps the newest entry")
+}
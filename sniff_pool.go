@@ -0,0 +1,126 @@
+package dash
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/pools/fspool"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+type poolFactory func() (lake.Pool, error)
+
+// newSniffReader returns a reader for a file of a pool that doesn't share
+// any state with it. Folder pools cache a single open file, so the file
+// is opened directly instead, and several goroutines can sniff files of
+// the same folder at once. Other pools (zip files, single files) are
+// read through as usual, and can't be shared between goroutines.
+//
+// The returned function closes the reader, it must be called once done.
+func newSniffReader(pool lake.Pool, fileIndex int64) (io.ReadSeeker, func() error, error) {
+	if fsp, ok := pool.(*fspool.FsPool); ok && fsp.UniqueReader == nil {
+		f, err := os.Open(fsp.GetPath(fileIndex))
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+
+	r, err := pool.GetReadSeeker(fileIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, func() error { return nil }, nil
+}
+
+// sniffPoolEntries sniffs the given files of a container, and returns
+// candidates in the same order as fileIndices (with nil entries for
+// files that weren't interesting).
+//
+// When concurrency is greater than 1, files are sniffed by that many
+// workers. Pools cache a single reader, so they can't be shared between
+// goroutines: each worker gets its own pool from newPool. Without a
+// newPool, files are sniffed sequentially.
+//
+// Each sniffed file is reported to progress. Sniffing stops as soon as
+// ctx is cancelled.
+func sniffPoolEntries(ctx context.Context, container *tlc.Container, pool lake.Pool, newPool poolFactory, fileIndices []int64, concurrency int, collectSpells bool, deepELF bool, peVersionInfo bool, cache SniffCache, progress *progressReporter) ([]*Candidate, error) {
+	results := make([]*Candidate, len(fileIndices))
+
+	if concurrency <= 1 || newPool == nil {
+		for i, fileIndex := range fileIndices {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			res, err := sniffPoolEntry(pool, fileIndex, container.Files[fileIndex], collectSpells, deepELF, peVersionInfo, cache)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = res
+			progress.add(1)
+		}
+		return results, nil
+	}
+
+	jobs := make(chan int, len(fileIndices))
+	for i := range fileIndices {
+		jobs <- i
+	}
+	close(jobs)
+
+	var firstErr error
+	var errOnce sync.Once
+	stop := make(chan struct{})
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(stop)
+		})
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			workerPool, err := newPool()
+			if err != nil {
+				fail(errors.Wrap(err, "creating pool for sniff worker"))
+				return
+			}
+			defer workerPool.Close()
+
+			for i := range jobs {
+				select {
+				case <-stop:
+					return
+				case <-ctx.Done():
+					fail(ctx.Err())
+					return
+				default:
+				}
+
+				fileIndex := fileIndices[i]
+				res, err := sniffPoolEntry(workerPool, fileIndex, container.Files[fileIndex], collectSpells, deepELF, peVersionInfo, cache)
+				if err != nil {
+					fail(err)
+					return
+				}
+				results[i] = res
+				progress.add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
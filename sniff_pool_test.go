@@ -0,0 +1,72 @@
+package dash
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/itchio/lake/pools"
+	"github.com/itchio/lake/tlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSniffReaderConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	root, err := ioutil.TempDir("", "dash-sniff-reader")
+	assert.NoError(err)
+	defer os.RemoveAll(root)
+
+	sources := []string{
+		"linux-dual-arch/Game.x86_64",
+		"linux-dual-arch/Game.x86",
+		"windows/game.exe",
+		"linux/OpenHexagon",
+		"darwin/Some Grand Game.app/Contents/MacOS/game",
+		"darwin/readme.txt",
+	}
+	for i := 0; i < 100; i++ {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", filepath.FromSlash(sources[i%len(sources)])))
+		assert.NoError(err)
+		name := fmt.Sprintf("file-%03d", i)
+		if i%len(sources) == 2 {
+			name += ".exe"
+		}
+		assert.NoError(ioutil.WriteFile(filepath.Join(root, name), data, 0644))
+	}
+
+	container, err := tlc.WalkAny(root, tlc.WalkOpts{})
+	assert.NoError(err)
+	assert.EqualValues(100, len(container.Files))
+
+	pool, err := pools.New(container, root)
+	assert.NoError(err)
+	defer pool.Close()
+
+	serial := make([]*Candidate, len(container.Files))
+	for i, f := range container.Files {
+		serial[i], err = sniffPoolEntry(pool, int64(i), f, false, false, false, nil)
+		assert.NoError(err)
+	}
+
+	// all goroutines share the same pool on purpose
+	concurrent := make([]*Candidate, len(container.Files))
+	errs := make([]error, len(container.Files))
+	var wg sync.WaitGroup
+	for i, f := range container.Files {
+		wg.Add(1)
+		go func(i int, f *tlc.File) {
+			defer wg.Done()
+			concurrent[i], errs[i] = sniffPoolEntry(pool, int64(i), f, false, false, false, nil)
+		}(i, f)
+	}
+	wg.Wait()
+
+	for i := range container.Files {
+		assert.NoError(errs[i])
+		assert.EqualValues(serial[i], concurrent[i], "same result for (%s)", container.Files[i].Path)
+	}
+}
@@ -0,0 +1,76 @@
+package dash
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A SnifferFunc identifies files dash doesn't know about. It returns
+// a nil candidate (and a nil error) for files it doesn't recognize.
+// r is at the start of the file when the sniffer is called. When called
+// from Configure, path is relative to the configured folder.
+type SnifferFunc func(r io.ReadSeeker, path string, size int64) (*Candidate, error)
+
+type registeredSniffer struct {
+	name string
+	fn   SnifferFunc
+}
+
+var (
+	sniffersMutex sync.RWMutex
+	sniffers      []registeredSniffer
+)
+
+// RegisterSniffer adds a sniffer for formats dash doesn't know about,
+// like in-house game formats. Candidates with new flavors can be returned
+// after registering those with RegisterFlavor.
+//
+// Built-in detection always takes precedence: registered sniffers only see
+// files that dash itself didn't recognize, and never see files excluded by
+// their extension (images, sounds, libraries, etc.). They're tried in the
+// order they were registered, and the first candidate returned wins.
+//
+// It's meant to be called from init functions, but is safe to call from
+// several goroutines. It panics if fn is nil or name is already taken.
+func RegisterSniffer(name string, fn SnifferFunc) {
+	if fn == nil {
+		panic("dash: RegisterSniffer with nil sniffer")
+	}
+
+	sniffersMutex.Lock()
+	defer sniffersMutex.Unlock()
+
+	for _, s := range sniffers {
+		if s.name == name {
+			panic(fmt.Sprintf("dash: RegisterSniffer called twice for %q", name))
+		}
+	}
+	sniffers = append(sniffers, registeredSniffer{name: name, fn: fn})
+}
+
+// sniffRegistered runs registered sniffers, in order, until one of them
+// recognizes the file.
+func sniffRegistered(r io.ReadSeeker, path string, size int64) (*Candidate, error) {
+	sniffersMutex.RLock()
+	registered := sniffers
+	sniffersMutex.RUnlock()
+
+	for _, s := range registered {
+		_, err := r.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := s.fn(r, path, size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "running sniffer %q", s.name)
+		}
+		if c != nil {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,237 @@
+package dash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+	"github.com/pkg/errors"
+)
+
+// Archives are sometimes split into parts, to get around upload limits.
+// Those need joining (or all parts at hand) before they can be extracted,
+// and an upload with only some of the parts can't be extracted at all.
+// Like console images, they never become candidates, but they explain why
+// a folder has nothing else.
+var (
+	// parts named after the archive format: `game.zip.001`, `game.7z.001`,
+	// `game.part1.rar`, `game.part01.rar`...
+	namedSplitPartPattern = regexp.MustCompile(`\.((zip|7z|rar|tar|tgz|gz|bz2|xz)\.[0-9]{2,4}|part[0-9]+\.rar)$`)
+	// spanned zip parts (`game.z01`) and old-style RAR volumes (`game.r00`),
+	// which go along with a `game.zip` or `game.rar`. Without those, their
+	// contents have to say so: the same extensions mean other things
+	// (`.z64` ROMs, `.z80` snapshots...)
+	spannedPartPattern = regexp.MustCompile(`^(.+)\.([zr])[0-9]{2,3}$`)
+	// parts made by generic file splitters: `game.001`, `game.002`...
+	numberedPartPattern = regexp.MustCompile(`^(.+)\.[0-9]{3}$`)
+)
+
+var (
+	// the first part of a spanned zip starts with a spanning signature
+	zipSpannedMagic = []byte("PK\x07\x08")
+	rar4Magic       = []byte("Rar!\x1a\x07\x00")
+	rar5Magic       = []byte("Rar!\x1a\x07\x01\x00")
+)
+
+// splitArchiveHeadSize is how much of a file we read to tell whether it's
+// part of a split archive: enough for the main header of RAR archives
+const splitArchiveHeadSize = 64
+
+// countSplitArchiveParts returns the number of files of a container that
+// are parts of split archives, recognized by their names, and by their
+// contents: spanned zips and RAR volumes say so in their headers. Files
+// whose names merely end in digits only count when the first part of
+// their series is an archive.
+func countSplitArchiveParts(pool lake.Pool, container *tlc.Container) (int, error) {
+	paths := make(map[string]int)
+	for fileIndex, f := range container.Files {
+		paths[strings.ToLower(f.Path)] = fileIndex
+	}
+
+	readHead := func(fileIndex int) ([]byte, error) {
+		n := int64(splitArchiveHeadSize)
+		if size := container.Files[fileIndex].Size; size < n {
+			n = size
+		}
+		r, err := pool.GetReadSeeker(int64(fileIndex))
+		if err != nil {
+			return nil, err
+		}
+		return readBytesAt(r, 0, int(n))
+	}
+
+	// whether the first part of a series of numbered parts is an archive,
+	// by stem
+	archiveStems := make(map[string]bool)
+	isArchiveStem := func(stem string) (bool, error) {
+		if res, ok := archiveStems[stem]; ok {
+			return res, nil
+		}
+		res := false
+		for _, first := range []string{".000", ".001"} {
+			fileIndex, ok := paths[stem+first]
+			if !ok {
+				continue
+			}
+			head, err := readHead(fileIndex)
+			if err != nil {
+				return false, errors.Wrapf(err, "reading (%s)", container.Files[fileIndex].Path)
+			}
+			res = isArchiveHead(head)
+			break
+		}
+		archiveStems[stem] = res
+		return res, nil
+	}
+
+	count := 0
+	for fileIndex, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+
+		if namedSplitPartPattern.MatchString(lowerPath) {
+			count++
+			continue
+		}
+
+		if m := spannedPartPattern.FindStringSubmatch(lowerPath); m != nil {
+			sibling := m[1] + ".zip"
+			if m[2] == "r" {
+				sibling = m[1] + ".rar"
+			}
+			if _, ok := paths[sibling]; ok {
+				count++
+				continue
+			}
+			// when the last part is missing, the others may still say
+			// what they are
+			head, err := readHead(fileIndex)
+			if err != nil {
+				return 0, errors.Wrapf(err, "reading (%s)", f.Path)
+			}
+			if isSpannedArchiveHead(head) {
+				count++
+			}
+			continue
+		}
+
+		if m := numberedPartPattern.FindStringSubmatch(lowerPath); m != nil {
+			isArchive, err := isArchiveStem(m[1])
+			if err != nil {
+				return 0, err
+			}
+			if isArchive {
+				count++
+			}
+			continue
+		}
+
+		switch path.Ext(lowerPath) {
+		case ".zip", ".rar":
+			head, err := readHead(fileIndex)
+			if err != nil {
+				return 0, errors.Wrapf(err, "reading (%s)", f.Path)
+			}
+			if isSpannedArchiveHead(head) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// isSplitArchivePart returns true if an archive is part of a split
+// archive, going by its name, or by its header for RAR volumes.
+func isSplitArchivePart(r io.ReadSeeker, size int64, lowerPath string) bool {
+	if namedSplitPartPattern.MatchString(lowerPath) || numberedPartPattern.MatchString(lowerPath) {
+		return true
+	}
+
+	n := int64(splitArchiveHeadSize)
+	if size < n {
+		n = size
+	}
+	head, err := readBytesAt(r, 0, int(n))
+	if err != nil {
+		return false
+	}
+	return isSpannedArchiveHead(head)
+}
+
+// isArchiveHead returns true if a file starts like a zip, 7-Zip or
+// RAR archive
+func isArchiveHead(head []byte) bool {
+	for _, magic := range [][]byte{zipMagic, zipSpannedMagic, sevenZipMagic, rarMagic} {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpannedArchiveHead returns true if a file is the first part of a
+// spanned zip, or a volume of a multi-volume RAR archive.
+func isSpannedArchiveHead(head []byte) bool {
+	switch {
+	case bytes.HasPrefix(head, zipSpannedMagic):
+		return true
+	case bytes.HasPrefix(head, rar4Magic):
+		// HEAD_CRC, HEAD_TYPE (0x73 for the main header), then HEAD_FLAGS,
+		// where 0x0001 marks volumes
+		h := head[len(rar4Magic):]
+		return len(h) >= 5 && h[2] == 0x73 && binary.LittleEndian.Uint16(h[3:5])&0x0001 != 0
+	case bytes.HasPrefix(head, rar5Magic):
+		return isRAR5Volume(head[len(rar5Magic):])
+	}
+	return false
+}
+
+// isRAR5Volume reads the main header of a RAR5 archive, which follows its
+// signature: CRC32, then the header size, type (1 for the main header) and
+// flags, the sizes of the extra area and data if the flags say so, and the
+// archive flags, where 0x0001 marks volumes. All but the CRC are vints.
+func isRAR5Volume(h []byte) bool {
+	if len(h) < 4 {
+		return false
+	}
+	h = h[4:]
+
+	readVint := func() (uint64, bool) {
+		var res uint64
+		for i := 0; i < len(h) && i < 10; i++ {
+			res |= uint64(h[i]&0x7F) << (7 * uint(i))
+			if h[i]&0x80 == 0 {
+				h = h[i+1:]
+				return res, true
+			}
+		}
+		return 0, false
+	}
+
+	if _, ok := readVint(); !ok {
+		return false
+	}
+	if headerType, ok := readVint(); !ok || headerType != 1 {
+		return false
+	}
+	flags, ok := readVint()
+	if !ok {
+		return false
+	}
+	if flags&0x0001 != 0 {
+		if _, ok := readVint(); !ok {
+			return false
+		}
+	}
+	if flags&0x0002 != 0 {
+		if _, ok := readVint(); !ok {
+			return false
+		}
+	}
+	archiveFlags, ok := readVint()
+	return ok && archiveFlags&0x0001 != 0
+}
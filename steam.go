@@ -0,0 +1,39 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// The Steamworks API libraries, which games that use Steam ship next to
+// their main executable
+var steamAPILibraries = []string{"steam_api.dll", "steam_api64.dll"}
+
+// detectSteamAPI marks native windows executables that sit next to a
+// Steamworks API library. Those are usually the game itself, rather than
+// tools that happen to ship with it.
+func detectSteamAPI(container *tlc.Container, candidates []*Candidate) {
+	steamDirs := make(map[string]bool)
+	for _, f := range container.Files {
+		lowerPath := strings.ToLower(f.Path)
+		for _, name := range steamAPILibraries {
+			if path.Base(lowerPath) == name {
+				steamDirs[path.Dir(lowerPath)] = true
+			}
+		}
+	}
+	if len(steamDirs) == 0 {
+		return
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorNativeWindows || c.WindowsInfo == nil {
+			continue
+		}
+		if steamDirs[path.Dir(strings.ToLower(c.Path))] {
+			c.WindowsInfo.SteamAPI = true
+		}
+	}
+}
@@ -13,3 +13,14 @@ func HasSuspiciouslySetupLikeName(name string) bool {
 	}
 	return false
 }
+
+// HasSuspiciousVersionInfo returns true if the version resource of a
+// windows executable says it's a setup program, even if it was renamed:
+// its original filename or its description look setup-like.
+func HasSuspiciousVersionInfo(info *WindowsInfo) bool {
+	if info == nil {
+		return false
+	}
+	return HasSuspiciouslySetupLikeName(info.OriginalFilename) ||
+		HasSuspiciouslySetupLikeName(info.FileDescription)
+}
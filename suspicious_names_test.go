@@ -0,0 +1,19 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HasSuspiciousVersionInfo(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(HasSuspiciousVersionInfo(nil))
+	assert.False(HasSuspiciousVersionInfo(&WindowsInfo{}))
+	assert.False(HasSuspiciousVersionInfo(&WindowsInfo{OriginalFilename: "Game.exe", FileDescription: "Space Game"}))
+
+	assert.True(HasSuspiciousVersionInfo(&WindowsInfo{OriginalFilename: "setup.exe", FileDescription: "Space Game"}))
+	assert.True(HasSuspiciousVersionInfo(&WindowsInfo{OriginalFilename: "SETUP.EXE"}))
+	assert.True(HasSuspiciousVersionInfo(&WindowsInfo{FileDescription: "Space Game Installer"}))
+	assert.True(HasSuspiciousVersionInfo(&WindowsInfo{FileDescription: "Setup/Uninstall"}))
+}
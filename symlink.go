@@ -0,0 +1,87 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// maxSymlinkHops is how many symlinks we're willing to go through
+// before giving up on resolving one
+const maxSymlinkHops = 32
+
+// symlinksByTarget maps the index of files in a container to the symlinks
+// that resolve to them. Symlinks that point outside of the container, to
+// folders, to nothing, or that loop, are left out.
+func symlinksByTarget(container *tlc.Container) map[int64][]*tlc.Symlink {
+	if len(container.Symlinks) == 0 {
+		return nil
+	}
+
+	fileIndices := make(map[string]int64)
+	for i, f := range container.Files {
+		fileIndices[f.Path] = int64(i)
+	}
+	links := make(map[string]*tlc.Symlink)
+	for _, l := range container.Symlinks {
+		links[l.Path] = l
+	}
+
+	res := make(map[int64][]*tlc.Symlink)
+	for _, l := range container.Symlinks {
+		if fileIndex, ok := resolveSymlink(l, links, fileIndices); ok {
+			res[fileIndex] = append(res[fileIndex], l)
+		}
+	}
+	return res
+}
+
+// resolveSymlink follows a symlink (and any symlinks it points to, or
+// goes through, like `game -> bin/game` when `bin` is itself a symlink to
+// a folder) until it reaches a file of the container
+func resolveSymlink(l *tlc.Symlink, links map[string]*tlc.Symlink, fileIndices map[string]int64) (int64, bool) {
+	hops := 0
+	target, ok := resolveLinkPath(l.Path, links, &hops)
+	if !ok {
+		return 0, false
+	}
+	fileIndex, ok := fileIndices[target]
+	return fileIndex, ok
+}
+
+// resolveLinkPath replaces every component of a slash-separated path that
+// is a symlink of the container with where it points to, and returns false
+// if that leaves the container, or takes more than maxSymlinkHops.
+func resolveLinkPath(p string, links map[string]*tlc.Symlink, hops *int) (string, bool) {
+	resolved := ""
+	for _, component := range strings.Split(p, "/") {
+		current := path.Join(resolved, component)
+		l, ok := links[current]
+		if !ok {
+			resolved = current
+			continue
+		}
+
+		*hops++
+		if *hops > maxSymlinkHops {
+			return "", false
+		}
+
+		dest := strings.Replace(l.Dest, "\\", "/", -1)
+		if path.IsAbs(dest) {
+			return "", false
+		}
+
+		target := path.Join(path.Dir(current), dest)
+		if target == ".." || strings.HasPrefix(target, "../") {
+			return "", false
+		}
+
+		resolved, ok = resolveLinkPath(target, links, hops)
+		if !ok {
+			return "", false
+		}
+	}
+	return resolved, true
+}
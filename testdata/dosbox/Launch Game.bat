@@ -0,0 +1,2 @@
+@echo off
+DOSBOX\DOSBox.exe -conf dosboxGame.conf -noconsole -c "exit"
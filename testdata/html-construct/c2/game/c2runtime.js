@@ -0,0 +1 @@
+// Construct 2 runtime
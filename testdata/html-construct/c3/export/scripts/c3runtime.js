@@ -0,0 +1 @@
+// Construct 3 runtime
@@ -0,0 +1 @@
+var Module = typeof Module !== "undefined" ? Module : {};
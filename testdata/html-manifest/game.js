@@ -0,0 +1 @@
+document.getElementById("game").getContext("2d");
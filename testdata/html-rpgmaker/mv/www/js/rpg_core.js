@@ -0,0 +1 @@
+// rpg_core.js
@@ -0,0 +1 @@
+// rmmz_core.js
@@ -0,0 +1,4 @@
+#!/bin/sh
+cd "$(dirname "$0")"
+export LD_LIBRARY_PATH="$PWD/lib:$LD_LIBRARY_PATH"
+exec ./Game.x86_64 "$@"
@@ -0,0 +1 @@
+# renpy
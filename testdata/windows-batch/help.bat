@@ -0,0 +1,3 @@
+@echo off
+echo Edit settings.ini to change the resolution
+pause
@@ -0,0 +1,4 @@
+@echo off
+rem keep the working directory next to the data
+cd /d "%~dp0"
+start "" bin\game.exe %*
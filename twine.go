@@ -0,0 +1,94 @@
+package dash
+
+import (
+	"bytes"
+	"io"
+)
+
+// Elements that hold the passages of Twine 2 stories, whatever their
+// story format (Harlowe, SugarCube, Chapbook...)
+var twineMarkers = [][]byte{
+	[]byte("<tw-storydata"),
+	[]byte("<tw-passagedata"),
+}
+
+// sniffHTML returns an HTML candidate for Twine stories, marked as such,
+// and for other HTML files if index is true.
+func sniffHTML(r io.ReadSeeker, path string, index bool) (*Candidate, error) {
+	twine, err := isTwineStory(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case twine:
+		return &Candidate{
+			Flavor:   FlavorHTML,
+			Path:     path,
+			HTMLInfo: &HTMLInfo{Engine: HTMLEngineTwine},
+		}, nil
+	case index:
+		return &Candidate{
+			Flavor: FlavorHTML,
+			Path:   path,
+		}, nil
+	}
+	return nil, nil
+}
+
+// maxTwinePeekSize is how much of an HTML file is searched for Twine
+// markers. Some story formats put their whole engine in the document's
+// head, so the story data can come a few hundred kilobytes in.
+const maxTwinePeekSize = 1024 * 1024
+
+// twineChunkSize is how much is read at a time
+const twineChunkSize = 32 * 1024
+
+// isTwineStory returns true if an HTML file holds a Twine story. It reads
+// at most maxTwinePeekSize bytes, a chunk at a time, and stops as soon as
+// it finds a marker.
+func isTwineStory(r io.ReadSeeker) (bool, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return false, err
+	}
+
+	overlap := 0
+	for _, marker := range twineMarkers {
+		if len(marker) > overlap {
+			overlap = len(marker)
+		}
+	}
+	overlap--
+
+	buf := make([]byte, 0, twineChunkSize+overlap)
+	chunk := make([]byte, twineChunkSize)
+	read := 0
+	for read < maxTwinePeekSize {
+		n, err := io.ReadFull(r, chunk)
+		read += n
+		buf = append(buf, chunk[:n]...)
+		for _, marker := range twineMarkers {
+			if bytes.Contains(buf, marker) {
+				return true, nil
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+
+		// keep the end around, in case a marker straddles two chunks
+		if len(buf) > overlap {
+			buf = append(buf[:0], buf[len(buf)-overlap:]...)
+		}
+	}
+	return false, nil
+}
+
+func isTwineStoryCandidate(c *Candidate) bool {
+	return c.HTMLInfo != nil && c.HTMLInfo.Engine == HTMLEngineTwine
+}
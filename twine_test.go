@@ -0,0 +1,34 @@
+package dash
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsTwineStory(t *testing.T) {
+	assert := assert.New(t)
+
+	isTwine := func(doc string) bool {
+		res, err := isTwineStory(strings.NewReader(doc))
+		assert.NoError(err)
+		return res
+	}
+
+	assert.True(isTwine(`<html><body><tw-storydata name="Story"></tw-storydata></body></html>`))
+	assert.True(isTwine(`<tw-passagedata pid="1" name="Start">Hi</tw-passagedata>`))
+	assert.False(isTwine(`<html><body><h1>Read me</h1></body></html>`))
+	assert.False(isTwine(``))
+
+	// markers that straddle two chunks are found
+	for _, offset := range []int{1, 5, 12} {
+		padding := strings.Repeat(" ", twineChunkSize-offset)
+		assert.True(isTwine(padding+"<tw-storydata>"), "finds marker at chunk boundary (%d)", offset)
+	}
+
+	// markers past the peek size aren't
+	padding := bytes.Repeat([]byte(" "), maxTwinePeekSize)
+	assert.False(isTwine(string(padding) + "<tw-storydata>"))
+}
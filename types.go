@@ -1,5 +1,7 @@
 package dash
 
+import "github.com/itchio/lake/tlc"
+
 // A Verdict contains a wealth of information on how to "launch" or "open" a specific
 // folder.
 type Verdict struct {
@@ -9,14 +11,119 @@ type Verdict struct {
 	TotalSize int64 `json:"totalSize"`
 	// Candidates is a list of potentially interesting files, with a lot of additional info
 	Candidates []*Candidate `json:"candidates"`
+	// Diagnosis explains why Configure found no candidates, it's only
+	// set when there are none, or when they're all mobile packages or
+	// parts of split archives
+	// @optional
+	Diagnosis *Diagnosis `json:"diagnosis,omitempty"`
+	// Warnings lists the problems Configure and Filter came across, that
+	// didn't keep them from doing their job, like app bundles that were
+	// skipped because they have no Info.plist
+	// @optional
+	Warnings []VerdictWarning `json:"warnings,omitempty"`
+	// Container is the listing of every file, folder and symlink Configure
+	// walked, only set if ConfigureParams.RetainContainer is true. It belongs
+	// to the verdict: Filter shares it with the verdicts it returns, so
+	// callers must not modify it. It isn't serialized.
+	Container *tlc.Container `json:"-"`
+
+	// scores is set by Filter, see ScoredCandidates
+	scores []ScoredCandidate
+}
+
+// A PublicVerdict is the stable part of a Verdict, as returned by
+// Verdict.Public, with PublicCandidates.
+type PublicVerdict struct {
+	// BasePath is the absolute path of the folder that was configured
+	BasePath string `json:"basePath"`
+	// TotalSize is the size in bytes of the folder and all its children, recursively
+	TotalSize int64 `json:"totalSize"`
+	// Candidates is a list of potentially interesting files
+	Candidates []PublicCandidate `json:"candidates"`
+	// Diagnosis explains why Configure found no candidates, it's only
+	// set when there are none, or when they're all mobile packages or
+	// parts of split archives
+	// @optional
+	Diagnosis *Diagnosis `json:"diagnosis,omitempty"`
+	// Warnings lists the problems Configure and Filter came across, that
+	// didn't keep them from doing their job, like app bundles that were
+	// skipped because they have no Info.plist
+	// @optional
+	Warnings []VerdictWarning `json:"warnings,omitempty"`
 }
 
+// Contains an explanation of why a folder has no candidates (that
+// can run on desktop)
+type Diagnosis struct {
+	// What kind of problem was found
+	Kind DiagnosisKind `json:"kind"`
+	// A human-readable explanation
+	Message string `json:"message"`
+}
+
+// What kind of problem left a folder without candidates
+type DiagnosisKind string
+
+const (
+	// The folder has no files at all
+	DiagnosisEmpty DiagnosisKind = "empty"
+	// All candidates were left out by ConfigureParams.ExcludeGlobs
+	DiagnosisExcluded DiagnosisKind = "excluded"
+	// The folder only has console ROMs or disc images (`.iso`, `.bin`/`.cue`,
+	// `.nds`, `.gba`, etc.)
+	DiagnosisConsoleImage DiagnosisKind = "console-image"
+	// The folder only has zip archives (that aren't jars), which
+	// probably need extracting
+	DiagnosisOnlyArchives DiagnosisKind = "only-archives"
+	// All files have extensions that are never launched (assets,
+	// libraries, etc.)
+	DiagnosisBlacklisted DiagnosisKind = "blacklisted"
+	// The folder only has parts of split archives (`.zip.001`, `.z01`,
+	// `.part1.rar`, `.7z.001`...), which need joining and extracting, and
+	// may not all be there. Parts of 7-Zip and RAR archives are still
+	// candidates, see ArchiveInfo.Split
+	DiagnosisSplitArchive DiagnosisKind = "split-archive"
+	// The only candidates are mobile app packages (`.apk`, `.ipa`) or
+	// ebooks, most likely a mobile build uploaded by mistake
+	DiagnosisMobileBuild DiagnosisKind = "mobile-build"
+	// Files were inspected, but none of them were recognized
+	DiagnosisUnrecognized DiagnosisKind = "unrecognized"
+)
+
+// Contains a problem Configure or Filter came across, as logged by their
+// consumer
+type VerdictWarning struct {
+	// The file or folder the problem is about, relative to the verdict's
+	// BasePath
+	// @optional
+	Path string `json:"path,omitempty"`
+	// A human-readable explanation
+	Message string `json:"message"`
+	// How serious the problem is
+	Severity WarningSeverity `json:"severity"`
+}
+
+// How serious a problem found by Configure or Filter is
+type WarningSeverity string
+
+const (
+	// Something that's expected to happen now and then, like stray
+	// folders named like app bundles
+	WarningSeverityInfo WarningSeverity = "info"
+	// Something that's likely wrong with the folder, or that kept dash
+	// from inspecting it fully
+	WarningSeverityWarning WarningSeverity = "warning"
+)
+
 // A Candidate is a potentially interesting launch target, be it
 // a native executable, a Java or Love2D bundle, an HTML index, etc.
+// All of its fields are stable API, except for Mode and Spell: use
+// Public to leave those out.
 type Candidate struct {
 	// Path is relative to the configured folder
 	Path string `json:"path"`
-	// Mode describes file permissions
+	// Mode describes file permissions. It's internal bookkeeping for
+	// FixPermissions, which clears it, and isn't part of PublicCandidate.
 	Mode uint32 `json:"mode,omitempty"`
 	// Depth is the number of path elements leading up to this candidate
 	Depth int `json:"depth"`
@@ -26,7 +133,24 @@ type Candidate struct {
 	Arch Arch `json:"arch,omitempty"`
 	// Size is the size of the candidate's file, in bytes
 	Size int64 `json:"size"`
-	// Spell contains raw output from <https://github.com/itchio/wizardry>
+	// Compressed is true for native executables that were shipped
+	// gzip-compressed, and need to be decompressed before they can run
+	// @optional
+	Compressed bool `json:"compressed,omitempty"`
+	// Corrupt is true for files that were recognized, but are cut short or
+	// damaged (a zip without its central directory, an ELF executable
+	// truncated mid-header), and likely won't run
+	// @optional
+	Corrupt bool `json:"corrupt,omitempty"`
+	// MinOSVersion is the oldest version of the OS the candidate runs on,
+	// like "10.9". It's only set for macOS executables that name one in
+	// their load commands. For universal binaries, it's the lowest version
+	// any of their slices runs on.
+	// @optional
+	MinOSVersion string `json:"minOsVersion,omitempty"`
+	// Spell contains raw output from <https://github.com/itchio/wizardry>,
+	// when requested. It's meant for debugging, and isn't part of
+	// PublicCandidate.
 	// @optional
 	Spell []string `json:"spell,omitempty"`
 	// WindowsInfo contains information specific to native Windows candidates
@@ -38,17 +162,172 @@ type Candidate struct {
 	// MacosInfo contains information specific to native macOS candidates
 	// @optional
 	MacosInfo *MacosInfo `json:"macosInfo,omitempty"`
-	// LoveInfo contains information specific to Love2D bundles (`.love` files)
+	// LoveInfo contains information specific to Love2D bundles (`.love` files),
+	// or native executables a bundle is fused into
 	// @optional
 	LoveInfo *LoveInfo `json:"loveInfo,omitempty"`
 	// ScriptInfo contains information specific to shell scripts (`.sh`, `.bat` etc.)
 	// @optional
 	ScriptInfo *ScriptInfo `json:"scriptInfo,omitempty"`
+	// ScriptTarget is the path of the executable or jar a windows batch
+	// script launches, relative to the configured folder
+	// @optional
+	ScriptTarget string `json:"scriptTarget,omitempty"`
+	// DesktopEntry is the path of the desktop entry (`.desktop` file) that
+	// launches this candidate, relative to the configured folder
+	// @optional
+	DesktopEntry string `json:"desktopEntry,omitempty"`
 	// JarInfo contains information specific to Java archives (`.jar` files)
 	// @optional
 	JarInfo *JarInfo `json:"jarInfo,omitempty"`
+	// GodotInfo contains information specific to Godot games
+	// @optional
+	GodotInfo *GodotInfo `json:"godotInfo,omitempty"`
+	// GameMakerInfo contains information specific to GameMaker: Studio games
+	// @optional
+	GameMakerInfo *GameMakerInfo `json:"gameMakerInfo,omitempty"`
+	// NWjsInfo contains information specific to NW.js apps
+	// @optional
+	NWjsInfo *NWjsInfo `json:"nwjsInfo,omitempty"`
+	// ElectronInfo contains information specific to Electron apps
+	// @optional
+	ElectronInfo *ElectronInfo `json:"electronInfo,omitempty"`
+	// ArchiveInfo contains information specific to archives that need extracting,
+	// or disk images that need mounting
+	// @optional
+	ArchiveInfo *ArchiveInfo `json:"archiveInfo,omitempty"`
+	// HTMLInfo contains information specific to HTML5 games (`index.html` files)
+	// @optional
+	HTMLInfo *HTMLInfo `json:"htmlInfo,omitempty"`
+	// RenpyInfo contains information specific to Ren'Py games
+	// @optional
+	RenpyInfo *RenpyInfo `json:"renpyInfo,omitempty"`
+	// DOSBoxInfo contains information specific to DOS games run by a
+	// bundled DOSBox, for DOSBox itself and scripts that start it
+	// @optional
+	DOSBoxInfo *DOSBoxInfo `json:"dosboxInfo,omitempty"`
+	// AIRInfo contains information specific to Adobe AIR apps
+	// @optional
+	AIRInfo *AIRInfo `json:"airInfo,omitempty"`
+	// UnityInfo contains information specific to Unity games
+	// @optional
+	UnityInfo *UnityInfo `json:"unityInfo,omitempty"`
+	// UnrealInfo contains information specific to Unreal Engine games
+	// @optional
+	UnrealInfo *UnrealInfo `json:"unrealInfo,omitempty"`
+	// JNLPInfo contains information specific to Java Web Start descriptors
+	// @optional
+	JNLPInfo *JNLPInfo `json:"jnlpInfo,omitempty"`
+	// MobileInfo contains information specific to mobile app packages
+	// and ebooks
+	// @optional
+	MobileInfo *MobileInfo `json:"mobileInfo,omitempty"`
+	// Any other info.
+	// @optional
+	Metadata interface{} `json:"metadata,omitempty"`
+}
+
+// A PublicCandidate is the stable part of a Candidate, as returned by
+// Candidate.Public. It leaves out internal bookkeeping: Mode, which
+// FixPermissions clears, and Spell, which can get very large.
+type PublicCandidate struct {
+	// Path is relative to the configured folder
+	Path string `json:"path"`
+	// Depth is the number of path elements leading up to this candidate
+	Depth int `json:"depth"`
+	// Flavor is the type of a candidate - native, html, jar etc.
+	Flavor Flavor `json:"flavor"`
+	// Arch describes the architecture of a candidate (where relevant)
+	Arch Arch `json:"arch,omitempty"`
+	// Size is the size of the candidate's file, in bytes
+	Size int64 `json:"size"`
+	// Compressed is true for native executables that were shipped
+	// gzip-compressed, and need to be decompressed before they can run
+	// @optional
+	Compressed bool `json:"compressed,omitempty"`
+	// Corrupt is true for files that were recognized, but are cut short or
+	// damaged (a zip without its central directory, an ELF executable
+	// truncated mid-header), and likely won't run
+	// @optional
+	Corrupt bool `json:"corrupt,omitempty"`
+	// MinOSVersion is the oldest version of the OS the candidate runs on,
+	// like "10.9". It's only set for macOS executables that name one in
+	// their load commands. For universal binaries, it's the lowest version
+	// any of their slices runs on.
+	// @optional
+	MinOSVersion string `json:"minOsVersion,omitempty"`
+	// WindowsInfo contains information specific to native Windows candidates
+	// @optional
+	WindowsInfo *WindowsInfo `json:"windowsInfo,omitempty"`
+	// LinuxInfo contains information specific to native Linux candidates
+	// @optional
+	LinuxInfo *LinuxInfo `json:"linuxInfo,omitempty"`
+	// MacosInfo contains information specific to native macOS candidates
+	// @optional
+	MacosInfo *MacosInfo `json:"macosInfo,omitempty"`
+	// LoveInfo contains information specific to Love2D bundles (`.love` files),
+	// or native executables a bundle is fused into
+	// @optional
+	LoveInfo *LoveInfo `json:"loveInfo,omitempty"`
+	// ScriptInfo contains information specific to shell scripts (`.sh`, `.bat` etc.)
+	// @optional
+	ScriptInfo *ScriptInfo `json:"scriptInfo,omitempty"`
+	// ScriptTarget is the path of the executable or jar a windows batch
+	// script launches, relative to the configured folder
+	// @optional
+	ScriptTarget string `json:"scriptTarget,omitempty"`
+	// DesktopEntry is the path of the desktop entry (`.desktop` file) that
+	// launches this candidate, relative to the configured folder
+	// @optional
+	DesktopEntry string `json:"desktopEntry,omitempty"`
+	// JarInfo contains information specific to Java archives (`.jar` files)
+	// @optional
+	JarInfo *JarInfo `json:"jarInfo,omitempty"`
+	// GodotInfo contains information specific to Godot games
+	// @optional
+	GodotInfo *GodotInfo `json:"godotInfo,omitempty"`
+	// GameMakerInfo contains information specific to GameMaker: Studio games
+	// @optional
+	GameMakerInfo *GameMakerInfo `json:"gameMakerInfo,omitempty"`
+	// NWjsInfo contains information specific to NW.js apps
+	// @optional
+	NWjsInfo *NWjsInfo `json:"nwjsInfo,omitempty"`
+	// ElectronInfo contains information specific to Electron apps
+	// @optional
+	ElectronInfo *ElectronInfo `json:"electronInfo,omitempty"`
+	// ArchiveInfo contains information specific to archives that need extracting,
+	// or disk images that need mounting
+	// @optional
+	ArchiveInfo *ArchiveInfo `json:"archiveInfo,omitempty"`
+	// HTMLInfo contains information specific to HTML5 games (`index.html` files)
+	// @optional
+	HTMLInfo *HTMLInfo `json:"htmlInfo,omitempty"`
+	// RenpyInfo contains information specific to Ren'Py games
+	// @optional
+	RenpyInfo *RenpyInfo `json:"renpyInfo,omitempty"`
+	// DOSBoxInfo contains information specific to DOS games run by a
+	// bundled DOSBox, for DOSBox itself and scripts that start it
+	// @optional
+	DOSBoxInfo *DOSBoxInfo `json:"dosboxInfo,omitempty"`
+	// AIRInfo contains information specific to Adobe AIR apps
+	// @optional
+	AIRInfo *AIRInfo `json:"airInfo,omitempty"`
+	// UnityInfo contains information specific to Unity games
+	// @optional
+	UnityInfo *UnityInfo `json:"unityInfo,omitempty"`
+	// UnrealInfo contains information specific to Unreal Engine games
+	// @optional
+	UnrealInfo *UnrealInfo `json:"unrealInfo,omitempty"`
+	// JNLPInfo contains information specific to Java Web Start descriptors
+	// @optional
+	JNLPInfo *JNLPInfo `json:"jnlpInfo,omitempty"`
+	// MobileInfo contains information specific to mobile app packages
+	// and ebooks
+	// @optional
+	MobileInfo *MobileInfo `json:"mobileInfo,omitempty"`
 	// Any other info.
-	Metadata interface{}
+	// @optional
+	Metadata interface{} `json:"metadata,omitempty"`
 }
 
 // Flavor describes whether we're dealing with a native executables, a Java archive, a love2d bundle, etc.
@@ -67,14 +346,65 @@ const (
 	FlavorScript Flavor = "script"
 	// FlavorScriptWindows denotes windows scripts (.bat or .cmd)
 	FlavorScriptWindows Flavor = "windows-script"
+	// FlavorScriptMacos denotes macOS shell scripts that Finder runs
+	// on double-click (.command), with or without a shebang, and scripts
+	// that only make sense on macOS
+	FlavorScriptMacos Flavor = "macos-script"
 	// FlavorJar denotes a .jar archive with a Main-Class
 	FlavorJar Flavor = "jar"
+	// FlavorJNLP denotes a Java Web Start descriptor (`.jnlp` file),
+	// which names the jars and main class of a Java app
+	FlavorJNLP Flavor = "jnlp"
 	// FlavorHTML denotes an index html file
 	FlavorHTML Flavor = "html"
 	// FlavorLove denotes a love package
 	FlavorLove Flavor = "love"
 	// Microsoft installer packages
 	FlavorMSI Flavor = "msi"
+	// FlavorGodot denotes a native executable that runs a Godot pack,
+	// either embedded or as a sidecar .pck file
+	FlavorGodot Flavor = "godot"
+	// FlavorGameMaker denotes a GameMaker: Studio runner executable,
+	// which runs a data file shipped next to it
+	FlavorGameMaker Flavor = "gamemaker"
+	// FlavorNWjs denotes an NW.js executable, which runs an HTML5 app
+	// shipped next to it
+	FlavorNWjs Flavor = "nwjs"
+	// FlavorElectron denotes an Electron executable, which runs an HTML5
+	// app shipped in its resources folder
+	FlavorElectron Flavor = "electron"
+	// FlavorClickTeam denotes a ClickTeam Fusion (Multimedia Fusion)
+	// runtime executable, with the game's data appended to it
+	FlavorClickTeam Flavor = "clickteam"
+	// FlavorAIR denotes the launcher of an Adobe AIR app (like Stencyl
+	// exports) that ships with a captive runtime
+	FlavorAIR Flavor = "air"
+	// FlavorUnity denotes a Unity player executable, which runs the game
+	// in the data folder next to it
+	FlavorUnity Flavor = "unity"
+	// FlavorUnreal denotes the launcher of a packaged Unreal Engine game,
+	// or its shipping binary when there's no launcher
+	FlavorUnreal Flavor = "unreal"
+	// FlavorFlashProjector denotes a Flash projector, a standalone Flash
+	// Player executable with the game's SWF appended to it
+	FlavorFlashProjector Flavor = "flash-projector"
+	// FlavorDOSBox denotes a DOSBox executable shipped with a DOS game,
+	// usually along with a configuration file that starts the game
+	FlavorDOSBox Flavor = "dosbox"
+	// FlavorPkgMacos denotes a macOS installer package (`.pkg` files,
+	// or folders for legacy bundle-style packages)
+	FlavorPkgMacos Flavor = "pkg-macos"
+	// FlavorDmgMacos denotes a macOS disk image (`.dmg` files), which
+	// needs to be mounted (or extracted) before its contents can be
+	// configured
+	FlavorDmgMacos Flavor = "dmg-macos"
+	// FlavorArchive denotes an archive (7-zip, RAR, gzip) that needs
+	// to be extracted before anything in it can be launched
+	FlavorArchive Flavor = "archive"
+	// FlavorMobile denotes an Android or iOS app package, or an EPUB
+	// ebook. They're zip archives, but not jars, and they never run on
+	// desktop: Filter always leaves them out.
+	FlavorMobile Flavor = "mobile"
 )
 
 // The architecture of an executable
@@ -85,6 +415,10 @@ const (
 	Arch386 Arch = "386"
 	// 64-bit
 	ArchAmd64 Arch = "amd64"
+	// 32-bit ARM
+	ArchArm Arch = "arm"
+	// 64-bit ARM (aarch64, Apple Silicon)
+	ArchArm64 Arch = "arm64"
 )
 
 // Contains information specific to native windows executables
@@ -102,6 +436,31 @@ type WindowsInfo struct {
 	// Is this a .NET assembly?
 	// @optional
 	DotNet bool `json:"dotNet,omitempty"`
+	// Path of the Mono runtime file or wrapper script shipped next to a
+	// .NET assembly, which lets it run on linux and macOS
+	// @optional
+	MonoRuntime string `json:"monoRuntime,omitempty"`
+	// True for MS-DOS executables (an MZ header without a PE header),
+	// which modern versions of windows can't run by themselves
+	// @optional
+	DOS bool `json:"dos,omitempty"`
+	// ProductName from the executable's version resource, if any. Version
+	// properties are only read with ConfigureParams.PEVersionInfo
+	// @optional
+	ProductName string `json:"productName,omitempty"`
+	// FileDescription from the executable's version resource, if any
+	// @optional
+	FileDescription string `json:"fileDescription,omitempty"`
+	// CompanyName from the executable's version resource, if any
+	// @optional
+	CompanyName string `json:"companyName,omitempty"`
+	// OriginalFilename from the executable's version resource, if any
+	// @optional
+	OriginalFilename string `json:"originalFilename,omitempty"`
+	// True if `steam_api.dll` or `steam_api64.dll` is in the same folder,
+	// which hints that this is the game rather than a tool
+	// @optional
+	SteamAPI bool `json:"steamApi,omitempty"`
 }
 
 // Which particular type of windows-specific installer
@@ -116,34 +475,315 @@ const (
 	WindowsInstallerTypeNullsoft WindowsInstallerType = "nsis"
 	// Self-extracting installers that 7-zip knows how to extract
 	WindowsInstallerTypeArchive WindowsInstallerType = "archive"
+	// InstallShield installers
+	WindowsInstallerTypeInstallShield WindowsInstallerType = "installshield"
+	// Executables that wrap an MSI package
+	WindowsInstallerTypeMsiWrapper WindowsInstallerType = "msi-wrapper"
 )
 
 // Contains information specific to native macOS executables
 // or app bundles.
 type MacosInfo struct {
+	// Architectures of all the slices of a universal (fat) binary
+	// @optional
+	Archs []Arch `json:"archs,omitempty"`
+	// True for installer packages, which shouldn't be launched
+	// without asking the user first
+	// @optional
+	Installer bool `json:"installer,omitempty"`
 }
 
 // Contains information specific to native Linux executables
 type LinuxInfo struct {
+	// True if this is an AppImage, ie. a self-contained executable
+	// that carries its own filesystem image
+	// @optional
+	AppImage bool `json:"appImage,omitempty"`
+	// The ELF class (EI_CLASS) of the executable: 32 or 64 (bits), or 0
+	// if it's neither
+	// @optional
+	ELFClass int `json:"elfClass,omitempty"`
+	// The byte order (EI_DATA) of the executable
+	// @optional
+	ELFData ELFData `json:"elfData,omitempty"`
+	// The ABI the executable targets (EI_OSABI). Linux executables use
+	// 0 (System V) or 3 (Linux), other values are for other systems,
+	// like 9 for FreeBSD.
+	// @optional
+	OSABI uint8 `json:"osAbi,omitempty"`
+	// True if the executable is statically linked (it doesn't ask for a
+	// dynamic loader), so it doesn't depend on system libraries
+	// @optional
+	StaticallyLinked bool `json:"staticallyLinked,omitempty"`
+	// The highest glibc version the executable needs, like "2.17", from
+	// the GLIBC_x.yy versions of the symbols it imports. It's only looked
+	// up when configuring with DeepELF, and stays empty for executables
+	// that don't say, like statically-linked ones.
+	// @optional
+	MinGlibcVersion string `json:"minGlibcVersion,omitempty"`
 }
 
+// The byte order of an ELF executable
+type ELFData string
+
+const (
+	// Little-endian, like x86 and most ARM executables
+	ELFDataLittleEndian ELFData = "little-endian"
+	// Big-endian, like PowerPC and MIPS executables
+	ELFDataBigEndian ELFData = "big-endian"
+)
+
 // Contains information specific to Love2D bundles
 type LoveInfo struct {
 	// The version of love2D required to open this bundle. May be empty
 	// @optional
 	Version string `json:"version,omitempty"`
+	// True if the game is fused into a native executable, ie. the bundle
+	// is appended to the love2D executable
+	// @optional
+	Fused bool `json:"fused,omitempty"`
 }
 
 // Contains information specific to shell scripts
 type ScriptInfo struct {
-	// Something like `/bin/bash`
+	// Something like `/bin/bash`, or `python3` for scripts that
+	// start with `#!/usr/bin/env python3`
 	// @optional
 	Interpreter string `json:"interpreter,omitempty"`
+	// True if the interpreter is looked up in the PATH by `/usr/bin/env`
+	// @optional
+	Env bool `json:"env,omitempty"`
+	// What kind of interpreter the script needs
+	// @optional
+	Kind ScriptKind `json:"kind,omitempty"`
+	// True if the script mentions paths or tools that only exist on linux,
+	// like `/lib/x86_64-linux-gnu`
+	// @optional
+	LinuxOnly bool `json:"linuxOnly,omitempty"`
+	// The kind of self-extracting installer this script is, if any.
+	// Installers are left out by Filter when there's anything else.
+	// @optional
+	Installer ScriptInstallerType `json:"installer,omitempty"`
 }
 
+// Which kind of self-extracting installer a script is
+type ScriptInstallerType string
+
+const (
+	// Shell archives made with makeself, which extract (and usually run)
+	// the payload appended to them
+	ScriptInstallerMakeself ScriptInstallerType = "makeself"
+	// MojoSetup installers, as shipped by Humble Bundle and GOG.com, which
+	// are makeself archives
+	ScriptInstallerMojoSetup ScriptInstallerType = "mojosetup"
+)
+
+// Which family of interpreter a script is written for
+type ScriptKind string
+
+const (
+	// sh, bash, zsh, etc.
+	ScriptKindShell ScriptKind = "shell"
+	// Python 2 or 3
+	ScriptKindPython ScriptKind = "python"
+	// Node.js, which usually means an HTML5/JavaScript game
+	ScriptKindNode ScriptKind = "node"
+	// Any other interpreter
+	ScriptKindOther ScriptKind = "other"
+)
+
 // Contains information specific to Java archives
 type JarInfo struct {
 	// The main Java class as specified by the manifest included in the .jar (if any)
 	// @optional
 	MainClass string `json:"mainClass,omitempty"`
+	// True if the manifest names a main class, ie. the jar can be run
+	// with `java -jar`
+	// @optional
+	Runnable bool `json:"runnable,omitempty"`
+	// Path of the java executable of a runtime bundled next to the jar,
+	// if any. Jars that bundle a runtime are self-contained.
+	// @optional
+	BundledJava string `json:"bundledJava,omitempty"`
+}
+
+// Contains information specific to Godot games
+type GodotInfo struct {
+	// Path of the .pck file relative to the configured folder, if the pack
+	// is shipped next to the executable
+	// @optional
+	PackPath string `json:"packPath,omitempty"`
+	// True if the pack is embedded at the end of the executable
+	// @optional
+	Embedded bool `json:"embedded,omitempty"`
+}
+
+// Contains information specific to GameMaker: Studio games
+type GameMakerInfo struct {
+	// Path of the data file (`data.win`, `game.unx`, etc.) relative to the
+	// configured folder
+	DataPath string `json:"dataPath"`
+}
+
+// Contains information specific to NW.js apps
+type NWjsInfo struct {
+	// Path of the app package (`package.nw` or `package.json`) relative
+	// to the configured folder
+	PackagePath string `json:"packagePath"`
+}
+
+// Contains information specific to Electron apps
+type ElectronInfo struct {
+	// Path of the app (`resources/app.asar` or `resources/app`) relative
+	// to the configured folder
+	AppPath string `json:"appPath"`
 }
+
+// Contains information specific to HTML5 games
+type HTMLInfo struct {
+	// The engine the game was made with, if we recognized it
+	// @optional
+	Engine HTMLEngine `json:"engine,omitempty"`
+	// Path of a WebAssembly module shipped next to the HTML file, as found
+	// in Emscripten exports. It's a good sign that it's a game rather than
+	// a stray HTML page.
+	// @optional
+	WasmPath string `json:"wasmPath,omitempty"`
+	// Path of a web app manifest (`manifest.json`, `*.webmanifest`)
+	// whose `start_url` points to this HTML file
+	// @optional
+	WebManifestPath string `json:"webManifestPath,omitempty"`
+}
+
+// Which particular engine an HTML5 game was made with
+type HTMLEngine string
+
+const (
+	// RPG Maker MV projects
+	HTMLEngineRPGMakerMV HTMLEngine = "rpgmaker-mv"
+	// RPG Maker MZ projects
+	HTMLEngineRPGMakerMZ HTMLEngine = "rpgmaker-mz"
+	// Construct 2 exports
+	HTMLEngineConstruct2 HTMLEngine = "construct2"
+	// Construct 3 exports
+	HTMLEngineConstruct3 HTMLEngine = "construct3"
+	// love.js web exports of Love2D games
+	HTMLEngineLoveJS HTMLEngine = "lovejs"
+	// Twine stories (Harlowe, SugarCube, etc.)
+	HTMLEngineTwine HTMLEngine = "twine"
+)
+
+// Contains information specific to Java Web Start descriptors
+type JNLPInfo struct {
+	// The main Java class, as specified by the descriptor
+	// @optional
+	MainClass string `json:"mainClass,omitempty"`
+	// Reference to the main jar (the one marked main, or the first one),
+	// as written in the descriptor
+	// @optional
+	MainJar string `json:"mainJar,omitempty"`
+	// References to all the jars, as written in the descriptor
+	// @optional
+	Jars []string `json:"jars,omitempty"`
+	// True if all the jars have to be downloaded, ie. the descriptor
+	// can't be launched offline
+	// @optional
+	RequiresNetwork bool `json:"requiresNetwork,omitempty"`
+}
+
+// Contains information specific to DOS games run by a bundled DOSBox
+type DOSBoxInfo struct {
+	// Path of the DOSBox configuration file that starts the game
+	// (relative to the configured folder), if one was found
+	// @optional
+	ConfigPath string `json:"configPath,omitempty"`
+}
+
+// Contains information specific to Adobe AIR apps
+type AIRInfo struct {
+	// Path of the app descriptor (`META-INF/AIR/application.xml` or
+	// `*-app.xml`) relative to the configured folder
+	DescriptorPath string `json:"descriptorPath"`
+}
+
+// Contains information specific to Unity games
+type UnityInfo struct {
+	// Path of the data folder (`<product>_Data`, or `Contents/Resources/Data`
+	// in app bundles) relative to the configured folder
+	DataPath string `json:"dataPath"`
+}
+
+// Contains information specific to Unreal Engine games
+type UnrealInfo struct {
+	// Name of the project, which the folder that holds the game's
+	// binaries and data is named after
+	ProjectName string `json:"projectName"`
+	// Path of the shipping binary (like
+	// `Game/Binaries/Win64/Game-Win64-Shipping.exe`) the launcher
+	// starts, relative to the configured folder
+	// @optional
+	ShippingPath string `json:"shippingPath,omitempty"`
+}
+
+// Contains information specific to mobile app packages and ebooks,
+// which are zip archives that can't be launched on desktop
+type MobileInfo struct {
+	// The format of the package
+	Format MobileFormat `json:"format"`
+}
+
+// Which particular mobile package format
+type MobileFormat string
+
+const (
+	// Android app packages (`.apk` files)
+	MobileFormatAPK MobileFormat = "apk"
+	// iOS app packages (`.ipa` files)
+	MobileFormatIPA MobileFormat = "ipa"
+	// EPUB ebooks (`.epub` files)
+	MobileFormatEPUB MobileFormat = "epub"
+)
+
+// Contains information specific to Ren'Py games
+type RenpyInfo struct {
+	// What part this candidate plays in the Ren'Py game
+	Role RenpyRole `json:"role"`
+}
+
+// What part a candidate plays in a Ren'Py game
+type RenpyRole string
+
+const (
+	// Launchers at the root of the game (`Game.sh`, `Game.exe`, `Game.app`)
+	RenpyRoleLauncher RenpyRole = "launcher"
+	// Executables bundled in the game's `lib/` folder, like the Python interpreter
+	RenpyRoleBundled RenpyRole = "bundled"
+)
+
+// Contains information specific to archives that need extracting,
+// or disk images that need mounting
+type ArchiveInfo struct {
+	// The format of the archive
+	Format ArchiveFormat `json:"format"`
+	// True if this is only a part of a split archive (`.7z.001`,
+	// `.part1.rar`, RAR volumes...), which can't be extracted without
+	// the other parts
+	// @optional
+	Split bool `json:"split,omitempty"`
+}
+
+// Which particular archive format
+type ArchiveFormat string
+
+const (
+	// 7-zip archives (`.7z` files)
+	ArchiveFormat7z ArchiveFormat = "7z"
+	// RAR archives (`.rar` files)
+	ArchiveFormatRar ArchiveFormat = "rar"
+	// gzip-compressed files (`.gz`)
+	ArchiveFormatGzip ArchiveFormat = "gzip"
+	// gzip-compressed tarballs (`.tar.gz` or `.tgz` files)
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+	// macOS disk images (`.dmg` files)
+	ArchiveFormatDmg ArchiveFormat = "dmg"
+)
@@ -8,4 +8,13 @@ package dash
 type VerdictStats struct {
 	NumSniffs   int
 	SniffsByExt map[string]int
+
+	// Only collected if ConfigureParams.CollectSkips is set. Paths are
+	// slash-separated and relative to the configured folder, in walk order.
+
+	// BlacklistedPaths lists files that weren't sniffed because of their extension
+	BlacklistedPaths []string
+	// UnrecognizedPaths lists files that were sniffed, but aren't anything
+	// dash knows about
+	UnrecognizedPaths []string
 }
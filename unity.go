@@ -0,0 +1,81 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Unity builds ship a generic player executable, named after the product,
+// next to a `<product>_Data` folder that holds the actual game (macOS app
+// bundles keep theirs in Contents/Resources/Data). Those files tell a
+// Unity data folder apart from any other folder with that suffix.
+var unityDataFiles = []string{"data.unity3d", "globalgamemanagers"}
+
+// ...and so do those, next to the player
+var unityPlayerLibraries = []string{"unityplayer.dll", "unityplayer.so"}
+
+// detectUnityPlayers looks for Unity data folders in the container and
+// marks the native executables that run them as Unity candidates.
+func detectUnityPlayers(container *tlc.Container, candidates []*Candidate) {
+	lowerFiles := make(map[string]bool)
+	for _, f := range container.Files {
+		lowerFiles[strings.ToLower(f.Path)] = true
+	}
+
+	for _, d := range container.Dirs {
+		lowerDir := strings.ToLower(d.Path)
+		parentDir := path.Dir(lowerDir)
+
+		// for macOS bundles, the player is whatever is in Contents/MacOS,
+		// otherwise it's named after the data folder.
+		var playerDir, playerName string
+		switch {
+		case strings.HasSuffix(lowerDir, "/contents/resources/data"):
+			playerDir = path.Join(path.Dir(parentDir), "macos")
+		case strings.HasSuffix(lowerDir, "_data"):
+			playerDir = parentDir
+			playerName = strings.TrimSuffix(path.Base(lowerDir), "_data")
+		default:
+			continue
+		}
+
+		found := false
+		for _, name := range unityDataFiles {
+			if lowerFiles[path.Join(lowerDir, name)] {
+				found = true
+			}
+		}
+		for _, name := range unityPlayerLibraries {
+			if lowerFiles[path.Join(parentDir, name)] {
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		for _, c := range candidates {
+			if !isNativeFlavor(c.Flavor) {
+				continue
+			}
+
+			lowerPath := strings.ToLower(c.Path)
+			if path.Dir(lowerPath) != playerDir {
+				continue
+			}
+			if playerName != "" {
+				base := path.Base(lowerPath)
+				if strings.TrimSuffix(base, path.Ext(base)) != playerName {
+					continue
+				}
+			}
+
+			c.Flavor = FlavorUnity
+			c.UnityInfo = &UnityInfo{
+				DataPath: d.Path,
+			}
+		}
+	}
+}
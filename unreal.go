@@ -0,0 +1,86 @@
+package dash
+
+import (
+	"path"
+	"strings"
+
+	"github.com/itchio/lake/tlc"
+)
+
+// Packaged Unreal Engine games have an `Engine` folder next to a folder
+// named after the project, which holds the game's binaries and data:
+//
+//   Game.exe                                  (launcher)
+//   Engine/Binaries/Win64/CrashReportClient.exe
+//   Game/Binaries/Win64/Game-Win64-Shipping.exe
+//   Game/Content/Paks/Game-WindowsNoEditor.pak
+//
+// The launcher starts the shipping binary, and is what players run.
+
+// unrealShippingSuffix ends the name of the binaries of packaged games
+const unrealShippingSuffix = "-shipping"
+
+// detectUnrealGames marks the launchers of packaged Unreal Engine games
+// as Unreal candidates, along with the shipping binary they start. When
+// there's no launcher, shipping binaries are marked instead.
+func detectUnrealGames(container *tlc.Container, candidates []*Candidate) {
+	lowerDirs := make(map[string]bool)
+	for _, d := range container.Dirs {
+		lowerDirs[strings.ToLower(d.Path)] = true
+	}
+
+	for _, d := range container.Dirs {
+		if !strings.EqualFold(path.Base(d.Path), "Binaries") {
+			continue
+		}
+
+		projectDir := path.Dir(d.Path)
+		projectName := path.Base(projectDir)
+		rootDir := path.Dir(projectDir)
+		if projectDir == "." || strings.EqualFold(projectName, "Engine") {
+			continue
+		}
+		if !lowerDirs[strings.ToLower(path.Join(rootDir, "Engine"))] {
+			continue
+		}
+
+		binariesPrefix := strings.ToLower(d.Path) + "/"
+		var launcher *Candidate
+		var shipping []*Candidate
+		for _, c := range candidates {
+			if !isNativeFlavor(c.Flavor) {
+				continue
+			}
+
+			lowerPath := strings.ToLower(c.Path)
+			name := trimExt(path.Base(lowerPath))
+			switch {
+			case strings.HasPrefix(lowerPath, binariesPrefix) && strings.HasSuffix(name, unrealShippingSuffix):
+				shipping = append(shipping, c)
+			case path.Dir(lowerPath) == strings.ToLower(rootDir) && name == strings.ToLower(projectName):
+				launcher = c
+			}
+		}
+
+		if launcher != nil {
+			info := &UnrealInfo{ProjectName: projectName}
+			for _, s := range shipping {
+				if candidateOS(s) == candidateOS(launcher) {
+					info.ShippingPath = s.Path
+					break
+				}
+			}
+			launcher.Flavor = FlavorUnreal
+			launcher.UnrealInfo = info
+			continue
+		}
+
+		for _, s := range shipping {
+			s.Flavor = FlavorUnreal
+			s.UnrealInfo = &UnrealInfo{
+				ProjectName:  projectName,
+				ShippingPath: s.Path,
+			}
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package dash
 
 import (
+	"bytes"
 	"io"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/itchio/spellbook"
+	"github.com/itchio/wizardry/wizardry/wizutil"
 )
 
 func spellHas(spell []string, token string) bool {
@@ -15,7 +21,10 @@ func spellHas(spell []string, token string) bool {
 	return false
 }
 
-func pathDepth(path string) int {
+// PathDepth returns the number of elements in a slash-separated path, as
+// used for Candidate.Depth: "game.exe" is 1, "bin/game" is 2. A trailing
+// slash counts as an extra, empty element.
+func PathDepth(path string) int {
 	return len(strings.Split(path, "/"))
 }
 
@@ -23,10 +32,103 @@ func hasExt(path string, ext string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ext)
 }
 
-func getExt(path string) string {
+// GetExt returns the lower-cased extension of the last element of path,
+// including the dot, like ".exe". It's empty if there's no dot in the last
+// element. Only the last dot counts: "game.tar.gz" gives ".gz".
+func GetExt(path string) string {
 	return strings.ToLower(filepath.Ext(path))
 }
 
+// readBytesAt reads exactly n bytes at the given offset
+func readBytesAt(r io.ReadSeeker, offset int64, n int) ([]byte, error) {
+	_, err := r.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// hasMagicAt returns true if the bytes at offset match magic exactly
+func hasMagicAt(r io.ReadSeeker, offset int64, magic []byte) bool {
+	buf, err := readBytesAt(r, offset, len(magic))
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(buf, magic)
+}
+
+func isNativeFlavor(f Flavor) bool {
+	switch f {
+	case FlavorNativeLinux, FlavorNativeMacos, FlavorNativeWindows:
+		return true
+	}
+	return false
+}
+
+// keepsNativeInfo returns true for flavors of native executables that
+// were recognized as something more specific: engine flavors (see
+// engineFlavors), and DOSBox. Those keep the info (WindowsInfo, etc.) of
+// the executable.
+func keepsNativeInfo(f Flavor) bool {
+	return f == FlavorDOSBox || isEngineFlavor(f)
+}
+
+// candidateOS returns the operating system a candidate is tied to
+// ("windows", "linux" or "darwin"), or an empty string if it could
+// run anywhere (HTML, jars, love bundles, etc.)
+func candidateOS(c *Candidate) string {
+	switch c.Flavor {
+	case FlavorNativeLinux:
+		return "linux"
+	case FlavorNativeWindows:
+		return "windows"
+	case FlavorNativeMacos, FlavorAppMacos, FlavorPkgMacos, FlavorDmgMacos, FlavorScriptMacos:
+		return "darwin"
+	}
+
+	if keepsNativeInfo(c.Flavor) {
+		switch {
+		case c.WindowsInfo != nil:
+			return "windows"
+		case c.LinuxInfo != nil:
+			return "linux"
+		case c.MacosInfo != nil:
+			return "darwin"
+		}
+	}
+	return ""
+}
+
+// spellbook reads into a package-level buffer, so only one
+// goroutine may identify files at a time
+var spellbookMutex sync.Mutex
+
+// identifySpell returns the raw output of wizardry for a file
+func identifySpell(r io.ReadSeeker, size int64) []string {
+	spellbookMutex.Lock()
+	defer spellbookMutex.Unlock()
+
+	sr := wizutil.NewSliceReader(readerAt(r), 0, size)
+	return spellbook.Identify(sr, 0)
+}
+
+// readerAt returns r itself if it can already read at arbitrary offsets,
+// like the io.SectionReader SniffAt wraps, and adapts it otherwise.
+func readerAt(r io.ReadSeeker) io.ReaderAt {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra
+	}
+	return &readerAtFromSeeker{r}
+}
+
 // Adapt an io.ReadSeeker into an io.ReaderAt in the dumbest possible fashion
 
 type readerAtFromSeeker struct {
@@ -64,6 +166,122 @@ func selectByArch(candidates []*Candidate, a Arch) []*Candidate {
 	return res
 }
 
+// hasFlavor returns true if f is one of flavors
+func hasFlavor(flavors []Flavor, f Flavor) bool {
+	for _, ff := range flavors {
+		if ff == f {
+			return true
+		}
+	}
+	return false
+}
+
+func isAppImage(c *Candidate) bool {
+	return c.LinuxInfo != nil && c.LinuxInfo.AppImage
+}
+
+// hasArchSlice returns true if a candidate is built for the given
+// architecture, either directly or as one of the slices of a
+// universal binary.
+func hasArchSlice(c *Candidate, a Arch) bool {
+	if c.Arch == a {
+		return true
+	}
+	if c.MacosInfo != nil {
+		for _, sliceArch := range c.MacosInfo.Archs {
+			if sliceArch == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isLinuxOnlyScript returns true for scripts that mention linux-only
+// paths or tools
+func isLinuxOnlyScript(c *Candidate) bool {
+	return c.Flavor == FlavorScript && c.ScriptInfo != nil && c.ScriptInfo.LinuxOnly
+}
+
+// isLauncherScript returns true for shell scripts, and scripts
+// whose interpreter we couldn't figure out
+func isLauncherScript(c *Candidate) bool {
+	if c.Flavor != FlavorScript {
+		return false
+	}
+	if c.ScriptInfo == nil {
+		return true
+	}
+	switch c.ScriptInfo.Kind {
+	case "", ScriptKindShell:
+		return true
+	}
+	return false
+}
+
+// hasExecutableBit returns true for unix executables that have their
+// executable bit set. FixPermissions clears Mode, so it only works
+// before that.
+func hasExecutableBit(c *Candidate) bool {
+	switch c.Flavor {
+	case FlavorNativeLinux, FlavorNativeMacos, FlavorScript, FlavorScriptMacos:
+		return c.Mode&0100 != 0
+	}
+	return false
+}
+
+// Suffixes Unity and Godot give linux executables, and the arch they're for
+var linuxArchSuffixes = map[string]Arch{
+	".x86":     Arch386,
+	".x86_64":  ArchAmd64,
+	".aarch64": ArchArm64,
+	".arm64":   ArchArm64,
+}
+
+// hasArchSuffix returns true for linux executables named after the arch
+// being filtered for (or their own arch, if there's no arch filter), like
+// `Game.x86_64`.
+func hasArchSuffix(c *Candidate, archFilter string) bool {
+	if candidateOS(c) != "linux" {
+		return false
+	}
+	suffixArch, ok := linuxArchSuffixes[GetExt(c.Path)]
+	if !ok {
+		return false
+	}
+	if c.Arch != "" && c.Arch != suffixArch {
+		// misnamed
+		return false
+	}
+	if archFilter == "" {
+		return true
+	}
+	return string(suffixArch) == archFilter
+}
+
+// isConventionalLauncher returns a filter that keeps launcher scripts
+// named after one of names (`start.sh`, `run`...), see FilterParams.LauncherNames
+func isConventionalLauncher(names []string) candidateFilter {
+	return func(c *Candidate) bool {
+		if !isLauncherScript(c) {
+			return false
+		}
+
+		base := strings.ToLower(path.Base(c.Path))
+		base = strings.TrimSuffix(base, path.Ext(base))
+		for _, name := range names {
+			if strings.ToLower(name) == base {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isDOSExecutable(c *Candidate) bool {
+	return c.WindowsInfo != nil && c.WindowsInfo.DOS
+}
+
 type candidateFilter func(candidate *Candidate) bool
 
 func selectByFunc(candidates []*Candidate, f candidateFilter) []*Candidate {
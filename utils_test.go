@@ -0,0 +1,29 @@
+package dash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetExt(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualValues(".exe", GetExt("Game.EXE"))
+	assert.EqualValues(".exe", GetExt("bin/game.exe"))
+	assert.EqualValues(".gz", GetExt("game.tar.gz"))
+	assert.EqualValues(".x86_64", GetExt("Game.x86_64"))
+	assert.EqualValues("", GetExt("game"))
+	assert.EqualValues("", GetExt("game.app/Contents/MacOS/game"))
+	assert.EqualValues("", GetExt("game.app/"))
+	assert.EqualValues(".", GetExt("game."))
+	assert.EqualValues(".bashrc", GetExt(".bashrc"))
+}
+
+func Test_PathDepth(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualValues(1, PathDepth("game.exe"))
+	assert.EqualValues(2, PathDepth("bin/game"))
+	assert.EqualValues(4, PathDepth("Game.app/Contents/MacOS/Game"))
+	assert.EqualValues(2, PathDepth("bin/"))
+	assert.EqualValues(1, PathDepth(""))
+}
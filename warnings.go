@@ -0,0 +1,47 @@
+package dash
+
+import (
+	"fmt"
+
+	"github.com/itchio/headway/state"
+)
+
+// warningLog logs problems to a consumer, and records them for the
+// verdict's Warnings
+type warningLog struct {
+	consumer *state.Consumer
+	warnings []VerdictWarning
+}
+
+// newWarningLog returns a warningLog that starts with a copy of warnings
+func newWarningLog(consumer *state.Consumer, warnings []VerdictWarning) *warningLog {
+	return &warningLog{
+		consumer: consumer,
+		warnings: append([]VerdictWarning(nil), warnings...),
+	}
+}
+
+// warnf logs a warning about path, which may be empty
+func (wl *warningLog) warnf(path string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	wl.consumer.Warnf("%s", msg)
+	wl.add(VerdictWarning{Path: path, Message: msg, Severity: WarningSeverityWarning})
+}
+
+// logf logs something worth knowing about path, which may be empty
+func (wl *warningLog) logf(path string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	wl.consumer.Logf("%s", msg)
+	wl.add(VerdictWarning{Path: path, Message: msg, Severity: WarningSeverityInfo})
+}
+
+// add records a warning, unless it was already recorded: Filter may
+// come across the same problem once per OS it filters for
+func (wl *warningLog) add(w VerdictWarning) {
+	for _, existing := range wl.warnings {
+		if existing == w {
+			return
+		}
+	}
+	wl.warnings = append(wl.warnings, w)
+}
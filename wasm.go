@@ -0,0 +1,62 @@
+package dash
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/itchio/lake"
+	"github.com/itchio/lake/tlc"
+)
+
+// WebAssembly modules start with "\0asm"
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6D}
+
+// detectWasmModules looks for WebAssembly modules next to HTML
+// candidates. Only the first few bytes of .wasm files in the same
+// folder as an HTML candidate are read.
+func detectWasmModules(pool lake.Pool, container *tlc.Container, candidates []*Candidate) error {
+	wasmByDir := make(map[string][]int64)
+	for fileIndex, f := range container.Files {
+		if hasExt(f.Path, ".wasm") {
+			dir := path.Dir(strings.ToLower(f.Path))
+			wasmByDir[dir] = append(wasmByDir[dir], int64(fileIndex))
+		}
+	}
+	if len(wasmByDir) == 0 {
+		return nil
+	}
+
+	for _, c := range candidates {
+		if c.Flavor != FlavorHTML {
+			continue
+		}
+
+		fileIndices := wasmByDir[path.Dir(strings.ToLower(c.Path))]
+		sort.Slice(fileIndices, func(i, j int) bool {
+			return container.Files[fileIndices[i]].Size > container.Files[fileIndices[j]].Size
+		})
+
+		for _, fileIndex := range fileIndices {
+			r, err := pool.GetReadSeeker(fileIndex)
+			if err != nil {
+				return err
+			}
+			if !hasMagicAt(r, 0, wasmMagic) {
+				continue
+			}
+
+			if c.HTMLInfo == nil {
+				c.HTMLInfo = &HTMLInfo{}
+			}
+			c.HTMLInfo.WasmPath = container.Files[fileIndex].Path
+			break
+		}
+	}
+
+	return nil
+}
+
+func hasWasmModule(c *Candidate) bool {
+	return c.HTMLInfo != nil && c.HTMLInfo.WasmPath != ""
+}
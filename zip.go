@@ -7,41 +7,67 @@ import (
 	"strings"
 
 	"github.com/itchio/arkive/zip"
+	"github.com/pkg/errors"
 )
 
-func sniffZip(r io.ReadSeeker, size int64) (*Candidate, error) {
-	ra := &readerAtFromSeeker{r}
+// sniffZip returns a jar candidate for zip archives with a main class in
+// their manifest, and a mobile candidate for Android and iOS packages and
+// EPUB ebooks, which are zip archives too.
+func sniffZip(r io.ReadSeeker, size int64, lowerPath string) (*Candidate, error) {
+	ra := readerAt(r)
 
 	zr, err := zip.NewReader(ra, size)
 	if err != nil {
-		// not a zip, probably
-		return nil, nil
+		if !isZipFormatError(err) {
+			return nil, errors.Wrap(err, "reading zip central directory")
+		}
+		// it starts like a zip, but its central directory is missing or
+		// damaged, which happens with partial uploads. it can only be
+		// recognized by its extension.
+		return corruptZipCandidate(lowerPath), nil
+	}
+
+	// some android packages have a jar manifest, and a main class
+	// in it, so look for mobile packages first
+	if format := mobileFormat(zr, lowerPath); format != "" {
+		return &Candidate{
+			Flavor: FlavorMobile,
+			MobileInfo: &MobileInfo{
+				Format: format,
+			},
+		}, nil
 	}
 
 	for _, f := range zr.File {
 		path := filepath.ToSlash(filepath.Clean(filepath.ToSlash(f.Name)))
-		if path == "META-INF/MANIFEST.MF" {
+		if strings.EqualFold(path, "META-INF/MANIFEST.MF") {
 			rc, err := f.Open()
-			if err != nil {
+			if err == zip.ErrAlgorithm {
 				// :(
 				return nil, nil
+			} else if err != nil {
+				if !isZipFormatError(err) {
+					return nil, errors.Wrap(err, "opening jar manifest")
+				}
+				return &Candidate{
+					Flavor:  FlavorJar,
+					Corrupt: true,
+					JarInfo: &JarInfo{},
+				}, nil
 			}
 			defer rc.Close()
 
-			s := bufio.NewScanner(rc)
-
-			for s.Scan() {
-				tokens := strings.SplitN(s.Text(), ":", 2)
-				if len(tokens) > 0 && tokens[0] == "Main-Class" {
-					mainClass := strings.TrimSpace(tokens[1])
-					res := &Candidate{
-						Flavor: FlavorJar,
-						JarInfo: &JarInfo{
-							MainClass: mainClass,
-						},
-					}
-					return res, nil
+			manifest := parseManifest(rc)
+			mainClass := manifest["main-class"]
+			if mainClass != "" {
+				res := &Candidate{
+					Flavor: FlavorJar,
+					JarInfo: &JarInfo{
+						MainClass: mainClass,
+						Runnable:  true,
+					},
 				}
+				return res, nil
 			}
 
 			// we found the manifest, even if we couldn't read it
@@ -50,5 +76,128 @@ func sniffZip(r io.ReadSeeker, size int64) (*Candidate, error) {
 		}
 	}
 
+	// class files alone aren't enough, without a main class
+	// there's nothing to run.
 	return nil, nil
 }
+
+// isZipFormatError returns true for errors that mean a zip archive is
+// truncated or damaged, as opposed to errors reading it
+func isZipFormatError(err error) bool {
+	switch err {
+	case zip.ErrFormat, zip.ErrChecksum, io.ErrUnexpectedEOF, io.EOF:
+		return true
+	}
+	// the reader reports impossible entry counts without a sentinel
+	return strings.HasPrefix(err.Error(), "archive/zip: ")
+}
+
+// corruptZipCandidate returns a corrupt candidate for a damaged zip
+// archive that has the extension of a jar or of a mobile package, or nil
+// for other extensions.
+func corruptZipCandidate(lowerPath string) *Candidate {
+	switch filepath.Ext(lowerPath) {
+	case ".jar":
+		return &Candidate{
+			Flavor:  FlavorJar,
+			Corrupt: true,
+			JarInfo: &JarInfo{},
+		}
+	}
+	if format, ok := mobileFormatByExt[filepath.Ext(lowerPath)]; ok {
+		return &Candidate{
+			Flavor:  FlavorMobile,
+			Corrupt: true,
+			MobileInfo: &MobileInfo{
+				Format: format,
+			},
+		}
+	}
+	return nil
+}
+
+// epubMimetype is the contents of the `mimetype` entry of EPUB ebooks
+const epubMimetype = "application/epub+zip"
+
+var mobileFormatByExt = map[string]MobileFormat{
+	".apk":  MobileFormatAPK,
+	".ipa":  MobileFormatIPA,
+	".epub": MobileFormatEPUB,
+}
+
+// mobileFormat returns the format of a zip archive that's a mobile package
+// or an ebook, or an empty string if it isn't one. They're recognized by
+// their extension, or by entries only they have: `AndroidManifest.xml`
+// for Android, a `Payload/*.app/` folder for iOS and a `mimetype` entry
+// for EPUB.
+func mobileFormat(zr *zip.Reader, lowerPath string) MobileFormat {
+	if format, ok := mobileFormatByExt[filepath.Ext(lowerPath)]; ok {
+		return format
+	}
+
+	for _, f := range zr.File {
+		path := filepath.ToSlash(filepath.Clean(filepath.ToSlash(f.Name)))
+		switch {
+		case path == "AndroidManifest.xml":
+			return MobileFormatAPK
+		case strings.HasPrefix(path, "Payload/"):
+			tokens := strings.SplitN(path, "/", 3)
+			if len(tokens) == 3 && strings.HasSuffix(strings.ToLower(tokens[1]), ".app") {
+				return MobileFormatIPA
+			}
+		case path == "mimetype" && f.UncompressedSize64 == uint64(len(epubMimetype)):
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			buf := make([]byte, len(epubMimetype))
+			_, err = io.ReadFull(rc, buf)
+			rc.Close()
+			if err == nil && string(buf) == epubMimetype {
+				return MobileFormatEPUB
+			}
+		}
+	}
+	return ""
+}
+
+// parseManifest reads the main section of a jar manifest, and returns
+// its attributes. Attribute names are lower-cased, since they're
+// case-insensitive.
+//
+// Lines are at most 72 bytes long, so long values (like fully-qualified
+// class names) are continued on the next line, which starts with a space.
+// cf. https://docs.oracle.com/javase/8/docs/technotes/guides/jar/jar.html#JAR_Manifest
+func parseManifest(r io.Reader) map[string]string {
+	attrs := make(map[string]string)
+
+	var lastKey string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if line == "" {
+			// end of the main section
+			break
+		}
+
+		if strings.HasPrefix(line, " ") {
+			if lastKey != "" {
+				attrs[lastKey] += line[1:]
+			}
+			continue
+		}
+
+		tokens := strings.SplitN(line, ":", 2)
+		if len(tokens) != 2 {
+			lastKey = ""
+			continue
+		}
+		lastKey = strings.ToLower(strings.TrimSpace(tokens[0]))
+		attrs[lastKey] = strings.TrimLeft(tokens[1], " ")
+	}
+
+	for k, v := range attrs {
+		attrs[k] = strings.TrimSpace(v)
+	}
+	return attrs
+}
@@ -0,0 +1,57 @@
+package dash
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	manifest := parseManifest(strings.NewReader(strings.Join([]string{
+		"Manifest-Version: 1.0",
+		"Created-By: 1.8.0_202 (Oracle Corporation)",
+		"main-class: com.badlogicgames.superjumper.desktop.DesktopLaunch",
+		" er",
+		"",
+		"Name: com/badlogicgames/superjumper/",
+		"Main-Class: not.the.right.One",
+	}, "\r\n")))
+
+	assert.EqualValues("1.0", manifest["manifest-version"])
+	assert.EqualValues("com.badlogicgames.superjumper.desktop.DesktopLauncher", manifest["main-class"])
+
+	manifest = parseManifest(strings.NewReader("Manifest-Version: 1.0\n"))
+	assert.EqualValues("", manifest["main-class"])
+}
+
+type failingReader struct {
+	io.ReadSeeker
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("disk on fire")
+}
+
+func Test_SniffZipErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := sniffZip(bytes.NewReader([]byte("PK\x03\x04 and then nothing")), 21, "game.jar")
+	assert.NoError(err, "truncation isn't an error")
+	if assert.NotNil(c, "still recognizes jar by its extension") {
+		assert.EqualValues(FlavorJar, c.Flavor)
+		assert.True(c.Corrupt, "is flagged as corrupt")
+	}
+
+	c, err = sniffZip(bytes.NewReader([]byte("PK\x03\x04 and then nothing")), 21, "data.zip")
+	assert.NoError(err, "truncation isn't an error")
+	assert.Nil(c, "plain zips aren't candidates")
+
+	_, err = sniffZip(&failingReader{bytes.NewReader(make([]byte, 1024))}, 1024, "game.jar")
+	assert.Error(err, "read errors are errors")
+}